@@ -1,3 +1,5 @@
 module github.com/paxaf/BrandScoutTest
 
 go 1.23.0
+
+require golang.org/x/text v0.28.0
@@ -7,53 +7,339 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/paxaf/BrandScoutTest/internal/cluster"
 	"github.com/paxaf/BrandScoutTest/internal/controller"
 	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
-	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/replica"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	"github.com/paxaf/BrandScoutTest/internal/repo/audit"
+	_ "github.com/paxaf/BrandScoutTest/internal/repo/btree"
+	_ "github.com/paxaf/BrandScoutTest/internal/repo/engine"
 	"github.com/paxaf/BrandScoutTest/internal/usecase"
 )
 
 const (
-	appHost                      = "0.0.0.0"
-	appPort                      = "8080"
-	defaultTimeout time.Duration = 5 * time.Second
+	appHost                       = "0.0.0.0"
+	defaultPort                   = "8080"
+	defaultTimeout  time.Duration = 5 * time.Second
+	idempotencyTTL  time.Duration = 24 * time.Hour
+	dailyWindow                   = 30
+	trashRetention  time.Duration = 30 * 24 * time.Hour
+	purgeInterval   time.Duration = time.Hour
+	leaderPoll      time.Duration = time.Second
+	historyCap                    = 100
+	defaultAuditLog               = "audit.log"
+	defaultBackend                = "memory"
+)
+
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacySunset       = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
 )
 
 type App struct {
 	apiServer *http.Server
+	purger    trashPurger
+	expirer   quoteExpirer
+	auditLog  *audit.FileLog
+	storage   repo.Store
+	follower  *replica.Follower
+	following chan struct{}
+	node      *cluster.Node
+	running   chan struct{}
+}
+
+type trashPurger interface {
+	PurgeTrash(retention time.Duration) (int, error)
+}
+
+type quoteExpirer interface {
+	ExpireQuotes() int
+	NextExpiry() (time.Time, bool)
+	ExpiryRescheduled() <-chan struct{}
 }
 
 func New() (*App, error) {
 	app := &App{}
-	repo, err := storage.NewEngine()
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = defaultBackend
+	}
+	cfg, err := storageConfig()
+	if err != nil {
+		return nil, err
+	}
+	store, err := repo.Open(backend, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed init repo: %w", err)
 	}
-	service := usecase.New(repo)
+	app.storage = store
+	auditPath := os.Getenv("AUDIT_LOG")
+	if auditPath == "" {
+		auditPath = defaultAuditLog
+	}
+	app.auditLog, err = audit.Open(auditPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed open audit log: %w", err)
+	}
+	leader := strings.TrimSuffix(os.Getenv("REPLICA_OF"), "/")
+	clusterID := os.Getenv("CLUSTER_ID")
+	if clusterID != "" {
+		if leader != "" {
+			return nil, errors.New("CLUSTER_ID and REPLICA_OF cannot be used together")
+		}
+		if store, err = app.joinCluster(clusterID, backend, cfg); err != nil {
+			return nil, err
+		}
+	}
+	service := usecase.New(store, store, store, store, store,
+		usecase.WithDailyWindow(dailyWindow),
+		usecase.WithAudit(app.auditLog),
+	)
+	app.purger = service
+	app.expirer = service
+	if app.node != nil {
+		// Writes proposed through other nodes never pass this usecase.
+		app.node.OnWrite(service.ScheduleExpiry)
+	}
+	if leader == "" && app.node == nil {
+		created, err := service.MigrateAuthors(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed migrate authors: %w", err)
+		}
+		if created > 0 {
+			log.Printf("migrated %d authors from existing quotes", created)
+		}
+	}
 	handler := controller.New(service)
-	http.Handle("/quotes", middleware.SimpleMiddleware(
-		http.HandlerFunc(handler.GetAll),
-		http.HandlerFunc(handler.ByAutor),
-		http.HandlerFunc(handler.Add)))
-	http.HandleFunc("/quotes/random", handler.GetRand)
-	http.HandleFunc("/quotes/", handler.Delete)
-	addr := net.JoinHostPort(appHost, appPort)
+	idempotency := middleware.Idempotency(middleware.NewIdempotencyStore(idempotencyTTL))
+	router := middleware.NewRouter()
+	registerV1(router.Group("/v1"), handler, idempotency)
+	registerV1(router.Group("", middleware.Deprecated(legacyDeprecatedAt, legacySunset, "/v1")), handler, idempotency)
+	router.HandleFunc("admin.audit", http.MethodGet, "/admin/audit", handler.Audit)
+	reporter, _ := app.storage.(repo.StatsReporter)
+	router.HandleFunc("metrics", http.MethodGet, "/metrics", controller.Metrics(reporter, app.auditLog))
+	if changes, ok := app.storage.(repo.ChangeLog); ok {
+		router.HandleFunc("replication.checkpoint", http.MethodGet, "/replication/checkpoint", controller.Checkpoint(changes))
+		router.HandleFunc("replication.log", http.MethodGet, "/replication/log", controller.Commits(changes))
+	}
+	if feed, ok := app.storage.(repo.ChangeFeed); ok {
+		router.HandleFunc("changes.list", http.MethodGet, "/changes", controller.Changes(feed))
+		router.HandleFunc("changes.snapshot", http.MethodGet, "/changes/snapshot", controller.ChangesSnapshot(feed))
+	}
+	var root http.Handler = router
+	if leader != "" {
+		root, err = app.follow(leader, router)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if app.node != nil {
+		root = app.serveCluster(router)
+	}
+	port := os.Getenv("APP_PORT")
+	if port == "" {
+		port = defaultPort
+	}
+	addr := net.JoinHostPort(appHost, port)
+	// Requests see the server shutting down, so streams and long polls end
+	// instead of holding up Shutdown.
+	base, cancel := context.WithCancel(context.Background())
 	app.apiServer = &http.Server{
 		Addr:              addr,
-		Handler:           http.DefaultServeMux,
+		Handler:           middleware.RequestID(middleware.Admin(os.Getenv("ADMIN_TOKEN"))(root)),
 		ReadHeaderTimeout: defaultTimeout,
+		BaseContext:       func(net.Listener) context.Context { return base },
 	}
+	app.apiServer.RegisterOnShutdown(cancel)
 	return app, nil
 }
 
+// follow turns the app into a read replica of leader. Writes are refused,
+// or proxied to the leader when REPLICA_PROXY_WRITES is true.
+func (app *App) follow(leader string, router *middleware.Router) (http.Handler, error) {
+	store, ok := app.storage.(repo.Replica)
+	if !ok {
+		return nil, errors.New("storage backend cannot follow a leader")
+	}
+	target, err := url.Parse(leader)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid REPLICA_OF %q", leader)
+	}
+	app.follower = replica.New(leader, store, replica.WithToken(os.Getenv("ADMIN_TOKEN")))
+	router.HandleFunc("replication.status", http.MethodGet, "/replication/status", controller.ReplicaStatus(app.follower))
+	var proxy http.Handler
+	if os.Getenv("REPLICA_PROXY_WRITES") == "true" {
+		proxy = httputil.NewSingleHostReverseProxy(target)
+	}
+	return middleware.ReadOnly(leader, proxy)(router), nil
+}
+
+// joinCluster makes the app a member of a raft cluster. The store starts
+// empty and is rebuilt from the raft log, so only the memory backend
+// without its own write-ahead log can back it. Limits are refused too:
+// they are checked only when a command is applied, after it already took
+// its place in the raft log.
+func (app *App) joinCluster(id, backend string, cfg repo.Config) (repo.Store, error) {
+	nodeID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || nodeID == 0 {
+		return nil, fmt.Errorf("invalid CLUSTER_ID %q", id)
+	}
+	machine, ok := app.storage.(cluster.StateMachine)
+	if backend != defaultBackend || cfg.Path != "" || !ok {
+		return nil, errors.New("clustered mode needs the memory backend without STORAGE_PATH")
+	}
+	if cfg.MaxEntries > 0 || cfg.MaxBytes > 0 {
+		return nil, errors.New("clustered mode does not support STORAGE_MAX_ENTRIES and STORAGE_MAX_BYTES")
+	}
+	peers, err := clusterPeers(os.Getenv("CLUSTER_PEERS"))
+	if err != nil {
+		return nil, err
+	}
+	dir := os.Getenv("CLUSTER_DIR")
+	if dir == "" {
+		dir = "."
+	}
+	opts := []cluster.Option{
+		cluster.WithStorage(filepath.Join(dir, "raft-"+id+".log")),
+		cluster.WithToken(os.Getenv("ADMIN_TOKEN")),
+	}
+	if os.Getenv("CLUSTER_JOIN") == "true" {
+		opts = append(opts, cluster.Joining())
+	} else if !slices.ContainsFunc(peers, func(peer entity.ClusterMember) bool { return peer.ID == nodeID }) {
+		return nil, fmt.Errorf("CLUSTER_PEERS does not list node %d", nodeID)
+	}
+	app.node, err = cluster.New(nodeID, peers, machine, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed start cluster node: %w", err)
+	}
+	return cluster.NewStore(app.node), nil
+}
+
+// serveCluster routes raft traffic around the API, sends writes to the
+// leader and holds reads until the node caught up with it.
+func (app *App) serveCluster(router *middleware.Router) http.Handler {
+	router.HandleFunc("cluster.members.add", http.MethodPost, "/cluster/members", controller.AddMember(app.node))
+	router.HandleFunc("cluster.members.remove", http.MethodDelete, "/cluster/members/{id}", controller.RemoveMember(app.node))
+	proxy := os.Getenv("CLUSTER_PROXY_WRITES") == "true"
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /raft/messages", controller.RaftMessages(app.node))
+	mux.HandleFunc("GET /cluster/status", controller.ClusterStatus(app.node))
+	mux.Handle("/", middleware.LeaderWrites(app.node.Leader, proxy)(middleware.Linearizable(app.node.Barrier)(router)))
+	return mux
+}
+
+// clusterPeers parses "1=http://host1:8080,2=http://host2:8080".
+func clusterPeers(value string) ([]entity.ClusterMember, error) {
+	var peers []entity.ClusterMember
+	for _, item := range strings.Split(value, ",") {
+		id, addr, ok := strings.Cut(strings.TrimSpace(item), "=")
+		nodeID, err := strconv.ParseUint(id, 10, 64)
+		if !ok || err != nil || nodeID == 0 || addr == "" {
+			return nil, fmt.Errorf("invalid CLUSTER_PEERS entry %q", item)
+		}
+		peers = append(peers, entity.ClusterMember{ID: nodeID, Addr: strings.TrimSuffix(addr, "/")})
+	}
+	return peers, nil
+}
+
+func storageConfig() (repo.Config, error) {
+	cfg := repo.Config{
+		Path:       os.Getenv("STORAGE_PATH"),
+		HistoryCap: historyCap,
+	}
+	var err error
+	if value := os.Getenv("STORAGE_MAX_ENTRIES"); value != "" {
+		if cfg.MaxEntries, err = strconv.Atoi(value); err != nil {
+			return cfg, fmt.Errorf("invalid STORAGE_MAX_ENTRIES: %w", err)
+		}
+	}
+	if value := os.Getenv("STORAGE_MAX_BYTES"); value != "" {
+		if cfg.MaxBytes, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid STORAGE_MAX_BYTES: %w", err)
+		}
+	}
+	if value := os.Getenv("STORAGE_LOG_RETENTION"); value != "" {
+		if cfg.LogRetention, err = strconv.Atoi(value); err != nil {
+			return cfg, fmt.Errorf("invalid STORAGE_LOG_RETENTION: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+func registerV1(group *middleware.Group, handler *controller.UsecaseHandler, idempotency func(http.Handler) http.Handler) {
+	group.HandleFunc("quotes.list", http.MethodGet, "/quotes", handler.GetAll)
+	group.Handle("quotes.create", http.MethodPost, "/quotes", idempotency(http.HandlerFunc(handler.Add)))
+	group.Handle("quotes.batch", http.MethodPost, "/quotes:batch", idempotency(http.HandlerFunc(handler.Batch)))
+	group.HandleFunc("quotes.random", http.MethodGet, "/quotes/random", handler.GetRand)
+	group.HandleFunc("quotes.daily", http.MethodGet, "/quotes/daily", handler.Daily)
+	group.HandleFunc("quotes.daily.pins", http.MethodGet, "/quotes/daily/pins", handler.DailyPins)
+	group.HandleFunc("quotes.daily.pin", http.MethodPut, "/quotes/daily/{date}", handler.PinDaily)
+	group.HandleFunc("quotes.daily.unpin", http.MethodDelete, "/quotes/daily/{date}", handler.UnpinDaily)
+	group.HandleFunc("quotes.trash", http.MethodGet, "/quotes/trash", handler.Trash)
+	group.HandleFunc("quotes.restore", http.MethodPost, "/quotes/{id}/restore", handler.Restore)
+	group.HandleFunc("quotes.revisions", http.MethodGet, "/quotes/{id}/revisions", handler.Revisions)
+	group.HandleFunc("quotes.revision", http.MethodGet, "/quotes/{id}/revisions/{n}", handler.Revision)
+	group.HandleFunc("quotes.revert", http.MethodPost, "/quotes/{id}/revisions/{n}/revert", handler.Revert)
+	group.HandleFunc("quotes.duplicates", http.MethodGet, "/quotes/duplicates", handler.Duplicates)
+	group.HandleFunc("quotes.get", http.MethodGet, "/quotes/{id}", handler.GetByID)
+	group.HandleFunc("quotes.merge", http.MethodPost, "/quotes/{id}/merge", handler.Merge)
+	group.HandleFunc("quotes.update", http.MethodPut, "/quotes/{id}", handler.Update)
+	group.HandleFunc("quotes.patch", http.MethodPatch, "/quotes/{id}", handler.Patch)
+	group.HandleFunc("quotes.delete", http.MethodDelete, "/quotes/{id}", handler.Delete)
+	group.HandleFunc("authors.aliases.list", http.MethodGet, "/authors/aliases", handler.Aliases)
+	group.HandleFunc("authors.aliases.set", http.MethodPut, "/authors/aliases/{alias}", handler.SetAlias)
+	group.HandleFunc("authors.aliases.delete", http.MethodDelete, "/authors/aliases/{alias}", handler.DeleteAlias)
+	group.HandleFunc("authors.list", http.MethodGet, "/authors", handler.Authors)
+	group.HandleFunc("authors.create", http.MethodPost, "/authors", handler.CreateAuthor)
+	group.HandleFunc("authors.get", http.MethodGet, "/authors/{id}", handler.GetAuthor)
+	group.HandleFunc("authors.update", http.MethodPut, "/authors/{id}", handler.UpdateAuthor)
+	group.HandleFunc("authors.delete", http.MethodDelete, "/authors/{id}", handler.DeleteAuthor)
+	group.HandleFunc("authors.quotes", http.MethodGet, "/authors/{id}/quotes", handler.AuthorQuotes)
+	group.HandleFunc("tags.list", http.MethodGet, "/tags", handler.Tags)
+	group.HandleFunc("tags.merge", http.MethodPost, "/tags/merge", handler.MergeTags)
+	group.HandleFunc("tags.rename", http.MethodPost, "/tags/{tag}/rename", handler.RenameTag)
+}
+
 func (app *App) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// stopped gets the error a cluster node stopped with; the app exits
+	// instead of serving without it.
+	stopped := make(chan error, 1)
+	if app.node != nil {
+		app.running = make(chan struct{})
+		go func() {
+			defer close(app.running)
+			if err := app.node.Run(ctx); err != nil {
+				stopped <- err
+			}
+		}()
+	}
+	if app.follower != nil {
+		app.following = make(chan struct{})
+		go func() {
+			defer close(app.following)
+			app.follower.Run(ctx)
+		}()
+	} else {
+		go app.purgeTrash(ctx)
+		go app.expireQuotes(ctx)
+	}
+
 	go func() {
 		log.Println("API server started successfully. " + "Address: " + app.apiServer.Addr)
 		if err := app.apiServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -61,16 +347,98 @@ func (app *App) Run() error {
 		}
 	}()
 
-	<-ctx.Done()
+	select {
+	case <-ctx.Done():
+	case err := <-stopped:
+		return fmt.Errorf("cluster node stopped: %w", err)
+	}
 	log.Printf("Received shutdown signal")
 
 	return nil
 }
 
+func (app *App) purgeTrash(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !app.leading() {
+				continue
+			}
+			if _, err := app.purger.PurgeTrash(trashRetention); err != nil {
+				log.Printf("failed to purge trash: %v", err)
+			}
+		}
+	}
+}
+
+// expireQuotes sleeps until the earliest scheduled expiry, or until a quote
+// is scheduled before it, instead of polling. A cluster node that is not the
+// leader only polls for leadership: the leader's expiries reach it through
+// the log.
+func (app *App) expireQuotes(ctx context.Context) {
+	for {
+		var (
+			timer *time.Timer
+			due   <-chan time.Time
+			moved <-chan struct{}
+		)
+		if app.leading() {
+			app.expirer.ExpireQuotes()
+			if at, ok := app.expirer.NextExpiry(); ok {
+				timer = time.NewTimer(time.Until(at))
+				due = timer.C
+			}
+			moved = app.expirer.ExpiryRescheduled()
+		} else {
+			timer = time.NewTimer(leaderPoll)
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-due:
+		case <-moved:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// leading reports whether this instance runs the background jobs. Only the
+// leader of a cluster does, so the nodes do not race each other's writes.
+func (app *App) leading() bool {
+	if app.node == nil {
+		return true
+	}
+	_, ok := app.node.Leader()
+	return ok
+}
+
 func (app *App) Close() error {
 	err := app.apiServer.Shutdown(context.Background())
 	if err != nil {
 		return err
 	}
-	return nil
+	if err := app.auditLog.Close(); err != nil {
+		return err
+	}
+	if app.following != nil {
+		<-app.following
+	}
+	if app.node != nil {
+		if app.running != nil {
+			<-app.running
+		}
+		if err := app.node.Close(); err != nil {
+			return err
+		}
+	}
+	return app.storage.Close()
 }
@@ -0,0 +1,194 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/app"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+)
+
+const token = "secret"
+
+// TestMain doubles as the server binary: the replication test starts
+// copies of itself with serveEnv set, one process per node.
+const serveEnv = "QUOTES_TEST_SERVE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serveEnv) == "" {
+		os.Exit(m.Run())
+	}
+	server, err := app.New()
+	if err != nil {
+		log.Fatalf("failed creating app: %v", err)
+	}
+	if err := server.Run(); err != nil {
+		log.Fatalf("error running app: %v", err)
+	}
+	if err := server.Close(); err != nil {
+		log.Fatalf("error graceful shutdown: %v", err)
+	}
+	os.Exit(0)
+}
+
+type node struct {
+	url string
+	cmd *exec.Cmd
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func start(t *testing.T, dir, name string, env ...string) *node {
+	t.Helper()
+	port := freePort(t)
+	output, err := os.OpenFile(filepath.Join(dir, name+".out"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer output.Close()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), serveEnv+"=1", "APP_PORT="+port, "ADMIN_TOKEN="+token, "AUDIT_LOG="+name+".audit")
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout, cmd.Stderr = output, output
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %s: %v", name, err)
+	}
+	n := &node{url: "http://127.0.0.1:" + port, cmd: cmd}
+	t.Cleanup(n.stop)
+	eventually(t, name+" to start", func() bool {
+		resp, err := http.Get(n.url + "/v1/quotes")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	})
+	return n
+}
+
+func (n *node) stop() {
+	if n.cmd.ProcessState != nil {
+		return
+	}
+	n.cmd.Process.Signal(syscall.SIGTERM)
+	n.cmd.Wait()
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func create(t *testing.T, url, phrase string) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(v1.Quote{Author: "Author", Phrase: phrase})
+	resp, err := http.Post(url+"/v1/quotes", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create quote: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func count(url string) int {
+	resp, err := http.Get(url + "/v1/quotes")
+	if err != nil {
+		return -1
+	}
+	defer resp.Body.Close()
+	var quotes v1.QuoteResponse
+	json.NewDecoder(resp.Body).Decode(&quotes)
+	return len(quotes.Quotes)
+}
+
+func status(t *testing.T, url string) v1.ReplicaStatus {
+	t.Helper()
+	resp, err := http.Get(url + "/replication/status")
+	if err != nil {
+		t.Fatalf("Failed to read replica status: %v", err)
+	}
+	defer resp.Body.Close()
+	var res v1.ReplicaStatus
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode replica status: %v", err)
+	}
+	return res
+}
+
+func TestReplication(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts server processes")
+	}
+	dir := t.TempDir()
+	leader := start(t, dir, "leader")
+	for i := range 20 {
+		if resp := create(t, leader.url, fmt.Sprint("Quote ", i)); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Leader refused a write: %s", resp.Status)
+		}
+	}
+
+	followerEnv := []string{"REPLICA_OF=" + leader.url, "STORAGE_BACKEND=wal", "STORAGE_PATH=follower.wal"}
+	follower := start(t, dir, "follower", followerEnv...)
+	eventually(t, "the follower to bootstrap", func() bool { return count(follower.url) == 20 })
+	if st := status(t, follower.url); st.Bootstraps != 1 || st.Lag != 0 {
+		t.Errorf("Unexpected replica status: %+v", st)
+	}
+
+	resp, err := http.Get(follower.url + "/v1/quotes/random")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Follower does not serve random quotes: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	resp = create(t, follower.url, "Written to a replica")
+	if resp.StatusCode != http.StatusMisdirectedRequest || resp.Header.Get("Location") != leader.url+"/v1/quotes" {
+		t.Errorf("Follower accepted a write: %s %q", resp.Status, resp.Header.Get("Location"))
+	}
+
+	follower.stop()
+	for i := range 5 {
+		create(t, leader.url, fmt.Sprint("Later ", i))
+	}
+	req, _ := http.NewRequest(http.MethodDelete, leader.url+"/v1/quotes/1", nil)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to delete on the leader: %v %v", resp, err)
+	}
+
+	follower = start(t, dir, "follower", followerEnv...)
+	eventually(t, "the follower to catch up", func() bool { return count(follower.url) == 24 })
+	if st := status(t, follower.url); st.Bootstraps != 0 || st.Lag != 0 {
+		t.Errorf("Restarted follower did not resume from its log: %+v", st)
+	}
+
+	proxy := start(t, dir, "proxy", "REPLICA_OF="+leader.url, "REPLICA_PROXY_WRITES=true")
+	if resp := create(t, proxy.url, "Proxied"); resp.StatusCode != http.StatusCreated {
+		t.Errorf("Proxied write failed: %s", resp.Status)
+	}
+	eventually(t, "the proxied write to replicate", func() bool {
+		return count(leader.url) == 25 && count(proxy.url) == 25 && count(follower.url) == 25
+	})
+}
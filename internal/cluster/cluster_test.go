@@ -0,0 +1,345 @@
+package cluster_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/cluster"
+	"github.com/paxaf/BrandScoutTest/internal/controller"
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/repo/repotest"
+)
+
+const token = "secret"
+
+type member struct {
+	id     uint64
+	node   *cluster.Node
+	store  *cluster.Store
+	server *httptest.Server
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// listen serves raft messages for a node created after its address is
+// known, which the founding members need to list each other.
+func listen(t *testing.T) (*httptest.Server, *atomic.Pointer[cluster.Node]) {
+	t.Helper()
+	var target atomic.Pointer[cluster.Node]
+	messages := func(w http.ResponseWriter, r *http.Request) {
+		node := target.Load()
+		if node == nil {
+			http.Error(w, "Not started", http.StatusServiceUnavailable)
+			return
+		}
+		controller.RaftMessages(node)(w, r)
+	}
+	server := httptest.NewServer(middleware.Admin(token)(http.HandlerFunc(messages)))
+	t.Cleanup(server.Close)
+	return server, &target
+}
+
+func start(t *testing.T, id uint64, peers []entity.ClusterMember, server *httptest.Server, target *atomic.Pointer[cluster.Node], opts ...cluster.Option) *member {
+	t.Helper()
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	opts = append([]cluster.Option{cluster.WithToken(token), cluster.WithTick(10 * time.Millisecond), cluster.WithTimeout(2 * time.Second)}, opts...)
+	node, err := cluster.New(id, peers, engine, opts...)
+	if err != nil {
+		t.Fatalf("Failed to start node %d: %v", id, err)
+	}
+	target.Store(node)
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &member{id: id, node: node, store: cluster.NewStore(node), server: server, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(m.done)
+		node.Run(ctx)
+	}()
+	t.Cleanup(m.stop)
+	return m
+}
+
+func (m *member) stop() {
+	m.cancel()
+	<-m.done
+	m.server.Close()
+	m.node.Close()
+}
+
+func startCluster(t *testing.T, size int, opts ...cluster.Option) []*member {
+	t.Helper()
+	servers := make([]*httptest.Server, size)
+	targets := make([]*atomic.Pointer[cluster.Node], size)
+	peers := make([]entity.ClusterMember, size)
+	for i := range size {
+		servers[i], targets[i] = listen(t)
+		peers[i] = entity.ClusterMember{ID: uint64(i + 1), Addr: servers[i].URL}
+	}
+	members := make([]*member, size)
+	for i := range size {
+		members[i] = start(t, uint64(i+1), peers, servers[i], targets[i], opts...)
+	}
+	return members
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func leader(t *testing.T, members []*member) *member {
+	t.Helper()
+	var lead *member
+	eventually(t, "a leader", func() bool {
+		for _, m := range members {
+			if _, self := m.node.Leader(); self {
+				lead = m
+				return true
+			}
+		}
+		return false
+	})
+	return lead
+}
+
+func create(t *testing.T, store *cluster.Store, key string) entity.Quote {
+	t.Helper()
+	quote, err := store.SetIfAbsent(key, entity.Quote{Id: key, Author: "Author", Phrase: "Phrase " + key})
+	if err != nil {
+		t.Fatalf("Failed to write %s: %v", key, err)
+	}
+	return quote
+}
+
+// read is a linearizable read on m.
+func read(t *testing.T, m *member, key string) (entity.Quote, error) {
+	t.Helper()
+	if err := m.node.Barrier(context.Background()); err != nil {
+		t.Fatalf("Node %d failed to catch up: %v", m.id, err)
+	}
+	return m.store.Get(key)
+}
+
+func TestReplicatedWrites(t *testing.T) {
+	members := startCluster(t, 3)
+	lead := leader(t, members)
+	created := create(t, lead.store, "1")
+	for _, m := range members {
+		if got, err := read(t, m, "1"); err != nil || got.Version != created.Version {
+			t.Errorf("Node %d read %+v %v, want version %d", m.id, got, err, created.Version)
+		}
+	}
+
+	follower := members[0]
+	if follower == lead {
+		follower = members[1]
+	}
+	if _, err := follower.store.SetIfAbsent("1", entity.Quote{Id: "1"}); !errors.Is(err, repo.ErrExists) {
+		t.Errorf("Expected the forwarded write to fail with ErrExists, got %v", err)
+	}
+	create(t, follower.store, "2")
+	follower.store.SetAuthor("a1", entity.Author{Id: "a1", Name: "Author"})
+	follower.store.SetPin("2026-10-19", "2")
+	err := lead.store.Update(func(tx repo.Tx) error {
+		quote, _ := tx.Get("1")
+		quote.Phrase = "Edited"
+		tx.Set("1", quote)
+		tx.SoftDel("2", time.Now())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	for _, m := range members {
+		if got, _ := read(t, m, "1"); got.Phrase != "Edited" {
+			t.Errorf("Node %d missed the transaction: %+v", m.id, got)
+		}
+		if trash, _ := m.store.GetTrash(); len(trash) != 1 {
+			t.Errorf("Node %d has trash %+v", m.id, trash)
+		}
+		if _, err := m.store.GetAuthor("a1"); err != nil {
+			t.Errorf("Node %d missed the author", m.id)
+		}
+		if pin, _ := m.store.GetPin("2026-10-19"); pin != "2" {
+			t.Errorf("Node %d missed the pin", m.id)
+		}
+	}
+}
+
+func TestLeaderFailover(t *testing.T) {
+	members := startCluster(t, 3)
+	lead := leader(t, members)
+	create(t, lead.store, "1")
+	lead.stop()
+
+	var rest []*member
+	for _, m := range members {
+		if m != lead {
+			rest = append(rest, m)
+		}
+	}
+	next := leader(t, rest)
+	create(t, next.store, "2")
+	for _, m := range rest {
+		for _, key := range []string{"1", "2"} {
+			if _, err := read(t, m, key); err != nil {
+				t.Errorf("Node %d lost %s after failover", m.id, key)
+			}
+		}
+	}
+
+	// Without a quorum nothing commits, and the caller is told so.
+	next.stop()
+	survivor := rest[0]
+	if survivor == next {
+		survivor = rest[1]
+	}
+	if _, err := survivor.store.SetIfAbsent("3", entity.Quote{Id: "3"}); !errors.Is(err, repo.ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable without a quorum, got %v", err)
+	}
+	if err := survivor.node.Barrier(context.Background()); !errors.Is(err, repo.ErrUnavailable) {
+		t.Errorf("Expected a read without a quorum to fail, got %v", err)
+	}
+}
+
+func TestMembership(t *testing.T) {
+	members := startCluster(t, 3, cluster.WithSnapshotEvery(5))
+	lead := leader(t, members)
+	for i := range 20 {
+		create(t, lead.store, fmt.Sprint(i))
+	}
+
+	server, target := listen(t)
+	var peers []entity.ClusterMember
+	for _, m := range members {
+		peers = append(peers, entity.ClusterMember{ID: m.id, Addr: m.server.URL})
+	}
+	joined := start(t, 4, peers, server, target, cluster.Joining(), cluster.WithSnapshotEvery(5))
+	if err := lead.node.AddMember(context.Background(), entity.ClusterMember{ID: 4, Addr: server.URL}); err != nil {
+		t.Fatalf("Failed to add a member: %v", err)
+	}
+	eventually(t, "the new member to catch up", func() bool {
+		quotes, _ := joined.store.GetAll()
+		return len(quotes) == 20
+	})
+	members = append(members, joined)
+	for _, m := range members {
+		if got := len(m.node.Status().Members); got != 4 {
+			t.Errorf("Node %d sees %d members", m.id, got)
+		}
+	}
+
+	if err := lead.node.RemoveMember(context.Background(), lead.id); err != nil {
+		t.Fatalf("Failed to remove the leader: %v", err)
+	}
+	lead.stop()
+	members = slices.DeleteFunc(members, func(m *member) bool { return m == lead })
+	next := leader(t, members)
+	create(t, next.store, "after")
+	if _, err := read(t, joined, "after"); err != nil {
+		t.Error("Write after removing the leader did not reach the new member")
+	}
+	for _, m := range members {
+		if got := m.node.Status().Members; slices.ContainsFunc(got, func(peer entity.ClusterMember) bool { return peer.ID == lead.id }) {
+			t.Errorf("Node %d still lists the removed leader: %v", m.id, got)
+		}
+	}
+}
+
+func TestRestart(t *testing.T) {
+	dir := t.TempDir()
+	server, target := listen(t)
+	peers := []entity.ClusterMember{{ID: 1, Addr: server.URL}}
+	path := dir + "/raft.log"
+	m := start(t, 1, peers, server, target, cluster.WithStorage(path), cluster.WithSnapshotEvery(3))
+	leader(t, []*member{m})
+	for i := range 5 {
+		create(t, m.store, fmt.Sprint(i))
+	}
+	m.stop()
+
+	server, target = listen(t)
+	m = start(t, 1, nil, server, target, cluster.WithStorage(path), cluster.WithSnapshotEvery(3))
+	if quotes, _ := m.store.GetAll(); len(quotes) != 5 {
+		t.Errorf("Restarted node replayed %d quotes, want 5", len(quotes))
+	}
+}
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Store {
+		server, target := listen(t)
+		m := start(t, 1, []entity.ClusterMember{{ID: 1, Addr: server.URL}}, server, target)
+		leader(t, []*member{m})
+		return m.store
+	})
+}
+
+func TestStopsOnPersistenceFailure(t *testing.T) {
+	server, target := listen(t)
+	peers := []entity.ClusterMember{{ID: 1, Addr: server.URL}}
+	m := start(t, 1, peers, server, target, cluster.WithStorage(t.TempDir()+"/raft.log"))
+	leader(t, []*member{m})
+	create(t, m.store, "1")
+
+	// Closing the raft log under the running node makes the next save fail.
+	m.node.Close()
+	if _, err := m.store.SetIfAbsent("2", entity.Quote{Id: "2"}); !errors.Is(err, repo.ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable once the log cannot be saved, got %v", err)
+	}
+	select {
+	case <-m.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Node kept running after failing to persist its state")
+	}
+	if _, ok := m.node.Leader(); ok {
+		t.Error("Stopped node still reports itself as the leader")
+	}
+}
+
+func TestWritesObservedOnEveryNode(t *testing.T) {
+	members := startCluster(t, 3)
+	var seen [3]atomic.Int32
+	for i, m := range members {
+		m.node.OnWrite(func(quote entity.Quote) {
+			if quote.Id == "1" {
+				seen[i].Add(1)
+			}
+		})
+	}
+	lead := leader(t, members)
+	follower := members[0]
+	if follower == lead {
+		follower = members[1]
+	}
+	eventually(t, "the follower to learn the leader", func() bool {
+		addr, _ := follower.node.Leader()
+		return addr != ""
+	})
+	create(t, follower.store, "1")
+	eventually(t, "every node to see the write", func() bool {
+		for i := range seen {
+			if seen[i].Load() == 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
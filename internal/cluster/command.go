@@ -0,0 +1,240 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	opSet               = "set"
+	opDel               = "del"
+	opSetIfAbsent       = "set_if_absent"
+	opCompareAndSwap    = "compare_and_swap"
+	opDeleteIfVersion   = "delete_if_version"
+	opSoftDel           = "soft_del"
+	opSoftDelIfVersion  = "soft_del_if_version"
+	opRestore           = "restore"
+	opPurge             = "purge"
+	opTx                = "tx"
+	opSetAuthor         = "set_author"
+	opSetAuthorIfAbsent = "set_author_if_absent"
+	opDelAuthor         = "del_author"
+	opSetAlias          = "set_alias"
+	opDelAlias          = "del_alias"
+	opSetPin            = "set_pin"
+	opDelPin            = "del_pin"
+	opAppendRevision    = "append_revision"
+)
+
+// command is one store write as it travels through the raft log. Every
+// node applies it to its own store; the node that proposed it hands the
+// outcome back to the caller.
+type command struct {
+	ID       uint64            `json:"id"`
+	Op       string            `json:"op"`
+	Key      string            `json:"key,omitempty"`
+	Value    string            `json:"value,omitempty"`
+	Expected uint64            `json:"expected,omitempty"`
+	At       time.Time         `json:"at"`
+	Quote    *entity.Quote     `json:"quote,omitempty"`
+	Author   *entity.Author    `json:"author,omitempty"`
+	Revision *entity.Revision  `json:"revision,omitempty"`
+	Base     uint64            `json:"base,omitempty"`
+	Ops      []entity.Mutation `json:"ops,omitempty"`
+}
+
+type result struct {
+	quote    entity.Quote
+	quotes   []entity.Quote
+	author   entity.Author
+	revision entity.Revision
+	err      error
+}
+
+func (c command) apply(store repo.Store) result {
+	var res result
+	switch c.Op {
+	case opSet:
+		res.quote, res.err = store.Set(c.Key, *c.Quote)
+	case opDel:
+		res.err = store.Del(c.Key)
+	case opSetIfAbsent:
+		res.quote, res.err = store.SetIfAbsent(c.Key, *c.Quote)
+	case opCompareAndSwap:
+		res.quote, res.err = store.CompareAndSwap(c.Key, c.Expected, *c.Quote)
+	case opDeleteIfVersion:
+		res.err = store.DeleteIfVersion(c.Key, c.Expected)
+	case opSoftDel:
+		res.err = store.SoftDel(c.Key, c.At)
+	case opSoftDelIfVersion:
+		res.err = store.SoftDelIfVersion(c.Key, c.Expected, c.At)
+	case opRestore:
+		res.quote, res.err = store.Restore(c.Key)
+	case opPurge:
+		res.quotes, res.err = store.PurgeBefore(c.At)
+	case opTx:
+		res.err = applyTx(store, c.Base, c.Ops)
+	case opSetAuthor:
+		res.author, res.err = store.SetAuthor(c.Key, *c.Author)
+	case opSetAuthorIfAbsent:
+		res.author, res.err = store.SetAuthorIfAbsent(c.Key, *c.Author)
+	case opDelAuthor:
+		res.err = store.DelAuthor(c.Key)
+	case opSetAlias:
+		res.err = store.SetAlias(c.Key, c.Value)
+	case opDelAlias:
+		res.err = store.DelAlias(c.Key)
+	case opSetPin:
+		res.err = store.SetPin(c.Key, c.Value)
+	case opDelPin:
+		res.err = store.DelPin(c.Key)
+	case opAppendRevision:
+		res.revision, res.err = store.AppendRevision(c.Key, *c.Revision)
+	default:
+		res.err = fmt.Errorf("unknown command %q", c.Op)
+	}
+	return res
+}
+
+// written returns the live quotes c wrote, given what applying it returned.
+func (c command) written(res result) []entity.Quote {
+	if res.err != nil {
+		return nil
+	}
+	switch c.Op {
+	case opSet, opSetIfAbsent, opCompareAndSwap, opRestore:
+		return []entity.Quote{res.quote}
+	case opTx:
+		var quotes []entity.Quote
+		for _, op := range c.Ops {
+			if op.Kind == entity.MutationSet {
+				quotes = append(quotes, *op.Quote)
+			}
+		}
+		return quotes
+	}
+	return nil
+}
+
+// applyTx writes the mutations a transaction recorded against the store at
+// seq base. Anything committed since then may have changed what it read,
+// so the transaction is refused and its proposer runs it again.
+func applyTx(store repo.Store, base uint64, ops []entity.Mutation) error {
+	if seq, _ := store.Version(); seq != base {
+		return repo.ErrVersionMismatch
+	}
+	return store.Update(func(tx repo.Tx) error {
+		for _, op := range ops {
+			switch op.Kind {
+			case entity.MutationSet:
+				tx.Set(op.Key, *op.Quote)
+			case entity.MutationTrash:
+				tx.SoftDel(op.Key, op.Quote.DeletedAt)
+			case entity.MutationDel:
+				tx.Del(op.Key)
+			case opSetAuthor:
+				if _, ok := tx.SetAuthorIfAbsent(op.Key, *op.Author); !ok {
+					return repo.ErrExists
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// recordingTx runs a transaction against the local store without writing
+// to it, keeping the mutations to propose. Versions are assigned the way
+// the store assigns them, so they hold when the commit lands at base.
+type recordingTx struct {
+	store   repo.Store
+	seq     uint64
+	view    map[string]*entity.Quote
+	authors map[string]entity.Author
+	ops     []entity.Mutation
+	// err is the first failed read; the transaction is not proposed.
+	err error
+}
+
+func (t *recordingTx) Get(key string) (entity.Quote, bool) {
+	if value, ok := t.view[key]; ok {
+		if value == nil {
+			return entity.Quote{}, false
+		}
+		return *value, true
+	}
+	value, err := t.store.Get(key)
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && t.err == nil {
+			t.err = err
+		}
+		return entity.Quote{}, false
+	}
+	return value, true
+}
+
+func (t *recordingTx) Set(key string, value entity.Quote) entity.Quote {
+	t.seq++
+	value.Version = t.seq
+	value.DeletedAt = time.Time{}
+	t.view[key] = &value
+	t.ops = append(t.ops, entity.Mutation{Kind: entity.MutationSet, Key: key, Quote: &value})
+	return value
+}
+
+func (t *recordingTx) Del(key string) {
+	t.seq++
+	t.view[key] = nil
+	t.ops = append(t.ops, entity.Mutation{Kind: entity.MutationDel, Key: key})
+}
+
+func (t *recordingTx) GetAuthorByName(name string) (entity.Author, bool) {
+	for _, author := range t.authors {
+		if entity.NormalizeAuthor(author.Name) == entity.NormalizeAuthor(name) {
+			return author, true
+		}
+	}
+	return t.storedAuthor(t.store.GetAuthorByName(name))
+}
+
+func (t *recordingTx) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, bool) {
+	if existing, ok := t.authors[key]; ok {
+		return existing, false
+	}
+	if existing, ok := t.GetAuthorByName(value.Name); ok || t.err != nil {
+		return existing, false
+	}
+	if existing, ok := t.storedAuthor(t.store.GetAuthor(key)); ok || t.err != nil {
+		return existing, false
+	}
+	t.authors[key] = value
+	t.ops = append(t.ops, entity.Mutation{Kind: opSetAuthor, Key: key, Author: &value})
+	return value, true
+}
+
+// storedAuthor keeps a failed author read in err, like Get does.
+func (t *recordingTx) storedAuthor(value entity.Author, err error) (entity.Author, bool) {
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && t.err == nil {
+			t.err = err
+		}
+		return entity.Author{}, false
+	}
+	return value, true
+}
+
+func (t *recordingTx) SoftDel(key string, at time.Time) bool {
+	value, ok := t.Get(key)
+	if !ok {
+		return false
+	}
+	t.seq++
+	value.Version = t.seq
+	value.DeletedAt = at
+	t.view[key] = nil
+	t.ops = append(t.ops, entity.Mutation{Kind: entity.MutationTrash, Key: key, Quote: &value})
+	return true
+}
@@ -0,0 +1,626 @@
+// Package cluster replicates a store across nodes with raft. Every write
+// is a command in the raft log, applied by each node in log order once a
+// majority has it; reads wait until the node caught up with the leader.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/raft"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	defaultTick          = 100 * time.Millisecond
+	defaultTimeout       = 5 * time.Second
+	defaultSnapshotEvery = 1000
+	sendBuffer           = 256
+	maxSendBatch         = 64
+)
+
+// StateMachine is the store a node replicates. Dump and LoadDump carry
+// the whole store in raft snapshots.
+type StateMachine interface {
+	repo.Store
+	Dump() entity.StoreDump
+	LoadDump(dump entity.StoreDump) error
+}
+
+type Node struct {
+	id            uint64
+	store         StateMachine
+	storage       *raft.Storage
+	storagePath   string
+	joining       bool
+	client        *http.Client
+	token         string
+	tick          time.Duration
+	timeout       time.Duration
+	snapshotEvery uint64
+	requests      atomic.Uint64
+	wake          chan struct{}
+	done          chan struct{}
+	// stopped is closed once the node failed to persist or restore its
+	// state; failure says why.
+	stopped chan struct{}
+
+	// written is told about every quote a committed command wrote.
+	written atomic.Pointer[func(entity.Quote)]
+
+	// Owned by the goroutine processing Ready.
+	addrs         map[uint64]string
+	senders       map[uint64]*sender
+	snapshotIndex uint64
+
+	mutex          sync.Mutex
+	raft           *raft.Raft
+	applied        uint64
+	proposals      map[uint64]chan result
+	reads          map[uint64]chan uint64
+	appliedWaiters []appliedWaiter
+	failure        error
+}
+
+type sender struct {
+	addr     string
+	messages chan raft.Message
+}
+
+type appliedWaiter struct {
+	index uint64
+	done  chan struct{}
+}
+
+type Option func(*Node)
+
+// WithStorage keeps the raft log and snapshots in the file at path, so the
+// node rejoins with its state after a restart.
+func WithStorage(path string) Option {
+	return func(n *Node) {
+		n.storagePath = path
+	}
+}
+
+// Joining starts a node that is not a member yet: peers only tell it where
+// the members are until a config change adding it commits.
+func Joining() Option {
+	return func(n *Node) {
+		n.joining = true
+	}
+}
+
+// WithToken sets the admin token sent along with raft messages.
+func WithToken(token string) Option {
+	return func(n *Node) {
+		n.token = token
+	}
+}
+
+func WithTick(tick time.Duration) Option {
+	return func(n *Node) {
+		n.tick = tick
+	}
+}
+
+// WithTimeout bounds how long a write or read waits for the cluster.
+func WithTimeout(timeout time.Duration) Option {
+	return func(n *Node) {
+		n.timeout = timeout
+	}
+}
+
+// WithSnapshotEvery compacts the raft log after that many applied entries.
+func WithSnapshotEvery(entries int) Option {
+	return func(n *Node) {
+		n.snapshotEvery = uint64(entries)
+	}
+}
+
+// New starts node id on top of an empty store. Founding nodes pass the
+// initial membership in peers. A node joining a running cluster is started
+// with Joining and added through AddMember on the leader.
+func New(id uint64, peers []entity.ClusterMember, store StateMachine, opts ...Option) (*Node, error) {
+	n := &Node{
+		id:            id,
+		store:         store,
+		tick:          defaultTick,
+		timeout:       defaultTimeout,
+		snapshotEvery: defaultSnapshotEvery,
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		addrs:         make(map[uint64]string),
+		senders:       make(map[uint64]*sender),
+		proposals:     make(map[uint64]chan result),
+		reads:         make(map[uint64]chan uint64),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.client = &http.Client{Timeout: n.timeout}
+	// Request ids are unique across the cluster and across restarts.
+	n.requests.Store(id<<48 | uint64(time.Now().UnixNano())&(1<<48-1))
+
+	var err error
+	n.storage = raft.NewMemoryStorage()
+	if n.storagePath != "" {
+		if n.storage, err = raft.OpenStorage(n.storagePath); err != nil {
+			return nil, fmt.Errorf("failed to open raft log: %w", err)
+		}
+	}
+	state := n.storage.State()
+	if len(state.Snapshot.Data) > 0 {
+		if err := n.restore(state.Snapshot); err != nil {
+			n.storage.Close()
+			return nil, err
+		}
+	}
+	var members []raft.Peer
+	for _, peer := range peers {
+		n.addrs[peer.ID] = peer.Addr
+		if !n.joining {
+			members = append(members, raft.Peer{ID: peer.ID, Addr: peer.Addr})
+		}
+	}
+	n.raft = raft.New(raft.Config{
+		ID:    id,
+		Peers: members,
+		State: state,
+		Seed:  time.Now().UnixNano() ^ int64(id),
+	})
+	// Replay what was committed before a restart, so the store is current
+	// before anyone reads it.
+	if err := n.process(); err != nil {
+		n.storage.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// Run drives the node until ctx is done. It stops early, returning the
+// error, when the node fails to persist or restore its state: raft has
+// already handed that state out, so the node cannot go on safely.
+func (n *Node) Run(ctx context.Context) error {
+	defer close(n.done)
+	ticker := time.NewTicker(n.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			n.mutex.Lock()
+			n.raft.Tick()
+			n.mutex.Unlock()
+		case <-n.wake:
+		}
+		if err := n.process(); err != nil {
+			return err
+		}
+	}
+}
+
+// OnWrite calls fn with every quote written by a committed command or
+// loaded from a raft snapshot, whichever node proposed it.
+func (n *Node) OnWrite(fn func(entity.Quote)) {
+	n.written.Store(&fn)
+}
+
+func (n *Node) wrote(quote entity.Quote) {
+	if fn := n.written.Load(); fn != nil {
+		(*fn)(quote)
+	}
+}
+
+func (n *Node) Close() error {
+	return n.storage.Close()
+}
+
+// Step hands messages received from other nodes to raft.
+func (n *Node) Step(msgs []raft.Message) {
+	n.mutex.Lock()
+	for _, m := range msgs {
+		n.raft.Step(m)
+	}
+	n.mutex.Unlock()
+	n.notify()
+}
+
+func (n *Node) Status() entity.ClusterStatus {
+	n.mutex.Lock()
+	status := n.raft.Status()
+	n.mutex.Unlock()
+	res := entity.ClusterStatus{
+		ID:      status.ID,
+		State:   status.State.String(),
+		Term:    status.Term,
+		Leader:  status.Leader,
+		Commit:  status.Commit,
+		Applied: status.Applied,
+	}
+	for _, peer := range status.Peers {
+		res.Members = append(res.Members, entity.ClusterMember{ID: peer.ID, Addr: peer.Addr})
+	}
+	return res
+}
+
+// Leader returns the address of the current leader and whether it is this
+// node. The address is empty while there is no leader, and for good once
+// the node stopped.
+func (n *Node) Leader() (string, bool) {
+	if n.failed() != nil {
+		return "", false
+	}
+	status := n.Status()
+	if status.Leader == n.id {
+		return "", true
+	}
+	for _, member := range status.Members {
+		if member.ID == status.Leader {
+			return member.Addr, false
+		}
+	}
+	return "", false
+}
+
+// Barrier returns once this node applied everything the cluster committed
+// before the call, so a read that follows is linearizable.
+func (n *Node) Barrier(ctx context.Context) error {
+	if err := n.failed(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	id := n.nextID()
+	confirmed := make(chan uint64, 1)
+	n.mutex.Lock()
+	n.reads[id] = confirmed
+	err := n.raft.ReadIndex(id)
+	n.mutex.Unlock()
+	if err != nil {
+		n.forgetRead(id)
+		return fmt.Errorf("%w: %v", repo.ErrUnavailable, err)
+	}
+	n.notify()
+	select {
+	case index := <-confirmed:
+		return n.waitApplied(ctx, index)
+	case <-n.stopped:
+		n.forgetRead(id)
+		return n.failed()
+	case <-ctx.Done():
+		n.forgetRead(id)
+		return fmt.Errorf("%w: %v", repo.ErrUnavailable, ctx.Err())
+	}
+}
+
+func (n *Node) AddMember(ctx context.Context, member entity.ClusterMember) error {
+	return n.changeConfig(ctx, raft.ConfigChange{Peer: raft.Peer{ID: member.ID, Addr: member.Addr}})
+}
+
+func (n *Node) RemoveMember(ctx context.Context, id uint64) error {
+	return n.changeConfig(ctx, raft.ConfigChange{Peer: raft.Peer{ID: id}, Remove: true})
+}
+
+func (n *Node) changeConfig(ctx context.Context, cc raft.ConfigChange) error {
+	id := n.nextID()
+	cc.Context = strconv.FormatUint(id, 10)
+	_, err := n.wait(ctx, id, func() error { return n.raft.ProposeConfigChange(cc) })
+	return err
+}
+
+func (n *Node) propose(ctx context.Context, cmd command) (result, error) {
+	cmd.ID = n.nextID()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return result{}, err
+	}
+	return n.wait(ctx, cmd.ID, func() error { return n.raft.Propose(data) })
+}
+
+// wait registers for the outcome of request id, runs propose and blocks
+// until this node applied the entry or the timeout ran out. An entry that
+// timed out may still commit later.
+func (n *Node) wait(ctx context.Context, id uint64, propose func() error) (result, error) {
+	if err := n.failed(); err != nil {
+		return result{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	done := make(chan result, 1)
+	n.mutex.Lock()
+	n.proposals[id] = done
+	err := propose()
+	n.mutex.Unlock()
+	if err != nil {
+		n.deliver(id, result{})
+		return result{}, fmt.Errorf("%w: %v", repo.ErrUnavailable, err)
+	}
+	n.notify()
+	select {
+	case res := <-done:
+		return res, nil
+	case <-n.stopped:
+		n.deliver(id, result{})
+		return result{}, n.failed()
+	case <-ctx.Done():
+		n.deliver(id, result{})
+		return result{}, fmt.Errorf("%w: %v", repo.ErrUnavailable, ctx.Err())
+	}
+}
+
+func (n *Node) nextID() uint64 {
+	return n.requests.Add(1)
+}
+
+func (n *Node) notify() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process persists, sends and applies what raft produced, in that order.
+// Raft considers rd handed out once Ready returns, so a failure to persist
+// or restore it stops the node.
+func (n *Node) process() error {
+	n.mutex.Lock()
+	rd := n.raft.Ready()
+	peers := n.raft.Status().Peers
+	n.mutex.Unlock()
+	if err := n.storage.Save(rd); err != nil {
+		return n.stop(fmt.Errorf("failed to persist raft state: %w", err))
+	}
+	for _, peer := range peers {
+		n.addrs[peer.ID] = peer.Addr
+	}
+	for _, m := range rd.Messages {
+		n.send(m)
+	}
+	if rd.Snapshot != nil && rd.Snapshot.Index > n.appliedIndex() {
+		if err := n.restore(*rd.Snapshot); err != nil {
+			return n.stop(fmt.Errorf("failed to restore raft snapshot: %w", err))
+		}
+	}
+	for _, entry := range rd.Committed {
+		n.apply(entry)
+	}
+	for _, read := range rd.ReadStates {
+		n.mutex.Lock()
+		confirmed := n.reads[read.Context]
+		delete(n.reads, read.Context)
+		n.mutex.Unlock()
+		if confirmed != nil {
+			confirmed <- read.Index
+		}
+	}
+	n.releaseApplied()
+	n.compact()
+	return nil
+}
+
+// stop marks the node as failed for good. Waiting writes and reads are
+// answered with the failure, and so is everything that comes after.
+func (n *Node) stop(err error) error {
+	log.Printf("stopping cluster node %d: %v", n.id, err)
+	n.mutex.Lock()
+	n.failure = fmt.Errorf("%w: node stopped: %v", repo.ErrUnavailable, err)
+	n.mutex.Unlock()
+	close(n.stopped)
+	return err
+}
+
+func (n *Node) failed() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.failure
+}
+
+func (n *Node) apply(entry raft.Entry) {
+	switch {
+	case entry.Type == raft.EntryConfig:
+		var cc raft.ConfigChange
+		if err := json.Unmarshal(entry.Data, &cc); err == nil {
+			id, _ := strconv.ParseUint(cc.Context, 10, 64)
+			n.deliver(id, result{})
+		}
+	case len(entry.Data) > 0:
+		var cmd command
+		if err := json.Unmarshal(entry.Data, &cmd); err != nil {
+			log.Printf("skipping raft entry %d: %v", entry.Index, err)
+			break
+		}
+		res := cmd.apply(n.store)
+		for _, quote := range cmd.written(res) {
+			n.wrote(quote)
+		}
+		n.deliver(cmd.ID, res)
+	}
+	n.mutex.Lock()
+	n.applied = entry.Index
+	n.mutex.Unlock()
+}
+
+func (n *Node) restore(snap raft.Snapshot) error {
+	var dump entity.StoreDump
+	if err := json.Unmarshal(snap.Data, &dump); err != nil {
+		return fmt.Errorf("corrupt raft snapshot: %w", err)
+	}
+	if err := n.store.LoadDump(dump); err != nil {
+		return err
+	}
+	for _, op := range dump.Quotes.Ops {
+		if op.Kind == entity.MutationSet {
+			n.wrote(*op.Quote)
+		}
+	}
+	n.mutex.Lock()
+	n.applied = snap.Index
+	n.mutex.Unlock()
+	n.snapshotIndex = snap.Index
+	return nil
+}
+
+func (n *Node) compact() {
+	applied := n.appliedIndex()
+	if n.snapshotEvery == 0 || applied-n.snapshotIndex < n.snapshotEvery {
+		return
+	}
+	data, err := json.Marshal(n.store.Dump())
+	if err != nil {
+		log.Printf("failed to snapshot the store: %v", err)
+		return
+	}
+	n.mutex.Lock()
+	err = n.raft.Compact(data)
+	n.mutex.Unlock()
+	if err != nil {
+		return
+	}
+	n.snapshotIndex = applied
+	n.notify()
+}
+
+func (n *Node) deliver(id uint64, res result) {
+	n.mutex.Lock()
+	done := n.proposals[id]
+	delete(n.proposals, id)
+	n.mutex.Unlock()
+	if done != nil {
+		done <- res
+	}
+}
+
+func (n *Node) forgetRead(id uint64) {
+	n.mutex.Lock()
+	delete(n.reads, id)
+	n.mutex.Unlock()
+}
+
+func (n *Node) appliedIndex() uint64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.applied
+}
+
+func (n *Node) waitApplied(ctx context.Context, index uint64) error {
+	n.mutex.Lock()
+	if n.applied >= index {
+		n.mutex.Unlock()
+		return nil
+	}
+	waiter := appliedWaiter{index: index, done: make(chan struct{})}
+	n.appliedWaiters = append(n.appliedWaiters, waiter)
+	n.mutex.Unlock()
+	select {
+	case <-waiter.done:
+		return nil
+	case <-n.stopped:
+		return n.failed()
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", repo.ErrUnavailable, ctx.Err())
+	}
+}
+
+func (n *Node) releaseApplied() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	waiting := n.appliedWaiters[:0]
+	for _, waiter := range n.appliedWaiters {
+		if waiter.index <= n.applied {
+			close(waiter.done)
+			continue
+		}
+		waiting = append(waiting, waiter)
+	}
+	n.appliedWaiters = waiting
+}
+
+// send queues m for its peer. Raft copes with lost messages, so a peer
+// that is down or slow just has its backlog dropped.
+func (n *Node) send(m raft.Message) {
+	addr := n.addrs[m.To]
+	if addr == "" {
+		return
+	}
+	s := n.senders[m.To]
+	if s == nil || s.addr != addr {
+		if s != nil {
+			close(s.messages)
+		}
+		s = &sender{addr: addr, messages: make(chan raft.Message, sendBuffer)}
+		n.senders[m.To] = s
+		go n.transmit(s)
+	}
+	select {
+	case s.messages <- m:
+	default:
+	}
+}
+
+func (n *Node) transmit(s *sender) {
+	failing := false
+	for {
+		var batch []raft.Message
+		select {
+		case <-n.done:
+			return
+		case m, ok := <-s.messages:
+			if !ok {
+				return
+			}
+			batch = append(batch, m)
+		}
+	collect:
+		for len(batch) < maxSendBatch {
+			select {
+			case m, ok := <-s.messages:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, m)
+			default:
+				break collect
+			}
+		}
+		err := n.post(s.addr, batch)
+		if err != nil && !failing {
+			log.Printf("raft peer %s is unreachable: %v", s.addr, err)
+		}
+		failing = err != nil
+	}
+}
+
+func (n *Node) post(addr string, batch []raft.Message) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, addr+"/raft/messages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("peer answered %s", resp.Status)
+	}
+	return nil
+}
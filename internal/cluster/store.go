@@ -0,0 +1,142 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// maxTxAttempts bounds how often a transaction is rerun after writes from
+// elsewhere committed between reading and proposing it.
+const maxTxAttempts = 10
+
+// Store sends every write through the raft log and serves reads from the
+// local store.
+type Store struct {
+	repo.Store
+	node *Node
+}
+
+func NewStore(node *Node) *Store {
+	return &Store{Store: node.store, node: node}
+}
+
+func (s *Store) propose(cmd command) result {
+	cmd.At = cmd.At.UTC()
+	res, err := s.node.propose(context.Background(), cmd)
+	if err != nil {
+		res.err = err
+	}
+	return res
+}
+
+func (s *Store) Set(key string, value entity.Quote) (entity.Quote, error) {
+	res := s.propose(command{Op: opSet, Key: key, Quote: &value})
+	return res.quote, res.err
+}
+
+func (s *Store) Del(key string) error {
+	return s.propose(command{Op: opDel, Key: key}).err
+}
+
+func (s *Store) SetIfAbsent(key string, value entity.Quote) (entity.Quote, error) {
+	res := s.propose(command{Op: opSetIfAbsent, Key: key, Quote: &value})
+	return res.quote, res.err
+}
+
+func (s *Store) CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error) {
+	res := s.propose(command{Op: opCompareAndSwap, Key: key, Expected: expected, Quote: &value})
+	return res.quote, res.err
+}
+
+func (s *Store) DeleteIfVersion(key string, expected uint64) error {
+	return s.propose(command{Op: opDeleteIfVersion, Key: key, Expected: expected}).err
+}
+
+func (s *Store) SoftDelIfVersion(key string, expected uint64, at time.Time) error {
+	return s.propose(command{Op: opSoftDelIfVersion, Key: key, Expected: expected, At: at}).err
+}
+
+func (s *Store) SoftDel(key string, at time.Time) error {
+	return s.propose(command{Op: opSoftDel, Key: key, At: at}).err
+}
+
+func (s *Store) Restore(key string) (entity.Quote, error) {
+	res := s.propose(command{Op: opRestore, Key: key})
+	return res.quote, res.err
+}
+
+func (s *Store) PurgeBefore(before time.Time) ([]entity.Quote, error) {
+	res := s.propose(command{Op: opPurge, At: before})
+	return res.quotes, res.err
+}
+
+// Update runs fn against the local store and proposes what it wrote,
+// tagged with the version it read. If anything committed in between, the
+// transaction is refused everywhere and fn runs again.
+func (s *Store) Update(fn func(tx repo.Tx) error) error {
+	for range maxTxAttempts {
+		base, _ := s.Store.Version()
+		tx := &recordingTx{
+			store:   s.Store,
+			seq:     base,
+			view:    make(map[string]*entity.Quote),
+			authors: make(map[string]entity.Author),
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if tx.err != nil {
+			return tx.err
+		}
+		if len(tx.ops) == 0 {
+			return nil
+		}
+		err := s.propose(command{Op: opTx, Base: base, Ops: tx.ops}).err
+		if !errors.Is(err, repo.ErrVersionMismatch) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: transaction kept conflicting with other writes", repo.ErrUnavailable)
+}
+
+func (s *Store) SetAuthor(key string, value entity.Author) (entity.Author, error) {
+	res := s.propose(command{Op: opSetAuthor, Key: key, Author: &value})
+	return res.author, res.err
+}
+
+// SetAuthorIfAbsent is checked where the command is applied, so of two
+// nodes creating the same author only the first to commit succeeds.
+func (s *Store) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, error) {
+	res := s.propose(command{Op: opSetAuthorIfAbsent, Key: key, Author: &value})
+	return res.author, res.err
+}
+
+func (s *Store) DelAuthor(key string) error {
+	return s.propose(command{Op: opDelAuthor, Key: key}).err
+}
+
+func (s *Store) SetAlias(alias, canonical string) error {
+	return s.propose(command{Op: opSetAlias, Key: alias, Value: canonical}).err
+}
+
+func (s *Store) DelAlias(alias string) error {
+	return s.propose(command{Op: opDelAlias, Key: alias}).err
+}
+
+func (s *Store) SetPin(date, key string) error {
+	return s.propose(command{Op: opSetPin, Key: date, Value: key}).err
+}
+
+func (s *Store) DelPin(date string) error {
+	return s.propose(command{Op: opDelPin, Key: date}).err
+}
+
+func (s *Store) AppendRevision(key string, rev entity.Revision) (entity.Revision, error) {
+	res := s.propose(command{Op: opAppendRevision, Key: key, Revision: &rev})
+	return res.revision, res.err
+}
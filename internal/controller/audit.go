@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (h *UsecaseHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	filter, err := ParseAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := h.service.Audit(filter)
+	if errors.Is(err, repo.ErrAuditTampered) {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromAuditEntries(entries))
+}
+
+func ParseAuditFilter(query url.Values) (entity.AuditFilter, error) {
+	var filter entity.AuditFilter
+	for name, values := range query {
+		if len(values) > 1 {
+			return filter, fmt.Errorf("parameter %q must be given once", name)
+		}
+		value := values[0]
+		var err error
+		switch name {
+		case "actor":
+			filter.Actor = value
+		case "operation":
+			filter.Operation = value
+		case "quote_id":
+			filter.QuoteId = value
+		case "subject":
+			filter.Subject = value
+		case "from":
+			filter.From, err = time.Parse(time.RFC3339, value)
+		case "to":
+			filter.To, err = time.Parse(time.RFC3339, value)
+		default:
+			return filter, fmt.Errorf("unknown parameter %q", name)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("invalid parameter %q: %w", name, err)
+		}
+	}
+	return filter, nil
+}
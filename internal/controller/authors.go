@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func (h *UsecaseHandler) Aliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	aliases, err := h.service.AuthorAliases()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromAliases(aliases))
+}
+
+func (h *UsecaseHandler) SetAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req v1.SetAliasRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	err := h.service.SetAuthorAlias(requestContext(r), r.PathValue("alias"), req.Canonical)
+	if errors.Is(err, usecase.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsecaseHandler) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	err := h.service.DeleteAuthorAlias(requestContext(r), r.PathValue("alias"))
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsecaseHandler) Authors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	authors, err := h.service.Authors()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromAuthors(authors))
+}
+
+func (h *UsecaseHandler) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req v1.Author
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	author, err := h.service.CreateAuthor(requestContext(r), req.ToEntity())
+	if err != nil {
+		writeAuthorError(w, err)
+		return
+	}
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+author.Id)
+	writeJSON(w, http.StatusCreated, v1.FromAuthor(author))
+}
+
+func (h *UsecaseHandler) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	author, err := h.service.GetAuthor(r.PathValue("id"))
+	if errors.Is(err, usecase.ErrAuthorNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromAuthor(author))
+}
+
+func (h *UsecaseHandler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req v1.Author
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	author, err := h.service.UpdateAuthor(requestContext(r), r.PathValue("id"), req.ToEntity())
+	if err != nil {
+		writeAuthorError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromAuthor(author))
+}
+
+func (h *UsecaseHandler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.service.DeleteAuthor(requestContext(r), r.PathValue("id")); err != nil {
+		writeAuthorError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsecaseHandler) AuthorQuotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quotes, err := h.service.AuthorQuotes(r.PathValue("id"))
+	if err != nil {
+		writeAuthorError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromEntities(quotes))
+}
+
+func writeAuthorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrAuthorNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, usecase.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		storageFailure(w, err)
+	}
+}
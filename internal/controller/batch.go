@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func (h *UsecaseHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req v1.BatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	results, err := h.service.Batch(requestContext(r), req.ToEntity())
+	if err != nil {
+		resp := v1.BatchError{Error: err.Error()}
+		var batchErr *usecase.BatchError
+		if errors.As(err, &batchErr) {
+			resp.Index = &batchErr.Index
+		}
+		switch {
+		case errors.Is(err, usecase.ErrValidation):
+			writeJSON(w, http.StatusBadRequest, resp)
+		case errors.Is(err, usecase.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, resp)
+		case errors.Is(err, usecase.ErrPreconditionFailed):
+			writeJSON(w, http.StatusPreconditionFailed, resp)
+		case errors.Is(err, usecase.ErrInsufficientStorage):
+			writeJSON(w, http.StatusInsufficientStorage, resp)
+		case errors.Is(err, usecase.ErrUnavailable):
+			w.Header().Set("Retry-After", retryAfter)
+			writeJSON(w, http.StatusServiceUnavailable, resp)
+		default:
+			log.Println(err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromBatchResults(results))
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	changesSnapshotPath = "/changes/snapshot"
+	offsetTooOld        = "offset too old, resnapshot"
+	keepAliveInterval   = 15 * time.Second
+)
+
+// ChangesSnapshot serves the live quotes and the seq to follow them from.
+func ChangesSnapshot(source repo.ChangeFeed) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		writeJSON(w, http.StatusOK, v1.FromCheckpointQuotes(source.Checkpoint()))
+	}
+}
+
+// Changes serves the quote changes after since. With wait it long-polls
+// like Commits; with Accept: text/event-stream it keeps the connection
+// open and streams server-sent events, resuming from Last-Event-ID.
+func Changes(source repo.ChangeFeed) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		query := r.URL.Query()
+		stream := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+		position := query.Get("since")
+		if stream && r.Header.Get("Last-Event-ID") != "" {
+			position = r.Header.Get("Last-Event-ID")
+		}
+		since, err := parseOffset(position)
+		if err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		limit := defaultCommitsLimit
+		if value := query.Get("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > defaultCommitsLimit {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		if stream {
+			streamChanges(w, r, source, since, limit)
+			return
+		}
+		if value := query.Get("wait"); value != "" {
+			wait, err := time.ParseDuration(value)
+			if err != nil || wait < 0 || wait > maxCommitsWait {
+				http.Error(w, "Invalid wait", http.StatusBadRequest)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), wait)
+			err = source.WaitCommit(ctx, since)
+			cancel()
+			if err != nil && r.Context().Err() != nil {
+				return
+			}
+		}
+		changes, next, err := source.Changes(since, limit)
+		if errors.Is(err, repo.ErrOffsetOutOfRange) {
+			writeJSON(w, http.StatusGone, v1.ChangesError{Error: offsetTooOld, Snapshot: changesSnapshotPath})
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		seq, _ := source.Version()
+		writeJSON(w, http.StatusOK, v1.FromChanges(seq, next, changes))
+	}
+}
+
+// streamChanges writes each change as an event named after its type. The
+// id goes on the last event of a commit only, so a client reconnecting
+// with Last-Event-ID never skips the rest of a commit it saw partly.
+func streamChanges(w http.ResponseWriter, r *http.Request, source repo.ChangeFeed, since entity.Offset, limit int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusNotAcceptable)
+		return
+	}
+	// Check the offset before committing to a 200.
+	changes, next, err := source.Changes(since, limit)
+	if errors.Is(err, repo.ErrOffsetOutOfRange) {
+		writeJSON(w, http.StatusGone, v1.ChangesError{Error: offsetTooOld, Snapshot: changesSnapshotPath})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		if err != nil {
+			if errors.Is(err, repo.ErrOffsetOutOfRange) {
+				writeEvent(w, "error", "", v1.ChangesError{Error: offsetTooOld, Snapshot: changesSnapshotPath})
+			} else {
+				log.Println(err)
+			}
+			flusher.Flush()
+			return
+		}
+		for i, change := range changes {
+			var id string
+			if i == len(changes)-1 || changes[i+1].Seq != change.Seq {
+				id = entity.Offset{Log: next.Log, Seq: change.Seq}.String()
+			}
+			if err := writeEvent(w, change.Type, id, v1.FromChange(change)); err != nil {
+				return
+			}
+		}
+		if next != since && (len(changes) == 0 || changes[len(changes)-1].Seq != next.Seq) {
+			// Commits that changed nothing visible still move the
+			// position a reconnecting client resumes from.
+			if _, err := fmt.Fprintf(w, "id: %s\n\n", next); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		since = next
+		ctx, cancel := context.WithTimeout(r.Context(), keepAliveInterval)
+		waitErr := source.WaitCommit(ctx, since)
+		cancel()
+		if r.Context().Err() != nil {
+			return
+		}
+		if waitErr != nil {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+		changes, next, err = source.Changes(since, limit)
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, data)
+	} else {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	}
+	return err
+}
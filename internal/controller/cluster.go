@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/raft"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// retryAfter is how many seconds a client should wait while the cluster
+// has no leader; an election takes about a second.
+const retryAfter = "1"
+
+// ClusterNode is the raft node behind the cluster endpoints.
+type ClusterNode interface {
+	Step(msgs []raft.Message)
+	Status() entity.ClusterStatus
+	AddMember(ctx context.Context, member entity.ClusterMember) error
+	RemoveMember(ctx context.Context, id uint64) error
+}
+
+// RaftMessages receives the messages other nodes send to this one.
+func RaftMessages(node ClusterNode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		var msgs []raft.Message
+		if !decodeJSON(w, r, &msgs) {
+			return
+		}
+		node.Step(msgs)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ClusterStatus(node ClusterNode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, v1.FromClusterStatus(node.Status()))
+	}
+}
+
+// AddMember adds a node to the cluster. It answers once the change
+// committed; the node then catches up on its own.
+func AddMember(node ClusterNode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		var member v1.Member
+		if !decodeJSON(w, r, &member) {
+			return
+		}
+		if member.Id == 0 || !validPeerAddr(member.Addr) {
+			http.Error(w, "Invalid member", http.StatusBadRequest)
+			return
+		}
+		if err := node.AddMember(r.Context(), member.ToEntity()); err != nil {
+			memberError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v1.FromClusterStatus(node.Status()))
+	}
+}
+
+func RemoveMember(node ClusterNode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil || id == 0 {
+			http.Error(w, "Invalid member id", http.StatusBadRequest)
+			return
+		}
+		if err := node.RemoveMember(r.Context(), id); err != nil {
+			memberError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v1.FromClusterStatus(node.Status()))
+	}
+}
+
+func memberError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repo.ErrUnavailable) {
+		unavailable(w, err)
+		return
+	}
+	log.Println(err)
+	http.Error(w, "Internal Error", http.StatusInternalServerError)
+}
+
+// unavailable answers a write the cluster could not commit, because there
+// is no leader or no quorum. The client may retry it.
+func unavailable(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", retryAfter)
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+func validPeerAddr(addr string) bool {
+	u, err := url.Parse(addr)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.HasSuffix(addr, "/")
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func requestContext(r *http.Request) context.Context {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return usecase.WithActor(r.Context(), entity.Actor{
+		Name:      middleware.Actor(r),
+		IP:        ip,
+		RequestId: middleware.GetRequestID(r),
+	})
+}
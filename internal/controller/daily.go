@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+const dateLayout = "2006-01-02"
+
+func (h *UsecaseHandler) Daily(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	for name := range query {
+		if name != "date" && name != "tz" {
+			http.Error(w, "unknown parameter "+strconv.Quote(name), http.StatusBadRequest)
+			return
+		}
+	}
+	loc := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			http.Error(w, "invalid parameter \"tz\"", http.StatusBadRequest)
+			return
+		}
+	}
+	now := time.Now().In(loc)
+	date := now
+	if value := query.Get("date"); value != "" {
+		var err error
+		if date, err = time.ParseInLocation(dateLayout, value, loc); err != nil {
+			http.Error(w, "invalid parameter \"date\"", http.StatusBadRequest)
+			return
+		}
+	}
+	quote, err := h.service.Daily(date)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "No content", http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	y, m, d := date.Date()
+	expires := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	if expires.After(now) && !date.After(now) {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(expires.Sub(now).Seconds())))
+		w.Header().Set("Expires", expires.UTC().Format(http.TimeFormat))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	writeJSON(w, http.StatusOK, v1.FromEntity(quote))
+}
+
+// DailyPins, PinDaily and UnpinDaily manage the editorial pins, which is
+// left to admins.
+func (h *UsecaseHandler) DailyPins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	pins, err := h.service.DailyPins()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromDailyPins(pins))
+}
+
+func (h *UsecaseHandler) PinDaily(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	date, err := time.Parse(dateLayout, r.PathValue("date"))
+	if err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	var req v1.PinRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	err = h.service.PinDaily(requestContext(r), date, req.Id)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "quote not found", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UsecaseHandler) UnpinDaily(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	date, err := time.Parse(dateLayout, r.PathValue("date"))
+	if err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+	err = h.service.UnpinDaily(requestContext(r), date)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
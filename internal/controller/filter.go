@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+func ParseFilter(query url.Values) (entity.Filter, error) {
+	var filter entity.Filter
+	for name, values := range query {
+		if len(values) > 1 && name != "tag" {
+			return filter, fmt.Errorf("parameter %q must be given once", name)
+		}
+		value := values[0]
+		var err error
+		switch name {
+		case "author_id":
+			filter.AuthorId = value
+		case "author":
+			filter.Author = value
+		case "author_match":
+			filter.AuthorMatch, err = parseAuthorMatch(value)
+		case "phrase":
+			filter.Phrase = value
+		case "tag":
+			filter.Tags = values
+		case "tag_mode":
+			filter.AnyTag, err = parseTagMode(value)
+		case "language":
+			filter.Language = value
+		case "created_from":
+			filter.CreatedFrom, err = time.Parse(time.RFC3339, value)
+		case "created_to":
+			filter.CreatedTo, err = time.Parse(time.RFC3339, value)
+		case "min_length":
+			filter.MinLength, err = parseLength(value)
+		case "max_length":
+			filter.MaxLength, err = parseLength(value)
+		default:
+			return filter, fmt.Errorf("unknown parameter %q", name)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("invalid parameter %q: %w", name, err)
+		}
+	}
+	if filter.MaxLength > 0 && filter.MinLength > filter.MaxLength {
+		return filter, fmt.Errorf("min_length is greater than max_length")
+	}
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && filter.CreatedFrom.After(filter.CreatedTo) {
+		return filter, fmt.Errorf("created_from must not be after created_to")
+	}
+	return filter, nil
+}
+
+func ParseRandomOptions(query url.Values) (entity.RandomOptions, error) {
+	var opts entity.RandomOptions
+	rest := make(url.Values, len(query))
+	for name, values := range query {
+		var err error
+		switch name {
+		case "seed":
+			opts.Seed, err = strconv.ParseUint(values[0], 10, 64)
+			opts.Seeded = true
+		case "exclude":
+			for _, value := range values {
+				for _, key := range strings.Split(value, ",") {
+					if key = strings.TrimSpace(key); key != "" {
+						opts.Exclude = append(opts.Exclude, key)
+					}
+				}
+			}
+		case "weight":
+			opts.Weight, err = parseWeight(values[0])
+		default:
+			rest[name] = values
+			continue
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid parameter %q: %w", name, err)
+		}
+		if len(values) > 1 && name != "exclude" {
+			return opts, fmt.Errorf("parameter %q must be given once", name)
+		}
+	}
+	filter, err := ParseFilter(rest)
+	opts.Filter = filter
+	return opts, err
+}
+
+func parseWeight(value string) (entity.Weight, error) {
+	switch value {
+	case "", "uniform":
+		return entity.WeightUniform, nil
+	case "rating":
+		return entity.WeightRating, nil
+	}
+	return 0, fmt.Errorf("expected uniform or rating")
+}
+
+func parseAuthorMatch(value string) (entity.AuthorMatch, error) {
+	switch value {
+	case "", "exact":
+		return entity.AuthorExact, nil
+	case "prefix":
+		return entity.AuthorPrefix, nil
+	case "ci":
+		return entity.AuthorCaseInsensitive, nil
+	}
+	return 0, fmt.Errorf("expected exact, prefix or ci")
+}
+
+func parseTagMode(value string) (bool, error) {
+	switch value {
+	case "", "all":
+		return false, nil
+	case "any":
+		return true, nil
+	}
+	return false, fmt.Errorf("expected all or any")
+}
+
+func parseLength(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected non-negative integer")
+	}
+	return n, nil
+}
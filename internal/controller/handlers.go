@@ -2,12 +2,16 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
 
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
 	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
 )
 
 func (h *UsecaseHandler) Add(w http.ResponseWriter, r *http.Request) {
@@ -21,8 +25,46 @@ func (h *UsecaseHandler) Add(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
-	h.service.Set(*quote)
+	create := h.service.Set
+	if r.URL.Query().Get("on_duplicate") == "reject" {
+		create = h.service.SetUnique
+	}
+	created, err := create(requestContext(r), *quote)
+	var duplicate *usecase.DuplicateError
+	if errors.As(err, &duplicate) {
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+duplicate.Existing.Id)
+		writeJSON(w, http.StatusConflict, v1.DuplicateConflict{Error: "duplicate quote", ExistingId: duplicate.Existing.Id})
+		return
+	}
+	if errors.Is(err, usecase.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, usecase.ErrInsufficientStorage) {
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	}
+	if errors.Is(err, usecase.ErrUnavailable) {
+		unavailable(w, err)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(v1.FromEntity(created))
+	if err != nil {
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	setValidators(w, quoteETag(created.Version), created.UpdatedAt)
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+created.Id)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
 }
 
 func (h *UsecaseHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -30,8 +72,36 @@ func (h *UsecaseHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	quotes := h.service.GetAll()
-	resp := entity.QuoteResponse{Quotes: quotes}
+	query := r.URL.Query()
+	cursor, limit := query.Get("cursor"), query.Get("limit")
+	query.Del("cursor")
+	query.Del("limit")
+	filter, err := ParseFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cursor != "" || limit != "" {
+		h.list(w, r, filter, cursor, limit)
+		return
+	}
+	version, modified := h.service.Version()
+	etag := collectionETag(version)
+	setValidators(w, etag, modified)
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if !filter.IsEmpty() {
+		h.find(w, filter)
+		return
+	}
+	quotes, err := h.service.GetAll()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	resp := v1.FromEntities(quotes)
 	data, err := json.Marshal(resp)
 	if err != nil {
 		http.Error(w, "Internal Error", http.StatusInternalServerError)
@@ -44,17 +114,80 @@ func (h *UsecaseHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *UsecaseHandler) find(w http.ResponseWriter, filter entity.Filter) {
+	quotes, err := h.service.Find(filter)
+	if errors.Is(err, usecase.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	if len(quotes) == 0 {
+		http.Error(w, "No content", http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromEntities(quotes))
+}
+
 func (h *UsecaseHandler) GetRand(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	quote, ok := h.service.Random()
-	if !ok {
+	opts, err := ParseRandomOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	quote, err := h.service.Random(opts)
+	if errors.Is(err, usecase.ErrNotFound) {
 		http.Error(w, "No content", http.StatusNoContent)
 		return
 	}
-	data, err := json.Marshal(quote)
+	if errors.Is(err, usecase.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(v1.FromEntity(quote))
+	if err != nil {
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func (h *UsecaseHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quote, err := h.service.Get(r.PathValue("id"))
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	etag := quoteETag(quote.Version)
+	setValidators(w, etag, quote.UpdatedAt)
+	if notModified(r, etag, quote.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	data, err := json.Marshal(v1.FromEntity(quote))
 	if err != nil {
 		http.Error(w, "Internal Error", http.StatusInternalServerError)
 		return
@@ -71,18 +204,112 @@ func (h *UsecaseHandler) ByAutor(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	version, modified := h.service.Version()
+	etag := collectionETag(version)
+	if notModified(r, etag, modified) {
+		setValidators(w, etag, modified)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	author := r.URL.Query().Get("author")
-	quotes, ok := h.service.GetAllByAuthor(author)
-	if !ok {
+	quotes, err := h.service.GetAllByAuthor(author)
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	if len(quotes) == 0 {
 		http.Error(w, "No content", http.StatusNoContent)
 		return
 	}
-	resp := entity.QuoteResponse{Quotes: quotes}
+	resp := v1.FromEntities(quotes)
 	data, err := json.Marshal(resp)
 	if err != nil {
 		http.Error(w, "Internal Error", http.StatusInternalServerError)
 		return
 	}
+	setValidators(w, etag, modified)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func (h *UsecaseHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quote, err := ParseQuoteFromReq(r)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	h.update(w, r, func(entity.Quote) entity.Quote { return *quote })
+}
+
+func (h *UsecaseHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	patch, err := ParsePatchFromReq(r)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	h.update(w, r, patch.Apply)
+}
+
+func (h *UsecaseHandler) update(w http.ResponseWriter, r *http.Request, apply func(entity.Quote) entity.Quote) {
+	key := r.PathValue("id")
+	current, err := h.service.Get(key)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	var version uint64
+	if conditional, match := ifMatch(r, quoteETag(current.Version)); !match {
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	} else if conditional {
+		version = current.Version
+	}
+	quote, err := h.service.Update(requestContext(r), key, apply(current), version)
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	case errors.Is(err, usecase.ErrPreconditionFailed):
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, usecase.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, usecase.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, usecase.ErrInsufficientStorage):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	case errors.Is(err, usecase.ErrUnavailable):
+		unavailable(w, err)
+		return
+	case err != nil:
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(v1.FromEntity(quote))
+	if err != nil {
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	setValidators(w, quoteETag(quote.Version), quote.UpdatedAt)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(data); err != nil {
@@ -95,18 +322,137 @@ func (h *UsecaseHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	path := strings.Split(r.URL.Path, "/")
-	key := path[2]
-	err := h.service.Delete(key)
-	if err != nil {
+	key := r.PathValue("id")
+	if key == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	hard := r.URL.Query().Get("hard") == "true"
+	if hard && !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var version uint64
+	if r.Header.Get("If-Match") != "" {
+		current, err := h.service.Get(key)
+		if errors.Is(err, usecase.ErrNotFound) {
+			http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+			return
+		}
+		if err != nil {
+			storageFailure(w, err)
+			return
+		}
+		if _, match := ifMatch(r, quoteETag(current.Version)); !match {
+			http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+			return
+		}
+		version = current.Version
+	}
+	var err error
+	if hard {
+		err = h.service.Purge(requestContext(r), key, version)
+	} else {
+		err = h.service.Delete(requestContext(r), key, version)
+	}
+	if errors.Is(err, usecase.ErrPreconditionFailed) {
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, usecase.ErrNotFound) {
 		log.Println(err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *UsecaseHandler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	groups, err := h.service.Duplicates()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromGroups(groups))
+}
+
+func (h *UsecaseHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req v1.MergeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Ids) == 0 {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	quote, err := h.service.Merge(requestContext(r), r.PathValue("id"), req.Ids)
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	case errors.Is(err, usecase.ErrInvalidMerge):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromEntity(quote))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// storageFailure answers for a read or write the storage could not serve:
+// 409 when quotes kept changing under a write, 507 when it is full, 503
+// when it cannot take writes for now and 500 otherwise, for example when
+// a page on disk is corrupt.
+func storageFailure(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecase.ErrInsufficientStorage):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	case errors.Is(err, usecase.ErrUnavailable):
+		unavailable(w, err)
+	default:
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+	}
+}
+
 func ParseQuoteFromReq(r *http.Request) (*entity.Quote, error) {
-	var quote entity.Quote
+	var quote v1.Quote
 	contentType := r.Header.Get("Content-Type")
 	if contentType != "application/json" {
 		return nil, fmt.Errorf("invalid content type: %s", contentType)
@@ -118,5 +464,22 @@ func ParseQuoteFromReq(r *http.Request) (*entity.Quote, error) {
 		return nil, fmt.Errorf("failed to decode request body: %w", err)
 	}
 
-	return &quote, nil
+	res := quote.ToEntity()
+	return &res, nil
+}
+
+func ParsePatchFromReq(r *http.Request) (*v1.QuotePatch, error) {
+	var patch v1.QuotePatch
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "application/json" && contentType != "application/merge-patch+json" {
+		return nil, fmt.Errorf("invalid content type: %s", contentType)
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode request body: %w", err)
+	}
+
+	return &patch, nil
 }
@@ -2,58 +2,257 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/paxaf/BrandScoutTest/internal/controller"
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
 	"github.com/paxaf/BrandScoutTest/internal/entity"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
 )
 
 type MockUsecase struct {
 	quotes     map[string]entity.Quote
 	keyCounter atomic.Uint64
+	version    uint64
 	returnErr  bool
+	full       bool
 }
 
-func (m *MockUsecase) Set(quote entity.Quote) {
+func (m *MockUsecase) Set(ctx context.Context, quote entity.Quote) (entity.Quote, error) {
 	if m.returnErr {
-		return
+		return entity.Quote{}, errors.New("mock error")
+	}
+	if m.full {
+		return entity.Quote{}, usecase.ErrInsufficientStorage
 	}
 	key := strconv.FormatUint(m.keyCounter.Add(1), 10)
 	quote.Id = key
+	m.version++
+	quote.Version = m.version
 	m.quotes[key] = quote
+	return quote, nil
+}
+
+func (m *MockUsecase) Update(ctx context.Context, id string, quote entity.Quote, version uint64) (entity.Quote, error) {
+	if m.returnErr {
+		return entity.Quote{}, errors.New("mock error")
+	}
+	current, exists := m.quotes[id]
+	if !exists {
+		return entity.Quote{}, usecase.ErrNotFound
+	}
+	if version != 0 && current.Version != version {
+		return entity.Quote{}, usecase.ErrPreconditionFailed
+	}
+	m.version++
+	quote.Id = id
+	quote.Version = m.version
+	m.quotes[id] = quote
+	return quote, nil
+}
+
+func (m *MockUsecase) SetUnique(ctx context.Context, quote entity.Quote) (entity.Quote, error) {
+	if existing, found, _ := m.FindDuplicate(quote); found {
+		return entity.Quote{}, &usecase.DuplicateError{Existing: existing}
+	}
+	return m.Set(ctx, quote)
+}
+
+func (m *MockUsecase) FindDuplicate(quote entity.Quote) (entity.Quote, bool, error) {
+	for _, q := range m.quotes {
+		if strings.EqualFold(q.Phrase, quote.Phrase) {
+			return q, true, nil
+		}
+	}
+	return entity.Quote{}, false, nil
+}
+
+func (m *MockUsecase) Duplicates() ([][]entity.Quote, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) Merge(ctx context.Context, canonical string, duplicates []string) (entity.Quote, error) {
+	quote, exists := m.quotes[canonical]
+	if !exists {
+		return entity.Quote{}, usecase.ErrNotFound
+	}
+	for _, id := range duplicates {
+		delete(m.quotes, id)
+	}
+	return quote, nil
+}
+
+func (m *MockUsecase) Find(filter entity.Filter) ([]entity.Quote, error) {
+	var result []entity.Quote
+	for _, q := range m.quotes {
+		if filter.Match(q) {
+			result = append(result, q)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockUsecase) Tags() ([]entity.TagCount, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUsecase) MergeTags(ctx context.Context, from []string, to string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUsecase) SetAuthorAlias(ctx context.Context, alias, canonical string) error {
+	return nil
+}
+
+func (m *MockUsecase) DeleteAuthorAlias(ctx context.Context, alias string) error {
+	return nil
+}
+
+func (m *MockUsecase) AuthorAliases() ([]entity.AuthorAlias, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) CreateAuthor(ctx context.Context, author entity.Author) (entity.Author, error) {
+	return author, nil
+}
+
+func (m *MockUsecase) GetAuthor(id string) (entity.Author, error) {
+	return entity.Author{}, usecase.ErrAuthorNotFound
+}
+
+func (m *MockUsecase) Authors() ([]entity.Author, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) UpdateAuthor(ctx context.Context, id string, author entity.Author) (entity.Author, error) {
+	return author, nil
+}
+
+func (m *MockUsecase) DeleteAuthor(ctx context.Context, id string) error {
+	return nil
 }
 
-func (m *MockUsecase) GetAll() []entity.Quote {
+func (m *MockUsecase) AuthorQuotes(id string) ([]entity.Quote, error) {
+	return nil, usecase.ErrAuthorNotFound
+}
+
+func (m *MockUsecase) Daily(date time.Time) (entity.Quote, error) {
+	return m.Random(entity.RandomOptions{})
+}
+
+func (m *MockUsecase) PinDaily(ctx context.Context, date time.Time, id string) error {
+	return nil
+}
+
+func (m *MockUsecase) UnpinDaily(ctx context.Context, date time.Time) error {
+	return nil
+}
+
+func (m *MockUsecase) DailyPins() ([]entity.DailyPin, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) Trash() ([]entity.Quote, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) Restore(ctx context.Context, id string) (entity.Quote, error) {
+	return entity.Quote{}, usecase.ErrNotFound
+}
+
+func (m *MockUsecase) Purge(ctx context.Context, id string, version uint64) error {
+	if _, exists := m.quotes[id]; !exists {
+		return usecase.ErrNotFound
+	}
+	delete(m.quotes, id)
+	return nil
+}
+
+func (m *MockUsecase) Revisions(id string) ([]entity.Revision, error) {
+	return nil, usecase.ErrNotFound
+}
+
+func (m *MockUsecase) Revision(id string, n int) (entity.Revision, error) {
+	return entity.Revision{}, usecase.ErrNotFound
+}
+
+func (m *MockUsecase) Revert(ctx context.Context, id string, n int, version uint64) (entity.Quote, error) {
+	return entity.Quote{}, usecase.ErrNotFound
+}
+
+func (m *MockUsecase) Audit(filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) Batch(ctx context.Context, ops []entity.BatchOp) ([]entity.BatchResult, error) {
+	return nil, nil
+}
+
+func (m *MockUsecase) List(filter entity.Filter, cursor string, limit int) (entity.Page, error) {
+	quotes, err := m.GetAll()
+	return entity.Page{Quotes: quotes}, err
+}
+
+func (m *MockUsecase) Version() (uint64, time.Time) {
+	return m.version, time.Time{}
+}
+
+func (m *MockUsecase) GetAll() ([]entity.Quote, error) {
 	if m.returnErr {
-		return nil
+		return nil, errors.New("mock error")
 	}
-	quotes := make([]entity.Quote, 0, len(m.quotes))
+	var quotes []entity.Quote
 	for _, q := range m.quotes {
 		quotes = append(quotes, q)
 	}
-	return quotes
+	return quotes, nil
+}
+
+func (m *MockUsecase) Get(id string) (entity.Quote, error) {
+	if m.returnErr {
+		return entity.Quote{}, errors.New("mock error")
+	}
+	q, ok := m.quotes[id]
+	if !ok {
+		return entity.Quote{}, usecase.ErrNotFound
+	}
+	return q, nil
 }
 
-func (m *MockUsecase) Random() (entity.Quote, bool) {
-	if m.returnErr || len(m.quotes) == 0 {
-		return entity.Quote{}, false
+func (m *MockUsecase) Random(opts entity.RandomOptions) (entity.Quote, error) {
+	if m.returnErr {
+		return entity.Quote{}, errors.New("mock error")
+	}
+	if slices.ContainsFunc(opts.Filter.Tags, func(tag string) bool { return strings.TrimSpace(tag) == "" }) {
+		return entity.Quote{}, usecase.ErrValidation
 	}
 	for _, q := range m.quotes {
-		return q, true
+		if opts.Filter.Match(q) && !slices.Contains(opts.Exclude, q.Id) {
+			return q, nil
+		}
 	}
-	return entity.Quote{}, false
+	return entity.Quote{}, usecase.ErrNotFound
 }
 
-func (m *MockUsecase) GetAllByAuthor(author string) ([]entity.Quote, bool) {
+func (m *MockUsecase) GetAllByAuthor(author string) ([]entity.Quote, error) {
 	if m.returnErr {
-		return nil, false
+		return nil, errors.New("mock error")
 	}
 	var result []entity.Quote
 	for _, q := range m.quotes {
@@ -61,15 +260,19 @@ func (m *MockUsecase) GetAllByAuthor(author string) ([]entity.Quote, bool) {
 			result = append(result, q)
 		}
 	}
-	return result, len(result) > 0
+	return result, nil
 }
 
-func (m *MockUsecase) Delete(id string) error {
+func (m *MockUsecase) Delete(ctx context.Context, id string, version uint64) error {
 	if m.returnErr {
 		return errors.New("mock error")
 	}
-	if _, exists := m.quotes[id]; !exists {
-		return errors.New("not found")
+	current, exists := m.quotes[id]
+	if !exists {
+		return usecase.ErrNotFound
+	}
+	if version != 0 && current.Version != version {
+		return usecase.ErrPreconditionFailed
 	}
 	delete(m.quotes, id)
 	return nil
@@ -83,7 +286,7 @@ func TestAddHandler(t *testing.T) {
 		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote)}
 		h := controller.New(mockUsecase)
 
-		quote := entity.Quote{Author: "Me", Phrase: "Hello"}
+		quote := v1.Quote{Author: "Me", Phrase: "Hello"}
 		body, _ := json.Marshal(quote)
 		req := httptest.NewRequest(http.MethodPost, "/add", bytes.NewReader(body))
 		req.Header.Set("Content-Type", "application/json")
@@ -94,6 +297,9 @@ func TestAddHandler(t *testing.T) {
 		if w.Code != http.StatusCreated {
 			t.Errorf("Expected status 201, got %d", w.Code)
 		}
+		if loc := w.Header().Get("Location"); loc != "/add/1" {
+			t.Errorf("Unexpected Location header: %q", loc)
+		}
 		if len(mockUsecase.quotes) != 1 {
 			t.Fatalf("Quote not added to service")
 		}
@@ -108,6 +314,53 @@ func TestAddHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("storage full", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote), full: true}
+		h := controller.New(mockUsecase)
+
+		body, _ := json.Marshal(v1.Quote{Author: "Me", Phrase: "Hello"})
+		req := httptest.NewRequest(http.MethodPost, "/add", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		h.Add(w, req)
+
+		if w.Code != http.StatusInsufficientStorage {
+			t.Errorf("Expected status 507, got %d", w.Code)
+		}
+	})
+
+	t.Run("reject duplicate", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Me", Phrase: "Hello"},
+			},
+		}
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodPost, "/quotes?on_duplicate=reject", strings.NewReader(`{"author":"Me","quote":"hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		h.Add(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", w.Code)
+		}
+		var conflict v1.DuplicateConflict
+		if err := json.Unmarshal(w.Body.Bytes(), &conflict); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if conflict.ExistingId != "1" || w.Header().Get("Location") != "/quotes/1" {
+			t.Errorf("Unexpected conflict: %+v", conflict)
+		}
+		if len(mockUsecase.quotes) != 1 {
+			t.Error("Duplicate quote must not be added")
+		}
+	})
+
 	t.Run("wrong method", func(t *testing.T) {
 		t.Parallel()
 		h := controller.UsecaseHandler{}
@@ -174,7 +427,7 @@ func TestGetAllHandler(t *testing.T) {
 			t.Errorf("Expected JSON content, got %s", ct)
 		}
 
-		var resp entity.QuoteResponse
+		var resp v1.QuoteResponse
 		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
@@ -200,12 +453,10 @@ func TestGetAllHandler(t *testing.T) {
 			t.Errorf("Expected status 200, got %d", w.Code)
 		}
 
-		var resp entity.QuoteResponse
-		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
-			t.Fatalf("Failed to unmarshal response: %v", err)
-		}
-		if len(resp.Quotes) != 0 {
-			t.Errorf("Expected 0 quotes, got %d", len(resp.Quotes))
+		// The /v1 and unversioned paths share the handler and keep the
+		// original wire shape of an empty list.
+		if body := strings.TrimSpace(w.Body.String()); body != `{"quotes":null}` {
+			t.Errorf("Unexpected body for an empty storage: %s", body)
 		}
 	})
 
@@ -244,7 +495,7 @@ func TestGetRandHandler(t *testing.T) {
 			t.Errorf("Expected status 200, got %d", w.Code)
 		}
 
-		var quote entity.Quote
+		var quote v1.Quote
 		if err := json.Unmarshal(w.Body.Bytes(), &quote); err != nil {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
@@ -253,6 +504,42 @@ func TestGetRandHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("filtered and excluded", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Author", Phrase: "Test quote"},
+				"2": {Id: "2", Author: "Other", Phrase: "Other quote"},
+			},
+		}
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodGet, "/random?author=Author&exclude=1&seed=42&weight=rating", nil)
+		w := httptest.NewRecorder()
+
+		h.GetRand(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		h := controller.New(&MockUsecase{quotes: make(map[string]entity.Quote)})
+
+		for _, query := range []string{"seed=abc", "weight=likes", "unknown=1", "tag=+"} {
+			req := httptest.NewRequest(http.MethodGet, "/random?"+query, nil)
+			w := httptest.NewRecorder()
+
+			h.GetRand(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+			}
+		}
+	})
+
 	t.Run("no content", func(t *testing.T) {
 		t.Parallel()
 		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote)}
@@ -269,6 +556,337 @@ func TestGetRandHandler(t *testing.T) {
 	})
 }
 
+func TestGetByIDHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Author", Phrase: "Test quote"},
+			},
+		}
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		h.GetByID(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		var quote v1.Quote
+		if err := json.Unmarshal(w.Body.Bytes(), &quote); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if quote.Id != "1" {
+			t.Errorf("Unexpected quote: %+v", quote)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote)}
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/missing", nil)
+		req.SetPathValue("id", "missing")
+		w := httptest.NewRecorder()
+
+		h.GetByID(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	newMock := func() *MockUsecase {
+		return &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Author", Phrase: "Test quote", Version: 7},
+			},
+			version: 7,
+		}
+	}
+
+	t.Run("etag on read", func(t *testing.T) {
+		t.Parallel()
+		h := controller.New(newMock())
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		h.GetByID(w, req)
+
+		if etag := w.Header().Get("ETag"); etag != `"7"` {
+			t.Errorf("Unexpected ETag: %q", etag)
+		}
+	})
+
+	t.Run("if-none-match", func(t *testing.T) {
+		t.Parallel()
+		h := controller.New(newMock())
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+		req.SetPathValue("id", "1")
+		req.Header.Set("If-None-Match", `W/"7"`)
+		w := httptest.NewRecorder()
+
+		h.GetByID(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("Expected status 304, got %d", w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Error("Expected empty body")
+		}
+	})
+
+	t.Run("collection if-none-match", func(t *testing.T) {
+		t.Parallel()
+		h := controller.New(newMock())
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes", nil)
+		req.Header.Set("If-None-Match", `"c7"`)
+		w := httptest.NewRecorder()
+
+		h.GetAll(w, req)
+
+		if w.Code != http.StatusNotModified {
+			t.Errorf("Expected status 304, got %d", w.Code)
+		}
+	})
+
+	t.Run("update with stale if-match", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := newMock()
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodPut, "/quotes/1", strings.NewReader(`{"author":"New","quote":"New quote"}`))
+		req.SetPathValue("id", "1")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"6"`)
+		w := httptest.NewRecorder()
+
+		h.Update(w, req)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412, got %d", w.Code)
+		}
+		if mockUsecase.quotes["1"].Author != "Author" {
+			t.Error("Quote must not be updated")
+		}
+	})
+
+	t.Run("patch with matching if-match", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := newMock()
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodPatch, "/quotes/1", strings.NewReader(`{"quote":"Patched"}`))
+		req.SetPathValue("id", "1")
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"7"`)
+		w := httptest.NewRecorder()
+
+		h.Patch(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"8"` {
+			t.Errorf("Unexpected ETag: %q", etag)
+		}
+		if q := mockUsecase.quotes["1"]; q.Author != "Author" || q.Phrase != "Patched" {
+			t.Errorf("Unexpected quote: %+v", q)
+		}
+	})
+
+	t.Run("delete with stale if-match", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := newMock()
+		h := controller.New(mockUsecase)
+
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/1", nil)
+		req.SetPathValue("id", "1")
+		req.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+
+		h.Delete(w, req)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status 412, got %d", w.Code)
+		}
+		if _, exists := mockUsecase.quotes["1"]; !exists {
+			t.Error("Quote must not be deleted")
+		}
+	})
+}
+
+func TestFilteredGetAllHandler(t *testing.T) {
+	t.Parallel()
+
+	newMock := func() *MockUsecase {
+		return &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Leo Tolstoy", Phrase: "All happy families are alike", Tags: []string{"love", "life"}, Language: "en"},
+				"2": {Id: "2", Author: "Lev Tolstoy", Phrase: "Q2", Tags: []string{"love"}, Language: "ru"},
+				"3": {Id: "3", Author: "Chekhov", Phrase: "Brevity is the sister of talent", Tags: []string{"war"}, Language: "en", CreatedAt: time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)},
+			},
+		}
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		code  int
+		count int
+	}{
+		{"all", "/quotes?tag=love&tag=life", http.StatusOK, 1},
+		{"any", "/quotes?tag=life&tag=war&tag_mode=any", http.StatusOK, 2},
+		{"no match", "/quotes?tag=peace", http.StatusNoContent, 0},
+		{"bad mode", "/quotes?tag=love&tag_mode=some", http.StatusBadRequest, 0},
+		{"author exact", "/quotes?author=Chekhov", http.StatusOK, 1},
+		{"author prefix", "/quotes?author=l&author_match=prefix", http.StatusOK, 2},
+		{"author case-insensitive", "/quotes?author=CHEKHOV&author_match=ci", http.StatusOK, 1},
+		{"phrase and language", "/quotes?phrase=HAPPY&language=en", http.StatusOK, 1},
+		{"length range", "/quotes?min_length=10&max_length=30", http.StatusOK, 1},
+		{"created range", "/quotes?created_from=2021-01-01T00:00:00Z&created_to=2022-01-01T00:00:00Z", http.StatusNoContent, 0},
+		{"created instant", "/quotes?created_from=2020-06-01T12:00:00Z&created_to=2020-06-01T12:00:00Z", http.StatusOK, 1},
+		{"created up to", "/quotes?created_from=2020-01-01T00:00:00Z&created_to=2020-06-01T12:00:00Z", http.StatusOK, 1},
+		{"created reversed", "/quotes?created_from=2021-01-01T00:00:00Z&created_to=2020-01-01T00:00:00Z", http.StatusBadRequest, 0},
+		{"unknown parameter", "/quotes?autor=Chekhov", http.StatusBadRequest, 0},
+		{"invalid length", "/quotes?min_length=-1", http.StatusBadRequest, 0},
+		{"invalid range", "/quotes?min_length=10&max_length=5", http.StatusBadRequest, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			h := controller.New(newMock())
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			w := httptest.NewRecorder()
+
+			h.GetAll(w, req)
+
+			if w.Code != tc.code {
+				t.Fatalf("Expected status %d, got %d", tc.code, w.Code)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+			var resp v1.QuoteResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(resp.Quotes) != tc.count {
+				t.Errorf("Expected %d quotes, got %d", tc.count, len(resp.Quotes))
+			}
+		})
+	}
+}
+
+func TestDailyHandler(t *testing.T) {
+	t.Parallel()
+
+	newHandler := func() *controller.UsecaseHandler {
+		return controller.New(&MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Author", Phrase: "Test quote"},
+			},
+		})
+	}
+
+	t.Run("today expires at local midnight", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, "/quotes/daily?tz=Europe/Moscow", nil)
+		w := httptest.NewRecorder()
+
+		newHandler().Daily(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		expires, err := http.ParseTime(w.Header().Get("Expires"))
+		if err != nil {
+			t.Fatalf("Invalid Expires header: %v", err)
+		}
+		loc, _ := time.LoadLocation("Europe/Moscow")
+		if local := expires.In(loc); local.Hour() != 0 || local.Minute() != 0 || !expires.After(time.Now()) {
+			t.Errorf("Expires is not the next local midnight: %v", local)
+		}
+	})
+
+	t.Run("past date", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, "/quotes/daily?date=2020-01-01", nil)
+		w := httptest.NewRecorder()
+
+		newHandler().Daily(w, req)
+
+		if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+			t.Errorf("Unexpected Cache-Control: %q", cc)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		t.Parallel()
+		for _, query := range []string{"tz=Mars/Olympus", "date=01.01.2026", "day=1"} {
+			req := httptest.NewRequest(http.MethodGet, "/quotes/daily?"+query, nil)
+			w := httptest.NewRecorder()
+
+			newHandler().Daily(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+			}
+		}
+	})
+}
+
+func TestDailyPinsRequireAdmin(t *testing.T) {
+	t.Parallel()
+
+	h := controller.New(&MockUsecase{quotes: make(map[string]entity.Quote)})
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    string
+		status  int
+	}{
+		{"list", h.DailyPins, http.MethodGet, "", http.StatusOK},
+		{"pin", h.PinDaily, http.MethodPut, `{"id":"1"}`, http.StatusNoContent},
+		{"unpin", h.UnpinDaily, http.MethodDelete, "", http.StatusNoContent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler := middleware.Admin("secret")(tc.handler)
+			for _, admin := range []bool{false, true} {
+				req := httptest.NewRequest(tc.method, "/quotes/daily/2026-01-01", strings.NewReader(tc.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.SetPathValue("date", "2026-01-01")
+				want := http.StatusForbidden
+				if admin {
+					req.Header.Set("Authorization", "Bearer secret")
+					want = tc.status
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				if w.Code != want {
+					t.Errorf("Admin %v: expected status %d, got %d", admin, want, w.Code)
+				}
+			}
+		})
+	}
+}
+
 func TestByAuthorHandler(t *testing.T) {
 	t.Parallel()
 
@@ -292,7 +910,7 @@ func TestByAuthorHandler(t *testing.T) {
 			t.Errorf("Expected status 200, got %d", w.Code)
 		}
 
-		var resp entity.QuoteResponse
+		var resp v1.QuoteResponse
 		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 			t.Fatalf("Failed to unmarshal response: %v", err)
 		}
@@ -353,7 +971,8 @@ func TestDeleteHandler(t *testing.T) {
 		}
 		h := controller.New(mockUsecase)
 
-		req := httptest.NewRequest(http.MethodDelete, "/delete/test-id", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/test-id", nil)
+		req.SetPathValue("id", "test-id")
 		w := httptest.NewRecorder()
 
 		h.Delete(w, req)
@@ -371,7 +990,8 @@ func TestDeleteHandler(t *testing.T) {
 		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote)}
 		h := controller.New(mockUsecase)
 
-		req := httptest.NewRequest(http.MethodDelete, "/delete/missing-id", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/missing-id", nil)
+		req.SetPathValue("id", "missing-id")
 		w := httptest.NewRecorder()
 
 		h.Delete(w, req)
@@ -386,13 +1006,47 @@ func TestDeleteHandler(t *testing.T) {
 		mockUsecase := &MockUsecase{returnErr: true}
 		h := controller.New(mockUsecase)
 
-		req := httptest.NewRequest(http.MethodDelete, "/delete/id", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/id", nil)
+		req.SetPathValue("id", "id")
 		w := httptest.NewRecorder()
 
 		h.Delete(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("Expected status 400, got %d", w.Code)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+	})
+
+	t.Run("hard delete requires admin", func(t *testing.T) {
+		t.Parallel()
+		mockUsecase := &MockUsecase{
+			quotes: map[string]entity.Quote{
+				"1": {Id: "1", Author: "Author", Phrase: "Test quote"},
+			},
+		}
+		h := controller.New(mockUsecase)
+		handler := middleware.Admin("secret")(http.HandlerFunc(h.Delete))
+
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/1?hard=true", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodDelete, "/quotes/1?hard=true", nil)
+		req.SetPathValue("id", "1")
+		req.Header.Set("Authorization", "Bearer secret")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if _, exists := mockUsecase.quotes["1"]; exists {
+			t.Error("Quote was not purged")
 		}
 	})
 
@@ -401,7 +1055,7 @@ func TestDeleteHandler(t *testing.T) {
 		mockUsecase := &MockUsecase{quotes: make(map[string]entity.Quote)}
 		h := controller.New(mockUsecase)
 
-		req := httptest.NewRequest(http.MethodDelete, "/delete/", nil)
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/", nil)
 		w := httptest.NewRecorder()
 
 		h.Delete(w, req)
@@ -412,12 +1066,83 @@ func TestDeleteHandler(t *testing.T) {
 	})
 }
 
+func TestTagRewritesRequireAdmin(t *testing.T) {
+	t.Parallel()
+
+	h := controller.New(&MockUsecase{quotes: make(map[string]entity.Quote)})
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+	}{
+		{"rename", h.RenameTag, `{"name":"love"}`},
+		{"merge", h.MergeTags, `{"tags":["amor"],"into":"love"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler := middleware.Admin("secret")(tc.handler)
+			for _, admin := range []bool{false, true} {
+				req := httptest.NewRequest(http.MethodPost, "/tags/amor/rename", strings.NewReader(tc.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.SetPathValue("tag", "amor")
+				want := http.StatusForbidden
+				if admin {
+					req.Header.Set("Authorization", "Bearer secret")
+					want = http.StatusOK
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				if w.Code != want {
+					t.Errorf("Admin %v: expected status %d, got %d", admin, want, w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeHandler(t *testing.T) {
+	t.Parallel()
+
+	mockUsecase := &MockUsecase{
+		quotes: map[string]entity.Quote{
+			"1": {Id: "1", Author: "Author", Phrase: "Test quote"},
+			"2": {Id: "2", Author: "Author", Phrase: "Test quote!"},
+		},
+	}
+	h := controller.New(mockUsecase)
+	handler := middleware.Admin("secret")(http.HandlerFunc(h.Merge))
+
+	req := httptest.NewRequest(http.MethodPost, "/quotes/1/merge", strings.NewReader(`{"ids":["2"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+	if len(mockUsecase.quotes) != 2 {
+		t.Error("Duplicate was merged without admin")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/quotes/1/merge", strings.NewReader(`{"ids":["2"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
 func TestParseQuoteFromReq(t *testing.T) {
 	t.Parallel()
 
 	t.Run("valid", func(t *testing.T) {
 		t.Parallel()
-		quote := entity.Quote{Author: "Me", Phrase: "Hello"}
+		quote := v1.Quote{Author: "Me", Phrase: "Hello"}
 		body, _ := json.Marshal(quote)
 		req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
 		req.Header.Set("Content-Type", "application/json")
@@ -464,3 +1189,107 @@ func TestParseQuoteFromReq(t *testing.T) {
 		}
 	})
 }
+
+type statsStub entity.StorageStats
+
+func (s statsStub) Stats() entity.StorageStats { return entity.StorageStats(s) }
+
+type auditFailuresStub uint64
+
+func (s auditFailuresStub) Failures() uint64 { return uint64(s) }
+
+func TestMetricsHandler(t *testing.T) {
+	t.Parallel()
+
+	h := controller.Metrics(statsStub{Entries: 3, Bytes: 900, MaxEntries: 10, Rejected: 1}, auditFailuresStub(4))
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, line := range []string{
+		"quotes_storage_entries 3",
+		"quotes_storage_bytes 900",
+		"quotes_storage_max_entries 10",
+		"quotes_storage_max_bytes 0",
+		"# TYPE quotes_storage_rejected_writes_total counter",
+		"quotes_storage_rejected_writes_total 1",
+		"quotes_audit_write_failures_total 4",
+	} {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, w.Body.String())
+		}
+	}
+}
+
+func TestChangesHandler(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine(storage.WithLogRetention(2))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1"})
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1 edited"})
+	engine.SoftDel("1", time.Now())
+	mux := http.NewServeMux()
+	mux.Handle("GET /changes", controller.Changes(engine))
+	server := httptest.NewServer(middleware.Admin("secret")(mux))
+	defer server.Close()
+
+	get := func(query string, header ...string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/changes"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	log := engine.Offset().Log
+	resp := get("?since=" + log + "-1")
+	var page v1.ChangesResponse
+	json.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || page.Next != log+"-3" || len(page.Changes) != 2 {
+		t.Fatalf("Unexpected response %d: %+v", resp.StatusCode, page)
+	}
+	if got := page.Changes[1]; got.Seq != 3 || got.Type != entity.ChangeDelete || got.Quote == nil || got.Quote.DeletedAt == nil {
+		t.Errorf("Unexpected delete event: %+v", got)
+	}
+
+	resp = get("?since=0")
+	var gone v1.ChangesError
+	json.NewDecoder(resp.Body).Decode(&gone)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGone || gone.Error != "offset too old, resnapshot" || gone.Snapshot == "" {
+		t.Errorf("Expected 410 with a resnapshot hint, got %d %+v", resp.StatusCode, gone)
+	}
+	// Seqs restart with the log of a restarted store; offsets into the
+	// old log have to resnapshot too.
+	for _, since := range []string{"3", "0123456789abcdef-3"} {
+		resp = get("?since=" + since)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusGone {
+			t.Errorf("Expected 410 for %s, got %d", since, resp.StatusCode)
+		}
+	}
+
+	resp = get("", "Accept", "text/event-stream", "Last-Event-ID", log+"-3")
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, ct)
+	}
+	engine.Restore("1")
+	buf := make([]byte, 4096)
+	n, _ := resp.Body.Read(buf)
+	if event := string(buf[:n]); !strings.HasPrefix(event, "id: "+log+"-4\nevent: create\ndata: {\"seq\":4") {
+		t.Errorf("Unexpected event: %q", event)
+	}
+}
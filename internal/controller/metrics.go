@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// AuditFailures is implemented by audit logs that count the entries they
+// failed to write.
+type AuditFailures interface {
+	Failures() uint64
+}
+
+// Metrics serves the storage gauges and counters, when the backend tracks
+// them, and the failed audit writes in the Prometheus text exposition
+// format.
+func Metrics(source repo.StatsReporter, audit AuditFailures) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var b strings.Builder
+		metric := func(name, kind, help string, value any) {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+		}
+		if source != nil {
+			stats := source.Stats()
+			metric("quotes_storage_entries", "gauge", "Quotes held by the storage engine, trash included.", stats.Entries)
+			metric("quotes_storage_bytes", "gauge", "Approximate memory used by stored quotes.", stats.Bytes)
+			metric("quotes_storage_max_entries", "gauge", "Entry limit, 0 if unlimited.", stats.MaxEntries)
+			metric("quotes_storage_max_bytes", "gauge", "Byte limit, 0 if unlimited.", stats.MaxBytes)
+			metric("quotes_storage_rejected_writes_total", "counter", "Writes refused because the storage was full.", stats.Rejected)
+		}
+		metric("quotes_audit_write_failures_total", "counter", "Committed writes whose audit entry could not be written.", audit.Failures())
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if _, err := w.Write([]byte(b.String())); err != nil {
+			log.Printf("Failed to write response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+type adminKey struct{}
+
+func Admin(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if ok && token != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+				r = r.WithContext(context.WithValue(r.Context(), adminKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func IsAdmin(r *http.Request) bool {
+	admin, _ := r.Context().Value(adminKey{}).(bool)
+	return admin
+}
+
+func Actor(r *http.Request) string {
+	if IsAdmin(r) {
+		return "admin"
+	}
+	return "anonymous"
+}
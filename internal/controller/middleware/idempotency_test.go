@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+)
+
+func newIdempotentHandler(calls *atomic.Int64) http.Handler {
+	store := middleware.NewIdempotencyStore(time.Minute)
+	return middleware.Idempotency(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Location", "/quotes/"+strconv.FormatInt(n, 10))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(strconv.FormatInt(n, 10)))
+	}))
+}
+
+func newIdempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/quotes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	return req
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replay", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		handler := newIdempotentHandler(&calls)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, newIdempotentRequest("k1", `{"author":"A"}`))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, newIdempotentRequest("k1", `{"author":"A"}`))
+
+		if calls.Load() != 1 {
+			t.Fatalf("Expected handler to run once, ran %d times", calls.Load())
+		}
+		if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+			t.Errorf("Replay differs: %d %q", second.Code, second.Body.String())
+		}
+		if second.Header().Get("Location") != "/quotes/1" || second.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("Unexpected replay headers: %v", second.Header())
+		}
+	})
+
+	t.Run("different payload", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		handler := newIdempotentHandler(&calls)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("k1", `{"author":"A"}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newIdempotentRequest("k1", `{"author":"B"}`))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		handler := newIdempotentHandler(&calls)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, newIdempotentRequest("shared", `{"author":"A"}`))
+				if w.Code != http.StatusCreated || w.Body.String() != "1" {
+					t.Errorf("Unexpected response: %d %q", w.Code, w.Body.String())
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("Expected handler to run once, ran %d times", calls.Load())
+		}
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		handler := newIdempotentHandler(&calls)
+
+		for range 2 {
+			req := newIdempotentRequest("", `{"author":"A"}`)
+			req.Header.Del("Idempotency-Key")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if calls.Load() != 2 {
+			t.Errorf("Expected handler to run twice, ran %d times", calls.Load())
+		}
+	})
+}
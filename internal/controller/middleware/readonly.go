@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ReadOnly lets reads through and turns writes away with 421 and a
+// Location on the leader. With a proxy, writes are forwarded there instead.
+func ReadOnly(leader string, proxy http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isRead(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if proxy != nil {
+				proxy.ServeHTTP(w, r)
+				return
+			}
+			misdirected(w, r, leader, "Read-only replica, send writes to the leader")
+		})
+	}
+}
+
+// LeaderWrites is ReadOnly for a leader that can move: leader reports the
+// current leader's address and whether it is this node. Writes are served
+// here on the leader, forwarded or redirected elsewhere, and refused with
+// 503 while the cluster has no leader.
+func LeaderWrites(leader func() (addr string, self bool), proxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isRead(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			addr, self := leader()
+			switch {
+			case self:
+				next.ServeHTTP(w, r)
+			case addr == "":
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "No leader, try again later", http.StatusServiceUnavailable)
+			case proxy:
+				target, err := url.Parse(addr)
+				if err != nil {
+					http.Error(w, "Invalid leader address", http.StatusBadGateway)
+					return
+				}
+				httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+			default:
+				misdirected(w, r, addr, "Not the leader, send writes to the leader")
+			}
+		})
+	}
+}
+
+// Linearizable holds every request until barrier confirms this node has
+// seen all writes committed before it arrived. Writes wait too: they read
+// the current state before proposing a change to it.
+func Linearizable(barrier func(ctx context.Context) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodOptions {
+				if err := barrier(r.Context()); err != nil {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "Cluster unavailable, try again later", http.StatusServiceUnavailable)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isRead(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func misdirected(w http.ResponseWriter, r *http.Request, leader, msg string) {
+	w.Header().Set("Location", leader+r.URL.RequestURI())
+	http.Error(w, msg, http.StatusMisdirectedRequest)
+}
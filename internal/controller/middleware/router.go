@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type routeNameKey struct{}
+
+type Router struct {
+	mux     *http.ServeMux
+	methods map[string]struct{}
+}
+
+func NewRouter() *Router {
+	return &Router{
+		mux:     http.NewServeMux(),
+		methods: make(map[string]struct{}),
+	}
+}
+
+func (rt *Router) Handle(name, method, path string, handler http.Handler) {
+	rt.methods[method] = struct{}{}
+	rt.mux.Handle(method+" "+path, named(name, method, handler))
+}
+
+func (rt *Router) HandleFunc(name, method, path string, handler http.HandlerFunc) {
+	rt.Handle(name, method, path, handler)
+}
+
+// ServeHTTP answers OPTIONS itself. Registering an OPTIONS pattern per path
+// would make paths such as /quotes/{id}/restore and /quotes/daily/{date}
+// conflict even though no real method is registered on both.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		if allow := rt.allow(r); allow != "" {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+func (rt *Router) allow(r *http.Request) string {
+	var allowed []string
+	for method := range rt.methods {
+		probe := *r
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(&probe); pattern == "" {
+			continue
+		}
+		allowed = append(allowed, method)
+		if method == http.MethodGet {
+			allowed = append(allowed, http.MethodHead)
+		}
+	}
+	if len(allowed) == 0 {
+		return ""
+	}
+	allowed = append(allowed, http.MethodOptions)
+	sort.Strings(allowed)
+	return strings.Join(allowed, ", ")
+}
+
+func named(name, method string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), routeNameKey{}, name))
+		if r.Method == http.MethodHead && method == http.MethodGet {
+			r.Method = http.MethodGet
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func RouteName(r *http.Request) string {
+	name, _ := r.Context().Value(routeNameKey{}).(string)
+	return name
+}
+
+type Group struct {
+	router *Router
+	prefix string
+	wrap   []func(http.Handler) http.Handler
+}
+
+func (rt *Router) Group(prefix string, wrap ...func(http.Handler) http.Handler) *Group {
+	return &Group{router: rt, prefix: prefix, wrap: wrap}
+}
+
+func (g *Group) Handle(name, method, path string, handler http.Handler) {
+	for i := len(g.wrap) - 1; i >= 0; i-- {
+		handler = g.wrap[i](handler)
+	}
+	if version := strings.TrimPrefix(g.prefix, "/"); version != "" {
+		name = version + "." + name
+	}
+	g.router.Handle(name, method, g.prefix+path, handler)
+}
+
+func (g *Group) HandleFunc(name, method, path string, handler http.HandlerFunc) {
+	g.Handle(name, method, path, handler)
+}
@@ -0,0 +1,179 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+)
+
+func newTestRouter() *middleware.Router {
+	router := middleware.NewRouter()
+	router.HandleFunc("quotes.list", http.MethodGet, "/quotes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("X-Route", middleware.RouteName(r))
+		_, _ = w.Write([]byte("list"))
+	})
+	router.HandleFunc("quotes.delete", http.MethodDelete, "/quotes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Id", r.PathValue("id"))
+		w.Header().Set("X-Route", middleware.RouteName(r))
+	})
+	return router
+}
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("route name", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, "/quotes", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if name := w.Header().Get("X-Route"); name != "quotes.list" {
+			t.Errorf("Expected route quotes.list, got %q", name)
+		}
+	})
+
+	t.Run("path value", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/42", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if id := w.Header().Get("X-Id"); id != "42" {
+			t.Errorf("Expected id 42, got %q", id)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodDelete, "/quotes/1/extra", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodPut, "/quotes", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow == "" {
+			t.Error("Expected Allow header")
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodHead, "/quotes", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodOptions, "/quotes", nil)
+		w := httptest.NewRecorder()
+
+		newTestRouter().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+			t.Errorf("Unexpected Allow header: %q", allow)
+		}
+	})
+
+	t.Run("options on overlapping paths", func(t *testing.T) {
+		t.Parallel()
+		router := newTestRouter()
+		router.HandleFunc("quotes.restore", http.MethodPost, "/quotes/{id}/restore", func(http.ResponseWriter, *http.Request) {})
+		router.HandleFunc("quotes.daily.pin", http.MethodPut, "/quotes/daily/{date}", func(http.ResponseWriter, *http.Request) {})
+		for path, want := range map[string]string{
+			"/quotes/1/restore":     "OPTIONS, POST",
+			"/quotes/daily/2026-01": "OPTIONS, PUT",
+			"/quotes/daily/restore": "OPTIONS, POST, PUT",
+		} {
+			req := httptest.NewRequest(http.MethodOptions, path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if allow := w.Header().Get("Allow"); w.Code != http.StatusNoContent || allow != want {
+				t.Errorf("OPTIONS %s: %d %q, want %q", path, w.Code, allow, want)
+			}
+		}
+	})
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	router := middleware.NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Route", middleware.RouteName(r))
+	}
+	since := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+	router.Group("/v1").HandleFunc("quotes.list", http.MethodGet, "/quotes", handler)
+	router.Group("", middleware.Deprecated(since, sunset, "/v1")).HandleFunc("quotes.list", http.MethodGet, "/quotes", handler)
+
+	t.Run("versioned", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, "/v1/quotes", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if name := w.Header().Get("X-Route"); name != "v1.quotes.list" {
+			t.Errorf("Expected route v1.quotes.list, got %q", name)
+		}
+		if w.Header().Get("Deprecation") != "" {
+			t.Error("Versioned route must not be deprecated")
+		}
+	})
+
+	t.Run("deprecated alias", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodGet, "/quotes", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if dep := w.Header().Get("Deprecation"); dep != "@1792368000" {
+			t.Errorf("Unexpected Deprecation header: %q", dep)
+		}
+		if s := w.Header().Get("Sunset"); s != "Thu, 01 Apr 2027 00:00:00 GMT" {
+			t.Errorf("Unexpected Sunset header: %q", s)
+		}
+		if link := w.Header().Get("Link"); link != `</v1/quotes>; rel="successor-version"` {
+			t.Errorf("Unexpected Link header: %q", link)
+		}
+	})
+}
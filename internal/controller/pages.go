@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+const defaultPageSize = 50
+
+func (h *UsecaseHandler) list(w http.ResponseWriter, r *http.Request, filter entity.Filter, cursor, limit string) {
+	size := defaultPageSize
+	if limit != "" {
+		var err error
+		if size, err = strconv.Atoi(limit); err != nil {
+			http.Error(w, "invalid parameter \"limit\"", http.StatusBadRequest)
+			return
+		}
+	}
+	page, err := h.service.List(filter, cursor, size)
+	switch {
+	case errors.Is(err, usecase.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, usecase.ErrCursorExpired):
+		http.Error(w, "cursor expired, restart the listing", http.StatusGone)
+		return
+	case err != nil:
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	if page.Next != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", page.Next)
+		query.Set("limit", strconv.Itoa(size))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", "<"+next.RequestURI()+">; rel=\"next\"")
+	}
+	writeJSON(w, http.StatusOK, v1.FromPage(page))
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	defaultCommitsLimit = 1000
+	maxCommitsWait      = time.Minute
+)
+
+// ReplicaReporter is implemented by a follower that can describe how far
+// behind its leader it is.
+type ReplicaReporter interface {
+	Status() entity.ReplicaStatus
+}
+
+// Checkpoint serves the whole store for a follower to bootstrap from.
+func Checkpoint(source repo.ChangeLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		writeJSON(w, http.StatusOK, v1.FromCheckpoint(source.Checkpoint()))
+	}
+}
+
+// Commits serves the commits after since. With wait it long-polls: the
+// response is held until a commit arrives or wait runs out.
+func Commits(source repo.ChangeLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.IsAdmin(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		query := r.URL.Query()
+		since, err := parseOffset(query.Get("since"))
+		if err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		limit := defaultCommitsLimit
+		if value := query.Get("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > defaultCommitsLimit {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("wait"); value != "" {
+			wait, err := time.ParseDuration(value)
+			if err != nil || wait < 0 || wait > maxCommitsWait {
+				http.Error(w, "Invalid wait", http.StatusBadRequest)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), wait)
+			err = source.WaitCommit(ctx, since)
+			cancel()
+			if err != nil && r.Context().Err() != nil {
+				return
+			}
+		}
+		commits, err := source.Commits(since, limit)
+		if errors.Is(err, repo.ErrOffsetOutOfRange) {
+			http.Error(w, err.Error()+", start over from the checkpoint", http.StatusGone)
+			return
+		}
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		seq, _ := source.Version()
+		writeJSON(w, http.StatusOK, v1.FromCommits(seq, commits))
+	}
+}
+
+// parseOffset reads an offset as written by entity.Offset: the log id and
+// the seq joined by a dash, or a bare seq.
+func parseOffset(value string) (entity.Offset, error) {
+	log, seq, found := strings.Cut(value, "-")
+	if !found {
+		log, seq = "", value
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return entity.Offset{}, err
+	}
+	return entity.Offset{Log: log, Seq: n}, nil
+}
+
+func ReplicaStatus(source ReplicaReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, v1.FromReplicaStatus(source.Status()))
+	}
+}
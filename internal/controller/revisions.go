@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func (h *UsecaseHandler) Revisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	revs, err := h.service.Revisions(r.PathValue("id"))
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromRevisions(revs))
+}
+
+func (h *UsecaseHandler) Revision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		http.Error(w, "invalid revision number", http.StatusBadRequest)
+		return
+	}
+	rev, err := h.service.Revision(r.PathValue("id"), n)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromRevision(rev))
+}
+
+func (h *UsecaseHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		http.Error(w, "invalid revision number", http.StatusBadRequest)
+		return
+	}
+	key := r.PathValue("id")
+	current, err := h.service.Get(key)
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	var version uint64
+	if conditional, match := ifMatch(r, quoteETag(current.Version)); !match {
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	} else if conditional {
+		version = current.Version
+	}
+	quote, err := h.service.Revert(requestContext(r), key, n, version)
+	switch {
+	case errors.Is(err, usecase.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	case errors.Is(err, usecase.ErrPreconditionFailed):
+		http.Error(w, "Precondition failed", http.StatusPreconditionFailed)
+		return
+	case errors.Is(err, usecase.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, usecase.ErrValidation):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, usecase.ErrInsufficientStorage):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		return
+	case errors.Is(err, usecase.ErrUnavailable):
+		unavailable(w, err)
+		return
+	case err != nil:
+		log.Println(err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+	setValidators(w, quoteETag(quote.Version), quote.UpdatedAt)
+	writeJSON(w, http.StatusOK, v1.FromEntity(quote))
+}
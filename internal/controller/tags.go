@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func (h *UsecaseHandler) Tags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	counts, err := h.service.Tags()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromTagCounts(counts))
+}
+
+// RenameTag and MergeTags rewrite every quote with the tag, so like the
+// other bulk operations they are for admins only.
+func (h *UsecaseHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req v1.RenameTagRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	updated, err := h.service.RenameTag(requestContext(r), r.PathValue("tag"), req.Name)
+	writeTagsUpdated(w, updated, err)
+}
+
+func (h *UsecaseHandler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !middleware.IsAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req v1.MergeTagsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	updated, err := h.service.MergeTags(requestContext(r), req.Tags, req.Into)
+	writeTagsUpdated(w, updated, err)
+}
+
+func writeTagsUpdated(w http.ResponseWriter, updated int, err error) {
+	if errors.Is(err, usecase.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.TagsUpdated{Updated: updated})
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func (h *UsecaseHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quotes, err := h.service.Trash()
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v1.FromEntities(quotes))
+}
+
+func (h *UsecaseHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quote, err := h.service.Restore(requestContext(r), r.PathValue("id"))
+	if errors.Is(err, usecase.ErrNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		storageFailure(w, err)
+		return
+	}
+	setValidators(w, quoteETag(quote.Version), quote.UpdatedAt)
+	writeJSON(w, http.StatusOK, v1.FromEntity(quote))
+}
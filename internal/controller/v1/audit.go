@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+type AuditEntry struct {
+	Seq          uint64    `json:"seq"`
+	At           time.Time `json:"at"`
+	Actor        string    `json:"actor"`
+	IP           string    `json:"ip,omitempty"`
+	RequestId    string    `json:"request_id,omitempty"`
+	Operation    string    `json:"operation"`
+	QuoteId      string    `json:"quote_id"`
+	Before       *Quote    `json:"before,omitempty"`
+	After        *Quote    `json:"after,omitempty"`
+	Subject      string    `json:"subject,omitempty"`
+	AuthorBefore *Author   `json:"author_before,omitempty"`
+	AuthorAfter  *Author   `json:"author_after,omitempty"`
+	ValueBefore  string    `json:"value_before,omitempty"`
+	ValueAfter   string    `json:"value_after,omitempty"`
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash"`
+}
+
+type AuditResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+func FromAuditEntries(entries []entity.AuditEntry) AuditResponse {
+	resp := AuditResponse{Entries: make([]AuditEntry, 0, len(entries))}
+	for _, entry := range entries {
+		res := AuditEntry{
+			Seq:         entry.Seq,
+			At:          entry.At,
+			Actor:       entry.Actor.Name,
+			IP:          entry.Actor.IP,
+			RequestId:   entry.Actor.RequestId,
+			Operation:   entry.Operation,
+			QuoteId:     entry.QuoteId,
+			Subject:     entry.Subject,
+			ValueBefore: entry.ValueBefore,
+			ValueAfter:  entry.ValueAfter,
+			PrevHash:    entry.PrevHash,
+			Hash:        entry.Hash,
+		}
+		if entry.Before != nil {
+			before := FromEntity(*entry.Before)
+			res.Before = &before
+		}
+		if entry.After != nil {
+			after := FromEntity(*entry.After)
+			res.After = &after
+		}
+		if entry.AuthorBefore != nil {
+			before := FromAuthor(*entry.AuthorBefore)
+			res.AuthorBefore = &before
+		}
+		if entry.AuthorAfter != nil {
+			after := FromAuthor(*entry.AuthorAfter)
+			res.AuthorAfter = &after
+		}
+		resp.Entries = append(resp.Entries, res)
+	}
+	return resp
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+type Author struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Bio        string     `json:"bio,omitempty"`
+	BirthDate  string     `json:"birth_date,omitempty"`
+	DeathDate  string     `json:"death_date,omitempty"`
+	PhotoURL   string     `json:"photo_url,omitempty"`
+	QuoteCount int        `json:"quote_count"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+type AuthorsResponse struct {
+	Authors []Author `json:"authors"`
+}
+
+func FromAuthor(author entity.Author) Author {
+	res := Author{
+		Id:         author.Id,
+		Name:       author.Name,
+		Bio:        author.Bio,
+		BirthDate:  author.BirthDate,
+		DeathDate:  author.DeathDate,
+		PhotoURL:   author.PhotoURL,
+		QuoteCount: author.QuoteCount,
+	}
+	if !author.CreatedAt.IsZero() {
+		res.CreatedAt = &author.CreatedAt
+	}
+	if !author.UpdatedAt.IsZero() {
+		res.UpdatedAt = &author.UpdatedAt
+	}
+	return res
+}
+
+func FromAuthors(authors []entity.Author) AuthorsResponse {
+	resp := AuthorsResponse{Authors: make([]Author, 0, len(authors))}
+	for _, author := range authors {
+		resp.Authors = append(resp.Authors, FromAuthor(author))
+	}
+	return resp
+}
+
+func (a Author) ToEntity() entity.Author {
+	return entity.Author{
+		Id:        a.Id,
+		Name:      a.Name,
+		Bio:       a.Bio,
+		BirthDate: a.BirthDate,
+		DeathDate: a.DeathDate,
+		PhotoURL:  a.PhotoURL,
+	}
+}
@@ -0,0 +1,54 @@
+package v1
+
+import "github.com/paxaf/BrandScoutTest/internal/entity"
+
+type BatchOp struct {
+	Op      string `json:"op"`
+	Id      string `json:"id,omitempty"`
+	Version uint64 `json:"version,omitempty"`
+	Quote   *Quote `json:"quote,omitempty"`
+}
+
+type BatchRequest struct {
+	Ops []BatchOp `json:"ops"`
+}
+
+type BatchResult struct {
+	Op    string `json:"op"`
+	Id    string `json:"id"`
+	Quote *Quote `json:"quote,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+type BatchError struct {
+	Error string `json:"error"`
+	Index *int   `json:"index,omitempty"`
+}
+
+func (r BatchRequest) ToEntity() []entity.BatchOp {
+	ops := make([]entity.BatchOp, 0, len(r.Ops))
+	for _, op := range r.Ops {
+		res := entity.BatchOp{Kind: op.Op, Id: op.Id, Version: op.Version}
+		if op.Quote != nil {
+			res.Quote = op.Quote.ToEntity()
+		}
+		ops = append(ops, res)
+	}
+	return ops
+}
+
+func FromBatchResults(results []entity.BatchResult) BatchResponse {
+	resp := BatchResponse{Results: make([]BatchResult, 0, len(results))}
+	for _, result := range results {
+		res := BatchResult{Op: result.Kind, Id: result.Id}
+		if result.Quote != nil {
+			quote := FromEntity(*result.Quote)
+			res.Quote = &quote
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	return resp
+}
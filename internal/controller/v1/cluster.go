@@ -0,0 +1,38 @@
+package v1
+
+import "github.com/paxaf/BrandScoutTest/internal/entity"
+
+type Member struct {
+	Id   uint64 `json:"id"`
+	Addr string `json:"addr"`
+}
+
+type ClusterStatus struct {
+	Id      uint64   `json:"id"`
+	State   string   `json:"state"`
+	Term    uint64   `json:"term"`
+	Leader  uint64   `json:"leader"`
+	Commit  uint64   `json:"commit"`
+	Applied uint64   `json:"applied"`
+	Members []Member `json:"members"`
+}
+
+func FromClusterStatus(status entity.ClusterStatus) ClusterStatus {
+	res := ClusterStatus{
+		Id:      status.ID,
+		State:   status.State,
+		Term:    status.Term,
+		Leader:  status.Leader,
+		Commit:  status.Commit,
+		Applied: status.Applied,
+		Members: make([]Member, 0, len(status.Members)),
+	}
+	for _, member := range status.Members {
+		res.Members = append(res.Members, Member{Id: member.ID, Addr: member.Addr})
+	}
+	return res
+}
+
+func (m Member) ToEntity() entity.ClusterMember {
+	return entity.ClusterMember{ID: m.Id, Addr: m.Addr}
+}
@@ -0,0 +1,274 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+type Quote struct {
+	Id        string     `json:"id"`
+	AuthorId  string     `json:"author_id,omitempty"`
+	Author    string     `json:"author"`
+	Phrase    string     `json:"quote"`
+	Source    *Source    `json:"source,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Language  string     `json:"language,omitempty"`
+	Rating    float64    `json:"rating,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpireAt  *time.Time `json:"expire_at,omitempty"`
+}
+
+type Source struct {
+	Kind  string `json:"kind,omitempty"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Year  int    `json:"year,omitempty"`
+}
+
+type QuotePatch struct {
+	AuthorId  *string    `json:"author_id"`
+	Author    *string    `json:"author"`
+	Phrase    *string    `json:"quote"`
+	Source    *Source    `json:"source"`
+	Tags      *[]string  `json:"tags"`
+	Language  *string    `json:"language"`
+	Rating    *float64   `json:"rating"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpireAt  *time.Time `json:"expire_at"`
+}
+
+type QuoteResponse struct {
+	Quotes []Quote `json:"quotes"`
+}
+
+type QuotePage struct {
+	Quotes     []Quote `json:"quotes"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type DuplicatesResponse struct {
+	Groups []QuoteResponse `json:"groups"`
+}
+
+type DuplicateConflict struct {
+	Error      string `json:"error"`
+	ExistingId string `json:"existing_id"`
+}
+
+type MergeRequest struct {
+	Ids []string `json:"ids"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type TagsResponse struct {
+	Tags []TagCount `json:"tags"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagsRequest struct {
+	Tags []string `json:"tags"`
+	Into string   `json:"into"`
+}
+
+type TagsUpdated struct {
+	Updated int `json:"updated"`
+}
+
+type AuthorAlias struct {
+	Alias     string `json:"alias"`
+	Canonical string `json:"canonical"`
+}
+
+type AliasesResponse struct {
+	Aliases []AuthorAlias `json:"aliases"`
+}
+
+type SetAliasRequest struct {
+	Canonical string `json:"canonical"`
+}
+
+func FromAliases(aliases []entity.AuthorAlias) AliasesResponse {
+	resp := AliasesResponse{Aliases: make([]AuthorAlias, 0, len(aliases))}
+	for _, alias := range aliases {
+		resp.Aliases = append(resp.Aliases, AuthorAlias{Alias: alias.Alias, Canonical: alias.Canonical})
+	}
+	return resp
+}
+
+type DailyPin struct {
+	Date    string `json:"date"`
+	QuoteId string `json:"quote_id"`
+}
+
+type DailyPinsResponse struct {
+	Pins []DailyPin `json:"pins"`
+}
+
+type PinRequest struct {
+	Id string `json:"id"`
+}
+
+func FromDailyPins(pins []entity.DailyPin) DailyPinsResponse {
+	resp := DailyPinsResponse{Pins: make([]DailyPin, 0, len(pins))}
+	for _, pin := range pins {
+		resp.Pins = append(resp.Pins, DailyPin{Date: pin.Date, QuoteId: pin.QuoteId})
+	}
+	return resp
+}
+
+func FromTagCounts(counts []entity.TagCount) TagsResponse {
+	resp := TagsResponse{Tags: make([]TagCount, 0, len(counts))}
+	for _, count := range counts {
+		resp.Tags = append(resp.Tags, TagCount{Tag: count.Tag, Count: count.Count})
+	}
+	return resp
+}
+
+func FromEntity(quote entity.Quote) Quote {
+	res := Quote{
+		Id:        quote.Id,
+		AuthorId:  quote.AuthorId,
+		Author:    quote.Author,
+		Phrase:    quote.Phrase,
+		Tags:      quote.Tags,
+		Language:  quote.Language,
+		Rating:    quote.Rating,
+		Source:    fromSource(quote.Source),
+		UpdatedBy: quote.UpdatedBy,
+	}
+	if !quote.CreatedAt.IsZero() {
+		res.CreatedAt = &quote.CreatedAt
+	}
+	if !quote.UpdatedAt.IsZero() {
+		res.UpdatedAt = &quote.UpdatedAt
+	}
+	if !quote.DeletedAt.IsZero() {
+		res.DeletedAt = &quote.DeletedAt
+	}
+	if !quote.PublishAt.IsZero() {
+		res.PublishAt = &quote.PublishAt
+	}
+	if !quote.ExpireAt.IsZero() {
+		res.ExpireAt = &quote.ExpireAt
+	}
+	return res
+}
+
+func fromSource(source *entity.Source) *Source {
+	if source == nil {
+		return nil
+	}
+	return &Source{
+		Kind:  source.Kind,
+		Title: source.Title,
+		URL:   source.URL,
+		Year:  source.Year,
+	}
+}
+
+func FromPage(page entity.Page) QuotePage {
+	return QuotePage{
+		Quotes:     FromEntities(page.Quotes).Quotes,
+		NextCursor: page.Next,
+	}
+}
+
+// FromEntities keeps the wire shape the unversioned API had: no quotes
+// at all are null, an empty result is an empty list.
+func FromEntities(quotes []entity.Quote) QuoteResponse {
+	var resp QuoteResponse
+	if quotes != nil {
+		resp.Quotes = make([]Quote, 0, len(quotes))
+	}
+	for _, quote := range quotes {
+		resp.Quotes = append(resp.Quotes, FromEntity(quote))
+	}
+	return resp
+}
+
+func FromGroups(groups [][]entity.Quote) DuplicatesResponse {
+	resp := DuplicatesResponse{Groups: make([]QuoteResponse, 0, len(groups))}
+	for _, group := range groups {
+		resp.Groups = append(resp.Groups, FromEntities(group))
+	}
+	return resp
+}
+
+func (q Quote) ToEntity() entity.Quote {
+	res := entity.Quote{
+		Id:       q.Id,
+		AuthorId: q.AuthorId,
+		Author:   q.Author,
+		Phrase:   q.Phrase,
+		Source:   q.Source.toEntity(),
+		Tags:     q.Tags,
+		Language: q.Language,
+		Rating:   q.Rating,
+	}
+	if q.PublishAt != nil {
+		res.PublishAt = *q.PublishAt
+	}
+	if q.ExpireAt != nil {
+		res.ExpireAt = *q.ExpireAt
+	}
+	return res
+}
+
+func (s *Source) toEntity() *entity.Source {
+	if s == nil {
+		return nil
+	}
+	return &entity.Source{
+		Kind:  s.Kind,
+		Title: s.Title,
+		URL:   s.URL,
+		Year:  s.Year,
+	}
+}
+
+func (p QuotePatch) Apply(quote entity.Quote) entity.Quote {
+	if p.Author != nil {
+		quote.Author = *p.Author
+		quote.AuthorId = ""
+	}
+	if p.AuthorId != nil {
+		quote.AuthorId = *p.AuthorId
+		if p.Author == nil {
+			quote.Author = ""
+		}
+	}
+	if p.Phrase != nil {
+		quote.Phrase = *p.Phrase
+	}
+	if p.Source != nil {
+		quote.Source = p.Source.toEntity()
+	}
+	if p.Tags != nil {
+		quote.Tags = *p.Tags
+	}
+	if p.Language != nil {
+		quote.Language = *p.Language
+	}
+	if p.Rating != nil {
+		quote.Rating = *p.Rating
+	}
+	if p.PublishAt != nil {
+		quote.PublishAt = *p.PublishAt
+	}
+	if p.ExpireAt != nil {
+		quote.ExpireAt = *p.ExpireAt
+	}
+	return quote
+}
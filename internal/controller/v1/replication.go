@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+// Mutation carries the quote as it is stored, version included, the same
+// way the write-ahead log does, so a replica ends up byte for byte equal.
+// Side-table mutations carry an author, a revision or a target instead.
+type Mutation struct {
+	Op       string           `json:"op"`
+	Key      string           `json:"key"`
+	Quote    *entity.Quote    `json:"quote,omitempty"`
+	Target   string           `json:"target,omitempty"`
+	Author   *entity.Author   `json:"author,omitempty"`
+	Revision *entity.Revision `json:"revision,omitempty"`
+}
+
+type Commit struct {
+	Seq uint64     `json:"seq"`
+	Ops []Mutation `json:"ops"`
+}
+
+type Checkpoint struct {
+	Log string     `json:"log"`
+	Seq uint64     `json:"seq"`
+	Ops []Mutation `json:"ops"`
+}
+
+type CommitsResponse struct {
+	Seq     uint64   `json:"seq"`
+	Commits []Commit `json:"commits"`
+}
+
+type ReplicaStatus struct {
+	Leader     string     `json:"leader"`
+	Seq        uint64     `json:"seq"`
+	LeaderSeq  uint64     `json:"leader_seq"`
+	Lag        uint64     `json:"lag"`
+	LastSync   *time.Time `json:"last_sync,omitempty"`
+	Bootstraps int        `json:"bootstraps"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func fromMutations(ops []entity.Mutation) []Mutation {
+	res := make([]Mutation, len(ops))
+	for i, op := range ops {
+		res[i] = Mutation{Op: op.Kind, Key: op.Key, Quote: op.Quote, Target: op.Target, Author: op.Author, Revision: op.Revision}
+	}
+	return res
+}
+
+func toMutations(ops []Mutation) []entity.Mutation {
+	res := make([]entity.Mutation, len(ops))
+	for i, op := range ops {
+		res[i] = entity.Mutation{Kind: op.Op, Key: op.Key, Quote: op.Quote, Target: op.Target, Author: op.Author, Revision: op.Revision}
+	}
+	return res
+}
+
+func FromCheckpoint(checkpoint entity.Checkpoint) Checkpoint {
+	return Checkpoint{Log: checkpoint.Log, Seq: checkpoint.Seq, Ops: fromMutations(checkpoint.Ops)}
+}
+
+func (c Checkpoint) ToEntity() entity.Checkpoint {
+	return entity.Checkpoint{Log: c.Log, Seq: c.Seq, Ops: toMutations(c.Ops)}
+}
+
+func FromCommits(seq uint64, commits []entity.Commit) CommitsResponse {
+	resp := CommitsResponse{Seq: seq, Commits: make([]Commit, 0, len(commits))}
+	for _, commit := range commits {
+		resp.Commits = append(resp.Commits, Commit{Seq: commit.Seq, Ops: fromMutations(commit.Ops)})
+	}
+	return resp
+}
+
+func (c Commit) ToEntity() entity.Commit {
+	return entity.Commit{Seq: c.Seq, Ops: toMutations(c.Ops)}
+}
+
+func FromReplicaStatus(status entity.ReplicaStatus) ReplicaStatus {
+	res := ReplicaStatus{
+		Leader:     status.Leader,
+		Seq:        status.Seq,
+		LeaderSeq:  status.LeaderSeq,
+		Bootstraps: status.Bootstraps,
+		Error:      status.Err,
+	}
+	if status.LeaderSeq > status.Seq {
+		res.Lag = status.LeaderSeq - status.Seq
+	}
+	if !status.LastSync.IsZero() {
+		res.LastSync = &status.LastSync
+	}
+	return res
+}
+
+type Change struct {
+	Seq   uint64 `json:"seq"`
+	Type  string `json:"type"`
+	Id    string `json:"id"`
+	Quote *Quote `json:"quote,omitempty"`
+}
+
+// ChangesResponse carries Next, the offset to pass as since for the
+// changes that follow, and Seq, the latest seq of the store.
+type ChangesResponse struct {
+	Seq     uint64   `json:"seq"`
+	Next    string   `json:"next"`
+	Changes []Change `json:"changes"`
+}
+
+// ChangesSnapshot carries Next, the offset to follow the quotes from.
+type ChangesSnapshot struct {
+	Seq    uint64  `json:"seq"`
+	Next   string  `json:"next"`
+	Quotes []Quote `json:"quotes"`
+}
+
+type ChangesError struct {
+	Error    string `json:"error"`
+	Snapshot string `json:"snapshot"`
+}
+
+func FromChange(change entity.Change) Change {
+	res := Change{Seq: change.Seq, Type: change.Type, Id: change.Key}
+	if change.Quote != nil {
+		quote := FromEntity(*change.Quote)
+		res.Quote = &quote
+	}
+	return res
+}
+
+func FromChanges(seq uint64, next entity.Offset, changes []entity.Change) ChangesResponse {
+	resp := ChangesResponse{Seq: seq, Next: next.String(), Changes: make([]Change, 0, len(changes))}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, FromChange(change))
+	}
+	return resp
+}
+
+// FromCheckpointQuotes keeps the live quotes of a checkpoint, the state a
+// change feed starts from.
+func FromCheckpointQuotes(checkpoint entity.Checkpoint) ChangesSnapshot {
+	next := entity.Offset{Log: checkpoint.Log, Seq: checkpoint.Seq}
+	res := ChangesSnapshot{Seq: checkpoint.Seq, Next: next.String(), Quotes: make([]Quote, 0, len(checkpoint.Ops))}
+	for _, op := range checkpoint.Ops {
+		if op.Kind == entity.MutationSet && op.Quote != nil {
+			res.Quotes = append(res.Quotes, FromEntity(*op.Quote))
+		}
+	}
+	return res
+}
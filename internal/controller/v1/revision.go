@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type Revision struct {
+	Number  int           `json:"revision"`
+	Actor   string        `json:"actor,omitempty"`
+	At      time.Time     `json:"at"`
+	Changes []FieldChange `json:"changes"`
+	Quote   *Quote        `json:"quote,omitempty"`
+}
+
+type RevisionsResponse struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+func FromRevision(rev entity.Revision) Revision {
+	quote := FromEntity(rev.Quote)
+	res := FromRevisionSummary(rev)
+	res.Quote = &quote
+	return res
+}
+
+func FromRevisionSummary(rev entity.Revision) Revision {
+	res := Revision{
+		Number:  rev.Number,
+		Actor:   rev.Actor,
+		At:      rev.At,
+		Changes: make([]FieldChange, 0, len(rev.Changes)),
+	}
+	for _, change := range rev.Changes {
+		res.Changes = append(res.Changes, FieldChange{
+			Field: change.Field,
+			Old:   fromFieldValue(change.Old),
+			New:   fromFieldValue(change.New),
+		})
+	}
+	return res
+}
+
+func FromRevisions(revs []entity.Revision) RevisionsResponse {
+	resp := RevisionsResponse{Revisions: make([]Revision, 0, len(revs))}
+	for _, rev := range revs {
+		resp.Revisions = append(resp.Revisions, FromRevisionSummary(rev))
+	}
+	return resp
+}
+
+func fromFieldValue(value any) any {
+	if source, ok := value.(*entity.Source); ok {
+		return fromSource(source)
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package entity
+
+import "time"
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditRevert  = "revert"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	AuditExpire  = "expire"
+
+	AuditAuthorCreate = "author_create"
+	AuditAuthorUpdate = "author_update"
+	AuditAuthorDelete = "author_delete"
+	AuditAliasSet     = "alias_set"
+	AuditAliasDelete  = "alias_delete"
+	AuditPin          = "pin"
+	AuditUnpin        = "unpin"
+)
+
+type Actor struct {
+	Name      string
+	IP        string
+	RequestId string
+}
+
+type AuditEntry struct {
+	Seq       uint64
+	At        time.Time
+	Actor     Actor
+	Operation string
+	QuoteId   string
+	Before    *Quote
+	After     *Quote
+	// Subject is the author id, alias or pin date an operation on the side
+	// tables changed. An author is kept whole, an alias or a pin by the
+	// canonical spelling or the quote id it points to.
+	Subject      string
+	AuthorBefore *Author
+	AuthorAfter  *Author
+	ValueBefore  string
+	ValueAfter   string
+	PrevHash     string
+	Hash         string
+}
+
+type AuditFilter struct {
+	Actor     string
+	Operation string
+	QuoteId   string
+	Subject   string
+	From      time.Time
+	To        time.Time
+}
+
+func (f AuditFilter) Match(entry AuditEntry) bool {
+	if f.Actor != "" && entry.Actor.Name != f.Actor {
+		return false
+	}
+	if f.Operation != "" && entry.Operation != f.Operation {
+		return false
+	}
+	if f.QuoteId != "" && entry.QuoteId != f.QuoteId {
+		return false
+	}
+	if f.Subject != "" && entry.Subject != f.Subject {
+		return false
+	}
+	if !f.From.IsZero() && entry.At.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.At.After(f.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+type AuthorAlias struct {
+	Alias     string
+	Canonical string
+}
+
+// foldedLetters spells out letters that carry no combining mark after
+// decomposition.
+var foldedLetters = map[rune]string{
+	'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ħ': "h", 'ı': "i", 'ł': "l", 'ŧ': "t",
+}
+
+// keptLetters are letters of their own in Cyrillic alphabets rather than
+// accented variants, so their marks are not dropped.
+var keptLetters = map[rune]bool{'й': true, 'ў': true, 'ї': true}
+
+// NormalizeAuthor is the form author names are compared in: case-folded,
+// decomposed with the combining marks dropped, and with every run of
+// punctuation and spaces collapsed to one space.
+func NormalizeAuthor(name string) string {
+	var b strings.Builder
+	space := true
+	for _, letter := range norm.NFC.String(cases.Fold().String(name)) {
+		decomposed := string(letter)
+		if !keptLetters[letter] {
+			decomposed = norm.NFD.String(decomposed)
+		}
+		for _, r := range decomposed {
+			switch {
+			case unicode.Is(unicode.Mn, r):
+				continue
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if folded, ok := foldedLetters[r]; ok {
+					b.WriteString(folded)
+				} else {
+					b.WriteRune(r)
+				}
+				space = false
+			case !space:
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type Author struct {
+	Id         string
+	Name       string
+	Bio        string
+	BirthDate  string
+	DeathDate  string
+	PhotoURL   string
+	QuoteCount int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,20 @@
+package entity
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type BatchOp struct {
+	Kind    string
+	Id      string
+	Version uint64
+	Quote   Quote
+}
+
+type BatchResult struct {
+	Kind  string
+	Id    string
+	Quote *Quote
+}
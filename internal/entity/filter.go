@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type AuthorMatch int
+
+const (
+	AuthorExact AuthorMatch = iota
+	AuthorPrefix
+	AuthorCaseInsensitive
+)
+
+type Filter struct {
+	AuthorId       string
+	Author         string
+	AuthorMatch    AuthorMatch
+	AuthorVariants []string
+	Phrase         string
+	Tags           []string
+	AnyTag         bool
+	Language       string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	MinLength      int
+	MaxLength      int
+	VisibleAt      time.Time
+}
+
+func (f Filter) IsEmpty() bool {
+	return f.AuthorId == "" && f.Author == "" && f.Phrase == "" && len(f.Tags) == 0 && f.Language == "" &&
+		f.CreatedFrom.IsZero() && f.CreatedTo.IsZero() && f.MinLength == 0 && f.MaxLength == 0
+}
+
+func (f Filter) Match(quote Quote) bool {
+	if f.AuthorId != "" && quote.AuthorId != f.AuthorId {
+		return false
+	}
+	if f.Author != "" && !f.matchAuthor(quote.Author) {
+		return false
+	}
+	if f.Phrase != "" && !strings.Contains(strings.ToLower(quote.Phrase), strings.ToLower(f.Phrase)) {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.matchTags(quote.Tags) {
+		return false
+	}
+	if f.Language != "" && !strings.EqualFold(quote.Language, f.Language) {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && quote.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && quote.CreatedAt.After(f.CreatedTo) {
+		return false
+	}
+	if !f.VisibleAt.IsZero() && !quote.Visible(f.VisibleAt) {
+		return false
+	}
+	length := utf8.RuneCountInString(quote.Phrase)
+	if f.MinLength > 0 && length < f.MinLength {
+		return false
+	}
+	if f.MaxLength > 0 && length > f.MaxLength {
+		return false
+	}
+	return true
+}
+
+func (f Filter) matchAuthor(author string) bool {
+	key := NormalizeAuthor(author)
+	switch {
+	case f.AuthorMatch == AuthorPrefix:
+		return strings.HasPrefix(key, NormalizeAuthor(f.Author))
+	case len(f.AuthorVariants) > 0:
+		return slices.Contains(f.AuthorVariants, key)
+	default:
+		return key == NormalizeAuthor(f.Author)
+	}
+}
+
+func (f Filter) matchTags(tags []string) bool {
+	for _, tag := range f.Tags {
+		found := slices.Contains(tags, tag)
+		if f.AnyTag && found {
+			return true
+		}
+		if !f.AnyTag && !found {
+			return false
+		}
+	}
+	return !f.AnyTag
+}
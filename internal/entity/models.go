@@ -1,11 +1,53 @@
 package entity
 
+import "time"
+
 type Quote struct {
-	Id     string `json:"id"`
-	Author string `json:"author"`
-	Phrase string `json:"quote"`
+	Id        string
+	AuthorId  string
+	Author    string
+	Phrase    string
+	Source    *Source
+	Tags      []string
+	Language  string
+	Rating    float64
+	Version   uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UpdatedBy string
+	DeletedAt time.Time
+	PublishAt time.Time
+	ExpireAt  time.Time
+}
+
+// Visible reports whether at falls inside the quote's publishing window.
+// A zero bound leaves that side of the window open.
+func (q Quote) Visible(at time.Time) bool {
+	return (q.PublishAt.IsZero() || !at.Before(q.PublishAt)) && (q.ExpireAt.IsZero() || at.Before(q.ExpireAt))
+}
+
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+type Source struct {
+	Kind  string
+	Title string
+	URL   string
+	Year  int
 }
 
-type QuoteResponse struct {
-	Quotes []Quote `json:"quotes"`
+const (
+	SourceBook      = "book"
+	SourceSpeech    = "speech"
+	SourceURL       = "url"
+	SourceFilm      = "film"
+	SourceInterview = "interview"
+	SourceOther     = "other"
+)
+
+type DailyPin struct {
+	Date    string
+	QuoteId string
 }
@@ -0,0 +1,6 @@
+package entity
+
+type Page struct {
+	Quotes []Quote
+	Next   string
+}
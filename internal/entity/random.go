@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+type Weight int
+
+const (
+	WeightUniform Weight = iota
+	WeightRating
+)
+
+type RandomOptions struct {
+	Filter  Filter
+	Exclude []string
+	Weight  Weight
+	Seed    uint64
+	Seeded  bool
+}
+
+func (w Weight) Of(quote Quote) float64 {
+	switch w {
+	case WeightRating:
+		return 1 + quote.Rating
+	default:
+		return 1
+	}
+}
+
+// Sampler draws one quote with weighted reservoir sampling (A-Res). Each key
+// gets its own random number derived from the seed, so the pick depends only
+// on the seed and the data, not on the order quotes are offered in.
+type Sampler struct {
+	opts      RandomOptions
+	seed      uint64
+	excluded  map[string]struct{}
+	best      Quote
+	bestScore float64
+	found     bool
+}
+
+func NewSampler(opts RandomOptions) *Sampler {
+	s := &Sampler{
+		opts:      opts,
+		seed:      opts.Seed,
+		excluded:  make(map[string]struct{}, len(opts.Exclude)),
+		bestScore: math.Inf(-1),
+	}
+	if !opts.Seeded {
+		s.seed = rand.Uint64()
+	}
+	for _, key := range opts.Exclude {
+		s.excluded[key] = struct{}{}
+	}
+	return s
+}
+
+func (s *Sampler) Consider(key string, value Quote) {
+	if _, ok := s.excluded[key]; ok || !s.opts.Filter.Match(value) {
+		return
+	}
+	weight := s.opts.Weight.Of(value)
+	if weight <= 0 {
+		return
+	}
+	score := math.Log(uniform(s.seed, key)) / weight
+	if !s.found || score > s.bestScore || (score == s.bestScore && key < s.best.Id) {
+		s.best, s.bestScore, s.found = value, score, true
+	}
+}
+
+func (s *Sampler) Result() (Quote, bool) {
+	return s.best, s.found
+}
+
+func uniform(seed uint64, key string) float64 {
+	hash := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], seed)
+	hash.Write(buf[:])
+	hash.Write([]byte(key))
+	x := hash.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return (float64(x>>11) + 0.5) / (1 << 53)
+}
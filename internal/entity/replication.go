@@ -0,0 +1,104 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	MutationSet   = "set"
+	MutationTrash = "trash"
+	MutationDel   = "del"
+)
+
+// Mutation is one change inside a commit. Set and trash carry the quote
+// exactly as it was stored, version included. Mutations of the side
+// tables carry the author, the revision, or the canonical author of an
+// alias and the quote of a pin in Target.
+type Mutation struct {
+	Kind     string
+	Key      string
+	Quote    *Quote
+	Target   string
+	Author   *Author
+	Revision *Revision
+}
+
+type Commit struct {
+	Seq uint64
+	Ops []Mutation
+}
+
+// Checkpoint is the whole store at Seq of log Log, side tables included,
+// as the mutations that rebuild it from empty.
+type Checkpoint struct {
+	Log string
+	Seq uint64
+	Ops []Mutation
+}
+
+// Offset is a position in a store's commit log. A store that keeps its
+// log in memory starts over from seq 0 when it restarts, so an offset also
+// names the log it was read from, and an offset into another log is out
+// of range. Seq 0 without a log is the start of any log.
+type Offset struct {
+	Log string
+	Seq uint64
+}
+
+func (o Offset) String() string {
+	if o.Log == "" {
+		return strconv.FormatUint(o.Seq, 10)
+	}
+	return o.Log + "-" + strconv.FormatUint(o.Seq, 10)
+}
+
+// StoreDump is everything a store holds: the quotes as a checkpoint and
+// the tables kept next to them.
+type StoreDump struct {
+	Quotes    Checkpoint
+	Authors   []Author
+	Aliases   map[string]string
+	Pins      map[string]string
+	Revisions map[string][]Revision
+}
+
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Change is a quote entering, changing in or leaving the live set. The
+// changes of one commit share its Seq. A delete carries the quote as it
+// went to the trash, or no quote when it was removed outright.
+type Change struct {
+	Seq   uint64
+	Type  string
+	Key   string
+	Quote *Quote
+}
+
+type ReplicaStatus struct {
+	Leader     string
+	Seq        uint64
+	LeaderSeq  uint64
+	LastSync   time.Time
+	Bootstraps int
+	Err        string
+}
+
+type ClusterMember struct {
+	ID   uint64
+	Addr string
+}
+
+type ClusterStatus struct {
+	ID      uint64
+	State   string
+	Term    uint64
+	Leader  uint64
+	Commit  uint64
+	Applied uint64
+	Members []ClusterMember
+}
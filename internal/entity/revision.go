@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+type Revision struct {
+	Number  int
+	Actor   string
+	At      time.Time
+	Changes []FieldChange
+	Quote   Quote
+}
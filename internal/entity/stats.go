@@ -0,0 +1,9 @@
+package entity
+
+type StorageStats struct {
+	Entries    int
+	Bytes      int64
+	MaxEntries int
+	MaxBytes   int64
+	Rejected   uint64
+}
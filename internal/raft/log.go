@@ -0,0 +1,83 @@
+package raft
+
+// raftLog holds the entries after the last snapshot. entries[0], when
+// present, always has Index snapshot.Index+1.
+type raftLog struct {
+	snapshot Snapshot
+	entries  []Entry
+}
+
+func (l *raftLog) lastIndex() uint64 {
+	if len(l.entries) == 0 {
+		return l.snapshot.Index
+	}
+	return l.entries[len(l.entries)-1].Index
+}
+
+func (l *raftLog) lastTerm() uint64 {
+	term, _ := l.term(l.lastIndex())
+	return term
+}
+
+func (l *raftLog) term(index uint64) (uint64, bool) {
+	switch {
+	case index == l.snapshot.Index:
+		return l.snapshot.Term, true
+	case index < l.snapshot.Index || index > l.lastIndex():
+		return 0, false
+	}
+	return l.entries[index-l.snapshot.Index-1].Term, true
+}
+
+func (l *raftLog) matches(index, term uint64) bool {
+	t, ok := l.term(index)
+	return ok && t == term
+}
+
+// slice returns a copy of the entries in [lo, hi), capped at limit entries
+// when limit is positive.
+func (l *raftLog) slice(lo, hi uint64, limit int) []Entry {
+	lo = max(lo, l.snapshot.Index+1)
+	hi = min(hi, l.lastIndex()+1)
+	if lo >= hi {
+		return nil
+	}
+	if limit > 0 && hi-lo > uint64(limit) {
+		hi = lo + uint64(limit)
+	}
+	offset := l.snapshot.Index + 1
+	return append([]Entry(nil), l.entries[lo-offset:hi-offset]...)
+}
+
+// append adds entries following the one at their first index - 1, dropping
+// any existing entry that conflicts with them. It returns the first index
+// that changed, or 0 when every entry was already present.
+func (l *raftLog) append(entries []Entry) uint64 {
+	for i, entry := range entries {
+		if entry.Index <= l.snapshot.Index || l.matches(entry.Index, entry.Term) {
+			continue
+		}
+		l.entries = l.entries[:entry.Index-l.snapshot.Index-1]
+		l.entries = append(l.entries, entries[i:]...)
+		return entry.Index
+	}
+	return 0
+}
+
+// compact drops the entries covered by snap, which must end at an entry of
+// this log.
+func (l *raftLog) compact(snap Snapshot) {
+	l.entries = append([]Entry(nil), l.entries[snap.Index-l.snapshot.Index:]...)
+	l.snapshot = snap
+}
+
+// restore replaces the log with snap. Entries following a matching entry
+// are kept, as the log matching property makes them consistent with it.
+func (l *raftLog) restore(snap Snapshot) {
+	if l.matches(snap.Index, snap.Term) && snap.Index >= l.snapshot.Index {
+		l.compact(snap)
+		return
+	}
+	l.entries = nil
+	l.snapshot = snap
+}
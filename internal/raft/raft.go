@@ -0,0 +1,708 @@
+// Package raft implements the Raft consensus algorithm as a deterministic
+// state machine. It does no I/O and reads no clock: the driver calls Tick
+// at a fixed interval, feeds incoming messages to Step and takes the
+// resulting work from Ready.
+package raft
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+)
+
+const (
+	defaultElectionTicks  = 10
+	defaultHeartbeatTicks = 1
+	maxAppendEntries      = 64
+)
+
+type Config struct {
+	ID uint64
+	// Peers is the initial membership, used while State holds no snapshot.
+	// A node joining a running cluster starts with none and waits for the
+	// leader to send it the log.
+	Peers []Peer
+	// State is what the node persisted before it restarted. The driver
+	// restores its state machine from State.Snapshot itself.
+	State          Persisted
+	ElectionTicks  int
+	HeartbeatTicks int
+	// Seed randomizes election timeouts and must differ between nodes.
+	Seed int64
+}
+
+type progress struct {
+	match  uint64
+	next   uint64
+	active bool
+}
+
+type readRequest struct {
+	from    uint64
+	context uint64
+	index   uint64
+	acks    map[uint64]bool
+}
+
+type Raft struct {
+	id    uint64
+	state StateType
+	term  uint64
+	vote  uint64
+	lead  uint64
+	peers map[uint64]string
+	log   raftLog
+
+	commit          uint64
+	applied         uint64
+	unstable        uint64
+	pendingSnapshot *Snapshot
+	pendingConfig   uint64
+
+	msgs       []Message
+	readStates []ReadState
+	reads      []*readRequest
+	votes      map[uint64]bool
+	progress   map[uint64]*progress
+
+	electionTicks     int
+	heartbeatTicks    int
+	randomizedTimeout int
+	electionElapsed   int
+	heartbeatElapsed  int
+	rand              *rand.Rand
+}
+
+func New(cfg Config) *Raft {
+	r := &Raft{
+		id:             cfg.ID,
+		peers:          make(map[uint64]string),
+		electionTicks:  cfg.ElectionTicks,
+		heartbeatTicks: cfg.HeartbeatTicks,
+		rand:           rand.New(rand.NewSource(cfg.Seed)),
+	}
+	if r.electionTicks <= 0 {
+		r.electionTicks = defaultElectionTicks
+	}
+	if r.heartbeatTicks <= 0 {
+		r.heartbeatTicks = defaultHeartbeatTicks
+	}
+	state := cfg.State
+	r.log.snapshot = state.Snapshot
+	for _, entry := range state.Entries {
+		if entry.Index > state.Snapshot.Index {
+			r.log.entries = append(r.log.entries, entry)
+		}
+	}
+	peers := cfg.Peers
+	if state.Snapshot.Index > 0 {
+		peers = state.Snapshot.Peers
+	}
+	r.setPeers(peers)
+	r.term, r.vote = state.HardState.Term, state.HardState.Vote
+	r.unstable = r.log.lastIndex() + 1
+	if r.log.lastIndex() == 0 && r.term == 0 && len(cfg.Peers) > 0 {
+		r.bootstrap(cfg.Peers)
+	}
+	r.commit = max(r.commit, min(max(state.HardState.Commit, state.Snapshot.Index), r.log.lastIndex()))
+	r.applied = state.Snapshot.Index
+	// Membership is rebuilt from the committed log right away, so a
+	// restarted node can campaign before its first Ready.
+	for _, entry := range r.log.slice(r.applied+1, r.commit+1, 0) {
+		var cc ConfigChange
+		if entry.Type == EntryConfig && json.Unmarshal(entry.Data, &cc) == nil {
+			r.applyConfig(cc)
+		}
+	}
+	r.becomeFollower(r.term, 0)
+	return r
+}
+
+// bootstrap writes the initial membership into the log as committed config
+// changes, identical on every founding node. A node joining later learns
+// the membership by replicating them.
+func (r *Raft) bootstrap(peers []Peer) {
+	entries := make([]Entry, 0, len(peers))
+	for i, peer := range peers {
+		data, _ := json.Marshal(ConfigChange{Peer: peer})
+		entries = append(entries, Entry{Index: uint64(i) + 1, Term: 1, Type: EntryConfig, Data: data})
+	}
+	r.log.append(entries)
+	r.term = 1
+	r.commit = r.log.lastIndex()
+	r.unstable = 1
+}
+
+// Tick advances the logical clock by one interval.
+func (r *Raft) Tick() {
+	r.electionElapsed++
+	if r.state != Leader {
+		if r.electionElapsed >= r.randomizedTimeout && r.promotable() {
+			r.campaign()
+		}
+		return
+	}
+	r.heartbeatElapsed++
+	if r.heartbeatElapsed >= r.heartbeatTicks {
+		r.heartbeatElapsed = 0
+		r.broadcastHeartbeat()
+	}
+	if r.electionElapsed >= r.electionTicks {
+		r.electionElapsed = 0
+		// A leader cut off from the majority steps down instead of
+		// accepting writes it can never commit.
+		if !r.quorumActive() {
+			r.becomeFollower(r.term, 0)
+		}
+	}
+}
+
+// Propose appends data to the log through the leader. A nil error does not
+// mean the entry will commit; the driver learns that from Ready.Committed.
+func (r *Raft) Propose(data []byte) error {
+	return r.handleProp(Message{Type: MsgProp, From: r.id, Entries: []Entry{{Type: EntryNormal, Data: data}}})
+}
+
+// ProposeConfigChange adds or removes one member. Only one change may be
+// in flight at a time; later ones are dropped until it commits.
+func (r *Raft) ProposeConfigChange(cc ConfigChange) error {
+	data, err := json.Marshal(cc)
+	if err != nil {
+		return err
+	}
+	return r.handleProp(Message{Type: MsgProp, From: r.id, Entries: []Entry{{Type: EntryConfig, Data: data}}})
+}
+
+// ReadIndex asks for the commit index a linearizable read has to wait for.
+// The answer arrives in Ready.ReadStates under context, which must be
+// unique across the cluster.
+func (r *Raft) ReadIndex(context uint64) error {
+	return r.handleReadIndex(Message{Type: MsgReadIndex, From: r.id, Context: context})
+}
+
+// Compact replaces the applied part of the log with a snapshot holding
+// data, the state machine as of the last entry handed out by Ready.
+func (r *Raft) Compact(data []byte) error {
+	if r.applied <= r.log.snapshot.Index {
+		return ErrNotCompactable
+	}
+	term, _ := r.log.term(r.applied)
+	snap := Snapshot{Index: r.applied, Term: term, Peers: r.peerList(), Data: data}
+	r.log.compact(snap)
+	r.pendingSnapshot = &snap
+	return nil
+}
+
+func (r *Raft) Status() Status {
+	return Status{
+		ID:      r.id,
+		State:   r.state,
+		Term:    r.term,
+		Leader:  r.lead,
+		Commit:  r.commit,
+		Applied: r.applied,
+		Index:   r.log.lastIndex(),
+		Peers:   r.peerList(),
+	}
+}
+
+// Ready hands out the work accumulated since the previous call. Committed
+// config changes take effect here.
+func (r *Raft) Ready() Ready {
+	var rd Ready
+	if r.pendingSnapshot != nil {
+		rd.Snapshot = r.pendingSnapshot
+		r.pendingSnapshot = nil
+		r.applied = max(r.applied, rd.Snapshot.Index)
+	}
+	if r.commit > r.applied {
+		rd.Committed = r.log.slice(r.applied+1, r.commit+1, 0)
+		r.applied = r.commit
+		for _, entry := range rd.Committed {
+			var cc ConfigChange
+			if entry.Type == EntryConfig && json.Unmarshal(entry.Data, &cc) == nil {
+				r.applyConfig(cc)
+			}
+		}
+	}
+	rd.Entries = r.log.slice(r.unstable, r.log.lastIndex()+1, 0)
+	r.unstable = r.log.lastIndex() + 1
+	rd.HardState = HardState{Term: r.term, Vote: r.vote, Commit: r.commit}
+	rd.Messages, rd.ReadStates = r.msgs, r.readStates
+	r.msgs, r.readStates = nil, nil
+	return rd
+}
+
+func (r *Raft) Step(m Message) {
+	switch {
+	case m.Type == MsgProp || m.Type == MsgReadIndex:
+		// Forwarded requests carry no term.
+	case m.Term > r.term:
+		if m.Type == MsgVote && r.inLease() {
+			return
+		}
+		var lead uint64
+		if m.Type == MsgApp || m.Type == MsgHeartbeat || m.Type == MsgSnap {
+			lead = m.From
+		}
+		r.becomeFollower(m.Term, lead)
+	case m.Term < r.term:
+		// Tell a stale leader or candidate about the newer term.
+		switch m.Type {
+		case MsgApp, MsgHeartbeat, MsgSnap:
+			r.send(Message{To: m.From, Type: MsgAppResp})
+		case MsgVote:
+			r.send(Message{To: m.From, Type: MsgVoteResp, Reject: true})
+		}
+		return
+	}
+
+	switch m.Type {
+	case MsgProp:
+		r.handleProp(m)
+		return
+	case MsgReadIndex:
+		r.handleReadIndex(m)
+		return
+	case MsgVote:
+		r.handleVote(m)
+		return
+	}
+	if r.state == Leader {
+		r.stepLeader(m)
+		return
+	}
+	r.stepFollower(m)
+}
+
+func (r *Raft) stepLeader(m Message) {
+	pr := r.progress[m.From]
+	if pr == nil {
+		return
+	}
+	switch m.Type {
+	case MsgAppResp:
+		pr.active = true
+		if m.Reject {
+			pr.next = max(pr.match+1, min(m.Index, m.RejectHint+1))
+			r.sendAppend(m.From)
+			return
+		}
+		if m.Index > pr.match {
+			pr.match = m.Index
+			if r.maybeCommit() {
+				r.broadcastAppend()
+			}
+		}
+		pr.next = max(pr.next, pr.match+1)
+		if pr.next <= r.log.lastIndex() {
+			r.sendAppend(m.From)
+		}
+	case MsgHeartbeatResp:
+		pr.active = true
+		if pr.match < r.log.lastIndex() {
+			pr.next = pr.match + 1
+			r.sendAppend(m.From)
+		}
+		if m.Context != 0 {
+			r.ackReads(m.From, m.Context)
+		}
+	}
+}
+
+func (r *Raft) stepFollower(m Message) {
+	switch m.Type {
+	case MsgApp, MsgHeartbeat, MsgSnap:
+		if r.state == Candidate {
+			r.becomeFollower(r.term, m.From)
+		}
+		r.lead = m.From
+		r.electionElapsed = 0
+		switch m.Type {
+		case MsgApp:
+			r.handleAppend(m)
+		case MsgHeartbeat:
+			r.commit = max(r.commit, min(m.Commit, r.log.lastIndex()))
+			r.send(Message{To: m.From, Type: MsgHeartbeatResp, Context: m.Context})
+		case MsgSnap:
+			r.handleSnapshot(m)
+		}
+	case MsgVoteResp:
+		if r.state == Candidate {
+			r.poll(m.From, !m.Reject)
+		}
+	case MsgReadIndexResp:
+		r.readStates = append(r.readStates, ReadState{Context: m.Context, Index: m.Index})
+	}
+}
+
+func (r *Raft) handleAppend(m Message) {
+	if m.Index < r.commit {
+		r.send(Message{To: m.From, Type: MsgAppResp, Index: r.commit})
+		return
+	}
+	if !r.log.matches(m.Index, m.LogTerm) {
+		r.send(Message{To: m.From, Type: MsgAppResp, Index: m.Index, Reject: true, RejectHint: r.log.lastIndex()})
+		return
+	}
+	if first := r.log.append(m.Entries); first != 0 {
+		r.unstable = min(r.unstable, first)
+	}
+	last := m.Index + uint64(len(m.Entries))
+	r.commit = max(r.commit, min(m.Commit, last))
+	r.send(Message{To: m.From, Type: MsgAppResp, Index: last})
+}
+
+func (r *Raft) handleSnapshot(m Message) {
+	snap := *m.Snapshot
+	if snap.Index <= r.commit {
+		r.send(Message{To: m.From, Type: MsgAppResp, Index: r.commit})
+		return
+	}
+	r.log.restore(snap)
+	r.commit = snap.Index
+	r.unstable = min(max(r.unstable, snap.Index+1), r.log.lastIndex()+1)
+	r.setPeers(snap.Peers)
+	r.pendingSnapshot = &snap
+	r.send(Message{To: m.From, Type: MsgAppResp, Index: snap.Index})
+}
+
+func (r *Raft) handleVote(m Message) {
+	canVote := r.vote == m.From || (r.vote == 0 && r.lead == 0)
+	upToDate := m.LogTerm > r.log.lastTerm() || (m.LogTerm == r.log.lastTerm() && m.Index >= r.log.lastIndex())
+	if canVote && upToDate {
+		r.vote = m.From
+		r.electionElapsed = 0
+		r.send(Message{To: m.From, Type: MsgVoteResp})
+		return
+	}
+	r.send(Message{To: m.From, Type: MsgVoteResp, Reject: true})
+}
+
+func (r *Raft) handleProp(m Message) error {
+	switch {
+	case r.state == Leader:
+		return r.appendEntries(m.Entries)
+	case r.lead != 0:
+		m.To = r.lead
+		r.msgs = append(r.msgs, m)
+		return nil
+	}
+	return ErrProposalDropped
+}
+
+func (r *Raft) handleReadIndex(m Message) error {
+	switch {
+	case r.state == Leader:
+		read := &readRequest{from: m.From, context: m.Context, acks: make(map[uint64]bool)}
+		r.reads = append(r.reads, read)
+		// Until an entry of this term commits the leader may not know
+		// the latest commit index; the read waits for it.
+		if r.log.matches(r.commit, r.term) {
+			read.index = r.commit
+			r.confirmReads()
+		}
+		return nil
+	case r.lead != 0:
+		m.To = r.lead
+		r.msgs = append(r.msgs, m)
+		return nil
+	}
+	return ErrProposalDropped
+}
+
+func (r *Raft) appendEntries(entries []Entry) error {
+	for _, entry := range entries {
+		if entry.Type == EntryConfig && r.pendingConfig > r.commit {
+			return ErrProposalDropped
+		}
+	}
+	last := r.log.lastIndex()
+	for i := range entries {
+		entries[i].Index = last + uint64(i) + 1
+		entries[i].Term = r.term
+		if entries[i].Type == EntryConfig {
+			r.pendingConfig = entries[i].Index
+		}
+	}
+	r.log.append(entries)
+	r.maybeCommit()
+	r.broadcastAppend()
+	return nil
+}
+
+func (r *Raft) maybeCommit() bool {
+	matches := make([]uint64, 0, len(r.peers))
+	for id := range r.peers {
+		switch pr := r.progress[id]; {
+		case id == r.id:
+			matches = append(matches, r.log.lastIndex())
+		case pr != nil:
+			matches = append(matches, pr.match)
+		default:
+			matches = append(matches, 0)
+		}
+	}
+	if len(matches) == 0 {
+		return false
+	}
+	slices.Sort(matches)
+	index := matches[len(matches)-r.quorum()]
+	if index <= r.commit || !r.log.matches(index, r.term) {
+		return false
+	}
+	r.commit = index
+	pending := false
+	for _, read := range r.reads {
+		if read.index == 0 {
+			read.index = r.commit
+			pending = true
+		}
+	}
+	if pending {
+		r.confirmReads()
+	}
+	return true
+}
+
+func (r *Raft) applyConfig(cc ConfigChange) {
+	if cc.Remove {
+		delete(r.peers, cc.Peer.ID)
+		delete(r.progress, cc.Peer.ID)
+	} else {
+		r.peers[cc.Peer.ID] = cc.Peer.Addr
+	}
+	if r.state != Leader {
+		return
+	}
+	if !r.promotable() {
+		r.becomeFollower(r.term, 0)
+		return
+	}
+	if _, ok := r.progress[cc.Peer.ID]; !ok && !cc.Remove && cc.Peer.ID != r.id {
+		r.progress[cc.Peer.ID] = &progress{next: r.log.lastIndex() + 1, active: true}
+		r.sendAppend(cc.Peer.ID)
+	}
+	if r.maybeCommit() {
+		r.broadcastAppend()
+	}
+}
+
+func (r *Raft) sendAppend(to uint64) {
+	pr := r.progress[to]
+	prev := pr.next - 1
+	term, ok := r.log.term(prev)
+	if !ok {
+		snap := r.log.snapshot
+		r.send(Message{To: to, Type: MsgSnap, Snapshot: &snap})
+		pr.next = snap.Index + 1
+		return
+	}
+	entries := r.log.slice(pr.next, r.log.lastIndex()+1, maxAppendEntries)
+	r.send(Message{To: to, Type: MsgApp, Index: prev, LogTerm: term, Entries: entries, Commit: r.commit})
+	if n := len(entries); n > 0 {
+		pr.next = entries[n-1].Index + 1
+	}
+}
+
+func (r *Raft) broadcastAppend() {
+	for _, id := range r.peerIDs() {
+		if id != r.id {
+			r.sendAppend(id)
+		}
+	}
+}
+
+// broadcastHeartbeat carries the newest confirmable read, so an answer to
+// it confirms that read and every one queued before it.
+func (r *Raft) broadcastHeartbeat() {
+	var context uint64
+	for _, read := range r.reads {
+		if read.index != 0 {
+			context = read.context
+		}
+	}
+	for _, id := range r.peerIDs() {
+		if id == r.id {
+			continue
+		}
+		pr := r.progress[id]
+		r.send(Message{To: id, Type: MsgHeartbeat, Commit: min(pr.match, r.commit), Context: context})
+	}
+}
+
+func (r *Raft) confirmReads() {
+	if r.quorum() <= 1 {
+		r.releaseReads()
+		return
+	}
+	r.broadcastHeartbeat()
+}
+
+func (r *Raft) ackReads(from, context uint64) {
+	for _, read := range r.reads {
+		if read.index == 0 {
+			break
+		}
+		read.acks[from] = true
+		if read.context == context {
+			break
+		}
+	}
+	r.releaseReads()
+}
+
+func (r *Raft) releaseReads() {
+	for len(r.reads) > 0 {
+		read := r.reads[0]
+		if read.index == 0 || len(read.acks)+1 < r.quorum() {
+			return
+		}
+		r.reads = r.reads[1:]
+		if read.from == r.id {
+			r.readStates = append(r.readStates, ReadState{Context: read.context, Index: read.index})
+			continue
+		}
+		r.send(Message{To: read.from, Type: MsgReadIndexResp, Context: read.context, Index: read.index})
+	}
+}
+
+func (r *Raft) campaign() {
+	r.becomeCandidate()
+	r.poll(r.id, true)
+	if r.state != Candidate {
+		return
+	}
+	for _, id := range r.peerIDs() {
+		if id != r.id {
+			r.send(Message{To: id, Type: MsgVote, Index: r.log.lastIndex(), LogTerm: r.log.lastTerm()})
+		}
+	}
+}
+
+func (r *Raft) poll(from uint64, granted bool) {
+	if _, ok := r.peers[from]; !ok {
+		return
+	}
+	r.votes[from] = granted
+	var yes, no int
+	for _, vote := range r.votes {
+		if vote {
+			yes++
+		} else {
+			no++
+		}
+	}
+	switch {
+	case yes >= r.quorum():
+		r.becomeLeader()
+	case no >= r.quorum():
+		r.becomeFollower(r.term, 0)
+	}
+}
+
+func (r *Raft) becomeFollower(term, lead uint64) {
+	if term != r.term {
+		r.term = term
+		r.vote = 0
+	}
+	r.state = Follower
+	r.lead = lead
+	r.reset()
+}
+
+func (r *Raft) becomeCandidate() {
+	r.term++
+	r.vote = r.id
+	r.state = Candidate
+	r.lead = 0
+	r.reset()
+	r.votes = make(map[uint64]bool)
+}
+
+func (r *Raft) becomeLeader() {
+	r.state = Leader
+	r.lead = r.id
+	r.reset()
+	r.progress = make(map[uint64]*progress)
+	for _, id := range r.peerIDs() {
+		if id != r.id {
+			r.progress[id] = &progress{next: r.log.lastIndex() + 1, active: true}
+		}
+	}
+	// Config entries from earlier terms may still be uncommitted.
+	r.pendingConfig = r.log.lastIndex()
+	r.appendEntries([]Entry{{Type: EntryNormal}})
+}
+
+func (r *Raft) reset() {
+	r.electionElapsed = 0
+	r.heartbeatElapsed = 0
+	r.randomizedTimeout = r.electionTicks + r.rand.Intn(r.electionTicks)
+	r.votes = nil
+	r.progress = nil
+	r.reads = nil
+}
+
+func (r *Raft) send(m Message) {
+	m.From = r.id
+	if m.Type != MsgProp && m.Type != MsgReadIndex {
+		m.Term = r.term
+	}
+	r.msgs = append(r.msgs, m)
+}
+
+func (r *Raft) quorumActive() bool {
+	active := 0
+	for _, id := range r.peerIDs() {
+		if id == r.id {
+			active++
+			continue
+		}
+		if pr := r.progress[id]; pr != nil && pr.active {
+			active++
+			pr.active = false
+		}
+	}
+	return active >= r.quorum()
+}
+
+// inLease reports whether a leader was heard from within the election
+// timeout. Votes for a higher term are ignored then, so a node rejoining
+// after a partition cannot depose a healthy leader.
+func (r *Raft) inLease() bool {
+	return r.lead != 0 && r.electionElapsed < r.electionTicks
+}
+
+func (r *Raft) promotable() bool {
+	_, ok := r.peers[r.id]
+	return ok
+}
+
+func (r *Raft) quorum() int {
+	return len(r.peers)/2 + 1
+}
+
+func (r *Raft) setPeers(peers []Peer) {
+	r.peers = make(map[uint64]string, len(peers))
+	for _, peer := range peers {
+		r.peers[peer.ID] = peer.Addr
+	}
+}
+
+func (r *Raft) peerIDs() []uint64 {
+	ids := make([]uint64, 0, len(r.peers))
+	for id := range r.peers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (r *Raft) peerList() []Peer {
+	peers := make([]Peer, 0, len(r.peers))
+	for _, id := range r.peerIDs() {
+		peers = append(peers, Peer{ID: id, Addr: r.peers[id]})
+	}
+	return peers
+}
@@ -0,0 +1,340 @@
+package raft_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/raft"
+	"github.com/paxaf/BrandScoutTest/internal/raft/raftsim"
+)
+
+func electLeader(t *testing.T, net *raftsim.Network) uint64 {
+	t.Helper()
+	if !net.RunUntil(500, func() bool { return net.Leader() != 0 }) {
+		t.Fatal("No leader was elected")
+	}
+	return net.Leader()
+}
+
+func proposeAll(t *testing.T, net *raftsim.Network, prefix string, count int) {
+	t.Helper()
+	for i := range count {
+		lead := electLeader(t, net)
+		if err := net.Propose(lead, fmt.Sprint(prefix, i)); err != nil {
+			t.Fatalf("Leader %d dropped a proposal: %v", lead, err)
+		}
+		net.Run(3)
+	}
+}
+
+func TestElection(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{1, 3, 5} {
+		ids := make([]uint64, size)
+		for i := range ids {
+			ids[i] = uint64(i + 1)
+		}
+		net := raftsim.New(t, 1, ids...)
+		lead := electLeader(t, net)
+		net.Run(50)
+		if got := net.Leader(); got != lead {
+			t.Errorf("%d nodes: leadership moved from %d to %d on a healthy network", size, lead, got)
+		}
+		for _, id := range ids {
+			if status := net.Node(id).Raft.Status(); status.Leader != lead {
+				t.Errorf("%d nodes: node %d follows %d, not %d", size, id, status.Leader, lead)
+			}
+		}
+	}
+}
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+
+	net := raftsim.New(t, 2, 1, 2, 3)
+	proposeAll(t, net, "entry ", 50)
+	if !net.RunUntil(200, func() bool { return len(net.Node(3).Applied) == 50 && net.Converged() }) {
+		t.Fatalf("Nodes did not converge: %v", net.Node(3).Applied)
+	}
+	if got := net.Node(1).Applied; got[0] != "entry 0" || got[49] != "entry 49" {
+		t.Errorf("Entries applied out of order: %v", got)
+	}
+
+	follower := net.IDs()[0]
+	if follower == net.Leader() {
+		follower = net.IDs()[1]
+	}
+	if err := net.Propose(follower, "forwarded"); err != nil {
+		t.Fatalf("Follower dropped a proposal it could forward: %v", err)
+	}
+	if !net.RunUntil(100, func() bool { return len(net.Node(follower).Applied) == 51 && net.Converged() }) {
+		t.Error("Forwarded proposal was not committed")
+	}
+}
+
+func TestLeaderPartition(t *testing.T) {
+	t.Parallel()
+
+	net := raftsim.New(t, 3, 1, 2, 3, 4, 5)
+	proposeAll(t, net, "before ", 3)
+	net.RunUntil(100, net.Converged)
+
+	old := net.Leader()
+	minority := []uint64{old}
+	var majority []uint64
+	for _, id := range net.IDs() {
+		switch {
+		case id == old:
+		case len(minority) < 2:
+			minority = append(minority, id)
+		default:
+			majority = append(majority, id)
+		}
+	}
+	net.Partition(minority, majority)
+	if err := net.Propose(old, "lost"); err != nil {
+		t.Fatalf("Old leader refused a proposal before noticing the partition: %v", err)
+	}
+
+	var lead uint64
+	if !net.RunUntil(500, func() bool {
+		lead = net.Leader()
+		return slices.Contains(majority, lead)
+	}) {
+		t.Fatal("Majority did not elect a new leader")
+	}
+	if err := net.Propose(lead, "after"); err != nil {
+		t.Fatalf("New leader dropped a proposal: %v", err)
+	}
+	net.Run(100)
+	if status := net.Node(old).Raft.Status(); status.State == raft.Leader {
+		t.Error("Leader without a quorum did not step down")
+	}
+	if slices.Contains(net.Node(old).Applied, "lost") {
+		t.Error("Minority committed an entry")
+	}
+
+	net.Heal()
+	if !net.RunUntil(500, func() bool { return len(net.Node(old).Applied) == 4 && net.Converged() }) {
+		t.Fatalf("Nodes did not converge after healing: %v", net.Node(old).Applied)
+	}
+	if got := net.Node(old).Applied; got[3] != "after" {
+		t.Errorf("Uncommitted entry survived the partition: %v", got)
+	}
+	net.Check()
+}
+
+// TestRandomFaults replays many schedules of partitions, crashes, lost and
+// delayed messages, and checks that no two nodes ever apply different
+// entries and that all of them agree once the faults stop.
+func TestRandomFaults(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(1); seed <= 20; seed++ {
+		ids := []uint64{1, 2, 3, 4, 5}
+		net := raftsim.New(t, seed, ids...)
+		net.DropRate = 0.1
+		net.MaxDelay = 3
+		net.CompactEvery = 10
+		faults := rand.New(rand.NewSource(seed))
+		proposed := 0
+		for round := range 40 {
+			switch faults.Intn(4) {
+			case 0:
+				faults.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+				cut := 1 + faults.Intn(len(ids)-1)
+				net.Partition(slices.Clone(ids[:cut]), slices.Clone(ids[cut:]))
+			case 1:
+				net.Heal()
+			case 2:
+				id := ids[faults.Intn(len(ids))]
+				if node := net.Node(id); node.Down {
+					net.Restart(id)
+				} else {
+					net.Crash(id)
+				}
+			}
+			for _, id := range net.IDs() {
+				if !net.Node(id).Down && faults.Intn(3) == 0 {
+					net.Propose(id, fmt.Sprintf("%d/%d", round, id))
+					proposed++
+				}
+			}
+			net.Run(10 + faults.Intn(20))
+			net.Check()
+		}
+
+		net.Heal()
+		net.DropRate = 0
+		for _, id := range net.IDs() {
+			if net.Node(id).Down {
+				net.Restart(id)
+			}
+		}
+		// Like a client, retry until the write lands: leadership may still
+		// move while nodes that were cut off rejoin with higher terms.
+		committed := func() bool {
+			return slices.Contains(net.Node(1).Applied, "final") && net.Converged()
+		}
+		for attempt := 0; !committed(); attempt++ {
+			if attempt == 20 {
+				t.Fatalf("seed %d: nodes did not converge after faults stopped", seed)
+			}
+			net.Propose(electLeader(t, net), "final")
+			proposed++
+			net.RunUntil(100, committed)
+		}
+		net.Check()
+		if applied := len(net.Node(1).Applied); applied > proposed {
+			t.Fatalf("seed %d: %d entries applied, only %d proposed", seed, applied, proposed)
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+
+	trace := func() []string {
+		net := raftsim.New(t, 42, 1, 2, 3)
+		net.DropRate = 0.2
+		net.MaxDelay = 4
+		var res []string
+		for i := range 30 {
+			if lead := net.Leader(); lead != 0 {
+				net.Propose(lead, fmt.Sprint(i))
+			}
+			if i == 10 {
+				net.Partition([]uint64{1}, []uint64{2, 3})
+			}
+			if i == 20 {
+				net.Heal()
+			}
+			net.Run(7)
+			res = append(res, fmt.Sprint(net.Now(), net.Leader(), net.Node(1).Applied))
+		}
+		return res
+	}
+	if first, second := trace(), trace(); !slices.Equal(first, second) {
+		t.Errorf("Same seed produced different runs:\n%v\n%v", first, second)
+	}
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	t.Parallel()
+
+	net := raftsim.New(t, 5, 1, 2, 3)
+	net.CompactEvery = 5
+	lead := electLeader(t, net)
+	lagging := net.IDs()[0]
+	if lagging == lead {
+		lagging = net.IDs()[1]
+	}
+	net.Crash(lagging)
+	proposeAll(t, net, "entry ", 30)
+	net.Run(20)
+	if status := net.Node(lead).Raft.Status(); status.Index-status.Commit > 5 {
+		t.Fatalf("Leader did not compact its log: %+v", status)
+	}
+
+	restarted := net.Restart(lagging)
+	if !net.RunUntil(500, func() bool { return len(restarted.Applied) == 30 && net.Converged() }) {
+		t.Fatalf("Node behind the compacted log did not catch up: %v", restarted.Applied)
+	}
+
+	// The snapshot was persisted, so a second restart starts from it.
+	net.Crash(lagging)
+	restarted = net.Restart(lagging)
+	if len(restarted.Applied) == 0 {
+		t.Error("Restarted node did not load its snapshot")
+	}
+	if !net.RunUntil(200, func() bool { return len(restarted.Applied) == 30 }) {
+		t.Errorf("Restarted node did not replay its log: %v", restarted.Applied)
+	}
+	net.Check()
+}
+
+func TestMembershipChange(t *testing.T) {
+	t.Parallel()
+
+	net := raftsim.New(t, 6, 1, 2, 3)
+	proposeAll(t, net, "entry ", 5)
+	joined := net.Join(4)
+	net.Run(50)
+	if len(joined.Applied) != 0 || joined.Raft.Status().State != raft.Follower {
+		t.Fatal("Node outside the membership took part")
+	}
+
+	lead := electLeader(t, net)
+	if err := net.ChangeConfig(lead, raft.ConfigChange{Peer: raft.Peer{ID: 4, Addr: "node4"}}); err != nil {
+		t.Fatalf("Failed to propose adding a node: %v", err)
+	}
+	if err := net.ChangeConfig(lead, raft.ConfigChange{Peer: raft.Peer{ID: 5, Addr: "node5"}}); err == nil {
+		t.Error("Second config change was accepted while the first was pending")
+	}
+	if !net.RunUntil(200, func() bool { return len(joined.Applied) == 5 }) {
+		t.Fatalf("Added node did not catch up: %v", joined.Applied)
+	}
+	if peers := joined.Raft.Status().Peers; len(peers) != 4 {
+		t.Errorf("Added node sees membership %v", peers)
+	}
+
+	if err := net.ChangeConfig(lead, raft.ConfigChange{Peer: raft.Peer{ID: lead}, Remove: true}); err != nil {
+		t.Fatalf("Failed to propose removing the leader: %v", err)
+	}
+	var next uint64
+	if !net.RunUntil(500, func() bool {
+		next = net.Leader()
+		return next != 0 && next != lead
+	}) {
+		t.Fatal("Cluster did not elect a leader after removing the old one")
+	}
+	if peers := net.Node(next).Raft.Status().Peers; len(peers) != 3 || slices.ContainsFunc(peers, func(p raft.Peer) bool { return p.ID == lead }) {
+		t.Errorf("Unexpected membership after removal: %v", peers)
+	}
+	net.Crash(lead)
+	if err := net.Propose(next, "after removal"); err != nil {
+		t.Fatalf("New leader dropped a proposal: %v", err)
+	}
+	if !net.RunUntil(200, func() bool { return len(joined.Applied) == 6 }) {
+		t.Error("Three remaining nodes could not commit")
+	}
+	net.Check()
+}
+
+func TestReadIndex(t *testing.T) {
+	t.Parallel()
+
+	net := raftsim.New(t, 7, 1, 2, 3)
+	proposeAll(t, net, "entry ", 3)
+	lead := electLeader(t, net)
+	commit := net.Node(lead).Raft.Status().Commit
+
+	for i, id := range net.IDs() {
+		context := uint64(100 + i)
+		if err := net.ReadIndex(id, context); err != nil {
+			t.Fatalf("Node %d refused a read: %v", id, err)
+		}
+		if !net.RunUntil(50, func() bool { _, ok := net.Node(id).Reads[context]; return ok }) {
+			t.Fatalf("Read on node %d was not confirmed", id)
+		}
+		if index := net.Node(id).Reads[context]; index < commit {
+			t.Errorf("Node %d may read at %d, before the commit at %d", id, index, commit)
+		}
+	}
+
+	var rest []uint64
+	for _, id := range net.IDs() {
+		if id != lead {
+			rest = append(rest, id)
+		}
+	}
+	net.Partition([]uint64{lead}, rest)
+	net.ReadIndex(lead, 200)
+	net.Run(100)
+	if _, ok := net.Node(lead).Reads[200]; ok {
+		t.Error("Leader cut off from the quorum confirmed a read")
+	}
+}
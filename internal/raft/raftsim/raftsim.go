@@ -0,0 +1,316 @@
+// Package raftsim runs raft nodes over a simulated network. Delivery order,
+// delays, drops and election timeouts all come from one seeded source, so
+// a failing schedule replays exactly from its seed.
+package raftsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/raft"
+)
+
+type delivery struct {
+	at  int
+	msg raft.Message
+}
+
+type Network struct {
+	t     testing.TB
+	seed  int64
+	rand  *rand.Rand
+	nodes map[uint64]*Node
+	queue []delivery
+	now   int
+	cut   map[[2]uint64]bool
+	// leaders records who led each term, to check there was only one.
+	leaders map[uint64]uint64
+
+	// DropRate is the probability of losing a message.
+	DropRate float64
+	// MaxDelay is the most ticks a message spends in flight.
+	MaxDelay int
+	// CompactEvery makes nodes snapshot once that many entries were
+	// applied since the last snapshot. Zero never compacts.
+	CompactEvery int
+}
+
+// Node is a raft node whose state machine is the list of applied entries.
+type Node struct {
+	ID      uint64
+	Raft    *raft.Raft
+	Storage *raft.Storage
+	Applied []string
+	Reads   map[uint64]uint64
+	Down    bool
+
+	snapshotIndex uint64
+	applied       uint64
+}
+
+func New(t testing.TB, seed int64, ids ...uint64) *Network {
+	n := &Network{
+		t:       t,
+		seed:    seed,
+		rand:    rand.New(rand.NewSource(seed)),
+		nodes:   make(map[uint64]*Node),
+		cut:     make(map[[2]uint64]bool),
+		leaders: make(map[uint64]uint64),
+	}
+	peers := make([]raft.Peer, 0, len(ids))
+	for _, id := range ids {
+		peers = append(peers, raft.Peer{ID: id, Addr: fmt.Sprint("node", id)})
+	}
+	for _, id := range ids {
+		n.start(id, peers, raft.NewMemoryStorage())
+	}
+	return n
+}
+
+// Join starts a node outside the membership; it takes part once a config
+// change adding it commits.
+func (n *Network) Join(id uint64) *Node {
+	return n.start(id, nil, raft.NewMemoryStorage())
+}
+
+func (n *Network) Node(id uint64) *Node {
+	return n.nodes[id]
+}
+
+func (n *Network) IDs() []uint64 {
+	ids := make([]uint64, 0, len(n.nodes))
+	for id := range n.nodes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (n *Network) Now() int {
+	return n.now
+}
+
+func (n *Network) start(id uint64, peers []raft.Peer, storage *raft.Storage) *Node {
+	state := storage.State()
+	node := &Node{
+		ID:            id,
+		Storage:       storage,
+		Reads:         make(map[uint64]uint64),
+		snapshotIndex: state.Snapshot.Index,
+		applied:       state.Snapshot.Index,
+	}
+	if len(state.Snapshot.Data) > 0 {
+		if err := json.Unmarshal(state.Snapshot.Data, &node.Applied); err != nil {
+			n.t.Fatalf("seed %d: node %d has a corrupt snapshot: %v", n.seed, id, err)
+		}
+	}
+	node.Raft = raft.New(raft.Config{
+		ID:    id,
+		Peers: peers,
+		State: state,
+		Seed:  n.seed*1000 + int64(id) + int64(n.now),
+	})
+	n.nodes[id] = node
+	return node
+}
+
+// Tick advances every running node by one tick and delivers the messages
+// due by then.
+func (n *Network) Tick() {
+	n.now++
+	for _, id := range n.IDs() {
+		if node := n.nodes[id]; !node.Down {
+			node.Raft.Tick()
+			n.drain(node)
+		}
+	}
+	for {
+		i := slices.IndexFunc(n.queue, func(d delivery) bool { return d.at <= n.now })
+		if i < 0 {
+			return
+		}
+		d := n.queue[i]
+		n.queue = slices.Delete(n.queue, i, i+1)
+		if node := n.nodes[d.msg.To]; node != nil && !node.Down && !n.isCut(d.msg.From, d.msg.To) {
+			node.Raft.Step(d.msg)
+			n.drain(node)
+		}
+	}
+}
+
+func (n *Network) Run(ticks int) {
+	for range ticks {
+		n.Tick()
+	}
+}
+
+// RunUntil ticks until cond holds and reports whether it did within ticks.
+func (n *Network) RunUntil(ticks int, cond func() bool) bool {
+	for range ticks {
+		if cond() {
+			return true
+		}
+		n.Tick()
+	}
+	return cond()
+}
+
+// Partition splits the network so that only nodes in the same group can
+// talk. Nodes left out of every group are isolated.
+func (n *Network) Partition(groups ...[]uint64) {
+	n.Heal()
+	group := make(map[uint64]int)
+	for i, ids := range groups {
+		for _, id := range ids {
+			group[id] = i + 1
+		}
+	}
+	for _, a := range n.IDs() {
+		for _, b := range n.IDs() {
+			if a != b && (group[a] == 0 || group[a] != group[b]) {
+				n.cut[[2]uint64{a, b}] = true
+			}
+		}
+	}
+}
+
+func (n *Network) Heal() {
+	clear(n.cut)
+}
+
+// Crash stops a node. Its storage is kept for Restart; everything else,
+// including the state machine, is lost.
+func (n *Network) Crash(id uint64) {
+	n.nodes[id].Down = true
+}
+
+func (n *Network) Restart(id uint64) *Node {
+	return n.start(id, nil, n.nodes[id].Storage)
+}
+
+func (n *Network) Propose(id uint64, data string) error {
+	node := n.nodes[id]
+	err := node.Raft.Propose([]byte(data))
+	n.drain(node)
+	return err
+}
+
+func (n *Network) ChangeConfig(id uint64, cc raft.ConfigChange) error {
+	node := n.nodes[id]
+	err := node.Raft.ProposeConfigChange(cc)
+	n.drain(node)
+	return err
+}
+
+func (n *Network) ReadIndex(id, context uint64) error {
+	node := n.nodes[id]
+	err := node.Raft.ReadIndex(context)
+	n.drain(node)
+	return err
+}
+
+// Leader returns the running leader with the highest term, or 0.
+func (n *Network) Leader() uint64 {
+	var lead, term uint64
+	for _, id := range n.IDs() {
+		node := n.nodes[id]
+		if status := node.Raft.Status(); !node.Down && status.State == raft.Leader && status.Term >= term {
+			lead, term = id, status.Term
+		}
+	}
+	return lead
+}
+
+// Converged reports whether every running node applied the same entries.
+func (n *Network) Converged() bool {
+	var want []string
+	first := true
+	for _, id := range n.IDs() {
+		node := n.nodes[id]
+		if node.Down {
+			continue
+		}
+		if first {
+			want, first = node.Applied, false
+			continue
+		}
+		if !slices.Equal(node.Applied, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check fails the test unless the applied entries of every pair of nodes
+// agree up to the shorter of the two.
+func (n *Network) Check() {
+	n.t.Helper()
+	ids := n.IDs()
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			x, y := n.nodes[a].Applied, n.nodes[b].Applied
+			size := min(len(x), len(y))
+			if !slices.Equal(x[:size], y[:size]) {
+				n.t.Fatalf("seed %d: nodes %d and %d applied different entries:\n%v\n%v", n.seed, a, b, x, y)
+			}
+		}
+	}
+}
+
+func (n *Network) drain(node *Node) {
+	rd := node.Raft.Ready()
+	if status := node.Raft.Status(); status.State == raft.Leader {
+		if lead, ok := n.leaders[status.Term]; ok && lead != node.ID {
+			n.t.Fatalf("seed %d: nodes %d and %d both led term %d", n.seed, lead, node.ID, status.Term)
+		}
+		n.leaders[status.Term] = node.ID
+	}
+	if err := node.Storage.Save(rd); err != nil {
+		n.t.Fatalf("seed %d: node %d failed to persist: %v", n.seed, node.ID, err)
+	}
+	if rd.Snapshot != nil && rd.Snapshot.Index > node.applied {
+		node.Applied = nil
+		if err := json.Unmarshal(rd.Snapshot.Data, &node.Applied); err != nil {
+			n.t.Fatalf("seed %d: node %d got a corrupt snapshot: %v", n.seed, node.ID, err)
+		}
+		node.applied = rd.Snapshot.Index
+		node.snapshotIndex = rd.Snapshot.Index
+	}
+	for _, entry := range rd.Committed {
+		if entry.Type == raft.EntryNormal && len(entry.Data) > 0 {
+			node.Applied = append(node.Applied, string(entry.Data))
+		}
+		node.applied = entry.Index
+	}
+	for _, read := range rd.ReadStates {
+		node.Reads[read.Context] = read.Index
+	}
+	for _, msg := range rd.Messages {
+		n.send(msg)
+	}
+	if n.CompactEvery > 0 && node.applied-node.snapshotIndex >= uint64(n.CompactEvery) {
+		data, _ := json.Marshal(node.Applied)
+		if err := node.Raft.Compact(data); err == nil {
+			node.snapshotIndex = node.applied
+			n.drain(node)
+		}
+	}
+}
+
+func (n *Network) send(msg raft.Message) {
+	if n.isCut(msg.From, msg.To) || n.rand.Float64() < n.DropRate {
+		return
+	}
+	delay := 1
+	if n.MaxDelay > 1 {
+		delay += n.rand.Intn(n.MaxDelay)
+	}
+	n.queue = append(n.queue, delivery{at: n.now + delay, msg: msg})
+}
+
+func (n *Network) isCut(from, to uint64) bool {
+	return n.cut[[2]uint64{from, to}]
+}
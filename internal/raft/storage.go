@@ -0,0 +1,112 @@
+package raft
+
+import (
+	"errors"
+	"os"
+
+	"github.com/paxaf/BrandScoutTest/internal/recordlog"
+)
+
+var ErrCorruptLog = errors.New("raft log is corrupt")
+
+type record struct {
+	State    *HardState `json:"state,omitempty"`
+	Snapshot *Snapshot  `json:"snapshot,omitempty"`
+	Entries  []Entry    `json:"entries,omitempty"`
+}
+
+// Storage persists what Ready hands out. The file is a sequence of
+// checksummed records and is rewritten whenever a snapshot replaces part
+// of the log.
+type Storage struct {
+	path  string
+	file  *os.File
+	state HardState
+	log   raftLog
+}
+
+// NewMemoryStorage keeps the state in memory only. It survives a simulated
+// restart but not the process.
+func NewMemoryStorage() *Storage {
+	return &Storage{}
+}
+
+func OpenStorage(path string) (*Storage, error) {
+	s := &Storage{path: path}
+	file, _, err := recordlog.Open(path, ErrCorruptLog, s.apply)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *Storage) State() Persisted {
+	return Persisted{
+		HardState: s.state,
+		Snapshot:  s.log.snapshot,
+		Entries:   append([]Entry(nil), s.log.entries...),
+	}
+}
+
+// Save has to return before the messages of rd are sent.
+func (s *Storage) Save(rd Ready) error {
+	rec := record{Snapshot: rd.Snapshot, Entries: rd.Entries}
+	if rd.HardState != s.state {
+		rec.State = &rd.HardState
+	}
+	if rec.State == nil && rec.Snapshot == nil && len(rec.Entries) == 0 {
+		return nil
+	}
+	s.apply(rec)
+	if s.file == nil {
+		return nil
+	}
+	if rec.Snapshot != nil {
+		return s.rewrite()
+	}
+	line, err := recordlog.Encode(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *Storage) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+func (s *Storage) apply(rec record) {
+	if rec.Snapshot != nil {
+		s.log.restore(*rec.Snapshot)
+	}
+	if len(rec.Entries) > 0 {
+		s.log.append(rec.Entries)
+	}
+	if rec.State != nil {
+		s.state = *rec.State
+	}
+}
+
+// rewrite replaces the file with a single record of the current state,
+// written next to it and renamed over it.
+func (s *Storage) rewrite() error {
+	snap := s.log.snapshot
+	line, err := recordlog.Encode(record{State: &s.state, Snapshot: &snap, Entries: s.log.entries})
+	if err != nil {
+		return err
+	}
+	file, err := recordlog.Replace(s.path, line)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
@@ -0,0 +1,59 @@
+package raft_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/raft"
+)
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "raft.log")
+	storage, err := raft.OpenStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	entries := func(term uint64, indexes ...uint64) []raft.Entry {
+		var res []raft.Entry
+		for _, index := range indexes {
+			res = append(res, raft.Entry{Index: index, Term: term, Data: []byte{byte(index)}})
+		}
+		return res
+	}
+	steps := []raft.Ready{
+		{HardState: raft.HardState{Term: 1, Vote: 1}, Entries: entries(1, 1, 2, 3)},
+		{HardState: raft.HardState{Term: 2, Vote: 2, Commit: 2}, Entries: entries(2, 3, 4)},
+		{HardState: raft.HardState{Term: 2, Vote: 2, Commit: 3}, Snapshot: &raft.Snapshot{Index: 2, Term: 1, Data: []byte("state")}},
+		{HardState: raft.HardState{Term: 2, Vote: 2, Commit: 3}, Entries: entries(2, 5)},
+	}
+	for _, rd := range steps {
+		if err := storage.Save(rd); err != nil {
+			t.Fatalf("Failed to save: %v", err)
+		}
+	}
+	storage.Close()
+
+	// A torn write at the tail is dropped on open.
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	file.WriteString(`0000 {"ent`)
+	file.Close()
+
+	storage, err = raft.OpenStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer storage.Close()
+	state := storage.State()
+	if state.HardState != (raft.HardState{Term: 2, Vote: 2, Commit: 3}) {
+		t.Errorf("Unexpected hard state: %+v", state.HardState)
+	}
+	if state.Snapshot.Index != 2 || string(state.Snapshot.Data) != "state" {
+		t.Errorf("Unexpected snapshot: %+v", state.Snapshot)
+	}
+	if len(state.Entries) != 3 || state.Entries[0].Index != 3 || state.Entries[0].Term != 2 || state.Entries[2].Index != 5 {
+		t.Errorf("Unexpected entries: %+v", state.Entries)
+	}
+}
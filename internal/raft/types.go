@@ -0,0 +1,154 @@
+package raft
+
+import "errors"
+
+var (
+	ErrProposalDropped = errors.New("raft: proposal dropped")
+	ErrNotCompactable  = errors.New("raft: nothing to compact")
+)
+
+type StateType int
+
+const (
+	Follower StateType = iota
+	Candidate
+	Leader
+)
+
+func (s StateType) String() string {
+	switch s {
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	}
+	return "follower"
+}
+
+type EntryType int
+
+const (
+	EntryNormal EntryType = iota
+	EntryConfig
+)
+
+type Entry struct {
+	Index uint64
+	Term  uint64
+	Type  EntryType
+	Data  []byte
+}
+
+type Peer struct {
+	ID   uint64
+	Addr string
+}
+
+// ConfigChange is the payload of an EntryConfig entry. It adds or replaces
+// Peer, or removes it when Remove is set, and takes effect once committed.
+type ConfigChange struct {
+	Peer   Peer
+	Remove bool
+	// Context is opaque to raft and handed back with the committed entry.
+	Context string
+}
+
+// Snapshot replaces every entry up to and including Index. Data is the
+// state machine at that point and Peers the membership.
+type Snapshot struct {
+	Index uint64
+	Term  uint64
+	Peers []Peer
+	Data  []byte
+}
+
+type HardState struct {
+	Term   uint64
+	Vote   uint64
+	Commit uint64
+}
+
+// Persisted is what a node has to keep across restarts.
+type Persisted struct {
+	HardState HardState
+	Snapshot  Snapshot
+	Entries   []Entry
+}
+
+type MessageType int
+
+const (
+	MsgVote MessageType = iota
+	MsgVoteResp
+	MsgApp
+	MsgAppResp
+	MsgSnap
+	MsgHeartbeat
+	MsgHeartbeatResp
+	MsgProp
+	MsgReadIndex
+	MsgReadIndexResp
+)
+
+func (t MessageType) String() string {
+	names := [...]string{"vote", "vote_resp", "app", "app_resp", "snap", "heartbeat", "heartbeat_resp", "prop", "read_index", "read_index_resp"}
+	if int(t) < len(names) {
+		return names[t]
+	}
+	return "unknown"
+}
+
+// Message is exchanged between nodes. Index and LogTerm hold the entry
+// preceding Entries for MsgApp, the last log entry for MsgVote and the
+// acknowledged index for MsgAppResp and MsgReadIndexResp.
+type Message struct {
+	Type       MessageType
+	From       uint64
+	To         uint64
+	Term       uint64
+	Index      uint64
+	LogTerm    uint64
+	Entries    []Entry
+	Commit     uint64
+	Reject     bool
+	RejectHint uint64
+	Snapshot   *Snapshot
+	Context    uint64
+}
+
+// ReadState reports that a read requested with Context is linearizable
+// once the state machine has applied Index.
+type ReadState struct {
+	Context uint64
+	Index   uint64
+}
+
+// Ready is the work a node hands to its driver. HardState, Snapshot and
+// Entries have to be persisted before Messages are sent; Entries replace
+// the stored log from Entries[0].Index on. Snapshot is applied to the
+// state machine when it is past what was applied, then Committed in order.
+type Ready struct {
+	HardState  HardState
+	Snapshot   *Snapshot
+	Entries    []Entry
+	Committed  []Entry
+	Messages   []Message
+	ReadStates []ReadState
+}
+
+func (rd Ready) Empty() bool {
+	return rd.Snapshot == nil && len(rd.Entries) == 0 && len(rd.Committed) == 0 &&
+		len(rd.Messages) == 0 && len(rd.ReadStates) == 0
+}
+
+// Status is a point-in-time view of a node.
+type Status struct {
+	ID      uint64
+	State   StateType
+	Term    uint64
+	Leader  uint64
+	Commit  uint64
+	Applied uint64
+	Index   uint64
+	Peers   []Peer
+}
@@ -0,0 +1,104 @@
+// Package recordlog reads and writes the append-only files of the storage
+// engine and the raft log: one JSON record per line, prefixed with the
+// CRC-32 of the JSON in hex.
+package recordlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+)
+
+func Encode(rec any) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(data), data), nil
+}
+
+func Decode[T any](line []byte) (T, error) {
+	var rec T
+	sum, data, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return rec, errors.New("missing checksum")
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil {
+		return rec, err
+	}
+	if crc32.ChecksumIEEE(data) != uint32(want) {
+		return rec, errors.New("checksum mismatch")
+	}
+	err = json.Unmarshal(data, &rec)
+	return rec, err
+}
+
+// Open replays the records of the file at path, creating it if missing,
+// and returns it open for appending along with its size. A record that
+// does not decode is a write that never committed when it is the last
+// one, and is cut off; anywhere else the file is reported as corrupt.
+func Open[T any](path string, corrupt error, replay func(T)) (*os.File, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, 0, err
+	}
+	valid := 0
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			break
+		}
+		rec, err := Decode[T](data[valid : valid+end])
+		if err != nil {
+			if valid+end+1 < len(data) {
+				return nil, 0, fmt.Errorf("%w: record at offset %d: %v", corrupt, valid, err)
+			}
+			break
+		}
+		replay(rec)
+		valid += end + 1
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := file.Truncate(int64(valid)); err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	if _, err := file.Seek(int64(valid), io.SeekStart); err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, int64(valid), nil
+}
+
+// Replace writes line as the only record of the file at path. It is
+// written next to the file and renamed over it, so a crash leaves one or
+// the other. The new file is returned open for appending.
+func Replace(path string, line []byte) (*os.File, error) {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,98 @@
+package recordlog_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/recordlog"
+)
+
+var errCorrupt = errors.New("corrupt")
+
+type record struct {
+	Seq int `json:"seq"`
+}
+
+func write(t *testing.T, path string, tail string, seqs ...int) {
+	t.Helper()
+	var data []byte
+	for _, seq := range seqs {
+		line, err := recordlog.Encode(record{Seq: seq})
+		if err != nil {
+			t.Fatalf("Failed to encode record: %v", err)
+		}
+		data = append(data, line...)
+	}
+	if err := os.WriteFile(path, append(data, tail...), 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	// A torn write at the tail is cut off, and appends go after the last
+	// record that decoded.
+	path := filepath.Join(t.TempDir(), "records.log")
+	write(t, path, `0000 {"se`, 1, 2)
+	var seqs []int
+	file, size, err := recordlog.Open(path, errCorrupt, func(rec record) { seqs = append(seqs, rec.Seq) })
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if !slices.Equal(seqs, []int{1, 2}) {
+		t.Errorf("Replayed %v, want [1 2]", seqs)
+	}
+	line, _ := recordlog.Encode(record{Seq: 3})
+	file.Write(line)
+	file.Close()
+	if info, _ := os.Stat(path); info.Size() != size+int64(len(line)) {
+		t.Errorf("Log is %d bytes, want %d", info.Size(), size+int64(len(line)))
+	}
+
+	seqs = nil
+	file, _, err = recordlog.Open(path, errCorrupt, func(rec record) { seqs = append(seqs, rec.Seq) })
+	if err != nil {
+		t.Fatalf("Failed to reopen log: %v", err)
+	}
+	file.Close()
+	if !slices.Equal(seqs, []int{1, 2, 3}) {
+		t.Errorf("Replayed %v, want [1 2 3]", seqs)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "records.log")
+	line, _ := recordlog.Encode(record{Seq: 2})
+	write(t, path, "0000 {}\n"+string(line), 1)
+	if _, _, err := recordlog.Open(path, errCorrupt, func(record) {}); !errors.Is(err, errCorrupt) {
+		t.Errorf("Expected a corrupt log, got %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "records.log")
+	write(t, path, "", 1, 2)
+	line, _ := recordlog.Encode(record{Seq: 3})
+	file, err := recordlog.Replace(path, line)
+	if err != nil {
+		t.Fatalf("Failed to replace log: %v", err)
+	}
+	file.Close()
+	var seqs []int
+	file, _, err = recordlog.Open(path, errCorrupt, func(rec record) { seqs = append(seqs, rec.Seq) })
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	file.Close()
+	if !slices.Equal(seqs, []int{3}) {
+		t.Errorf("Replayed %v, want [3]", seqs)
+	}
+}
@@ -0,0 +1,183 @@
+package replica
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	v1 "github.com/paxaf/BrandScoutTest/internal/controller/v1"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	defaultWait = 30 * time.Second
+	retryDelay  = time.Second
+)
+
+// Follower keeps a store in step with a leader's commit log: it bootstraps
+// from a checkpoint when it has nothing to continue from, then long-polls
+// the leader for the commits after its own seq.
+type Follower struct {
+	leader string
+	token  string
+	wait   time.Duration
+	client *http.Client
+	store  repo.Replica
+	mutex  sync.Mutex
+	status entity.ReplicaStatus
+}
+
+type Option func(*Follower)
+
+// WithToken sets the admin token the leader's replication endpoints expect.
+func WithToken(token string) Option {
+	return func(f *Follower) {
+		f.token = token
+	}
+}
+
+// WithWait sets how long the leader may hold a poll with no new commits.
+func WithWait(wait time.Duration) Option {
+	return func(f *Follower) {
+		f.wait = wait
+	}
+}
+
+func New(leader string, store repo.Replica, opts ...Option) *Follower {
+	f := &Follower{
+		leader: leader,
+		wait:   defaultWait,
+		store:  store,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.client = &http.Client{Timeout: f.wait + 10*time.Second}
+	f.status.Leader = leader
+	f.status.Seq, _ = store.Version()
+	return f
+}
+
+func (f *Follower) Status() entity.ReplicaStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.status
+}
+
+// Run follows the leader until ctx is done. An empty store, or one whose
+// seq the leader's log no longer covers, is rebuilt from a checkpoint.
+func (f *Follower) Run(ctx context.Context) {
+	seq, _ := f.store.Version()
+	bootstrap := seq == 0
+	for ctx.Err() == nil {
+		var err error
+		if bootstrap {
+			if err = f.bootstrap(ctx); err == nil {
+				bootstrap = false
+			}
+		} else {
+			err = f.pull(ctx)
+		}
+		switch {
+		case err == nil || ctx.Err() != nil:
+		case errors.Is(err, repo.ErrOffsetOutOfRange):
+			log.Printf("replica fell out of the leader's log: %v", err)
+			bootstrap = true
+		default:
+			log.Printf("replication failed: %v", err)
+			f.update(func(status *entity.ReplicaStatus) { status.Err = err.Error() })
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+}
+
+func (f *Follower) bootstrap(ctx context.Context) error {
+	var checkpoint v1.Checkpoint
+	if err := f.get(ctx, "/replication/checkpoint", nil, &checkpoint); err != nil {
+		return err
+	}
+	if err := f.store.Load(checkpoint.ToEntity()); err != nil {
+		return err
+	}
+	log.Printf("replica bootstrapped from checkpoint at seq %d", checkpoint.Seq)
+	f.update(func(status *entity.ReplicaStatus) {
+		status.Seq = checkpoint.Seq
+		status.LeaderSeq = max(status.LeaderSeq, checkpoint.Seq)
+		status.LastSync = time.Now()
+		status.Bootstraps++
+		status.Err = ""
+	})
+	return nil
+}
+
+func (f *Follower) pull(ctx context.Context) error {
+	offset := f.store.Offset()
+	seq := offset.Seq
+	query := url.Values{
+		"since": {offset.String()},
+		"wait":  {f.wait.String()},
+	}
+	var resp v1.CommitsResponse
+	if err := f.get(ctx, "/replication/log", query, &resp); err != nil {
+		return err
+	}
+	for _, commit := range resp.Commits {
+		if err := f.store.Apply(commit.ToEntity()); err != nil {
+			return err
+		}
+		seq = commit.Seq
+	}
+	f.update(func(status *entity.ReplicaStatus) {
+		status.Seq = seq
+		status.LeaderSeq = resp.Seq
+		status.LastSync = time.Now()
+		status.Err = ""
+	})
+	return nil
+}
+
+func (f *Follower) get(ctx context.Context, path string, query url.Values, v any) error {
+	target := f.leader + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if f.token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusGone:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%w: %s", repo.ErrOffsetOutOfRange, bytes.TrimSpace(body))
+	default:
+		return fmt.Errorf("leader answered %s to %s", resp.Status, path)
+	}
+}
+
+func (f *Follower) update(fn func(status *entity.ReplicaStatus)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	fn(&f.status)
+}
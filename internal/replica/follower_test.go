@@ -0,0 +1,187 @@
+package replica_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/controller"
+	"github.com/paxaf/BrandScoutTest/internal/controller/middleware"
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/replica"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+)
+
+const token = "secret"
+
+func newLeader(t *testing.T, opts ...storage.Option) (*storage.Engine, *httptest.Server) {
+	t.Helper()
+	engine, err := storage.NewEngine(opts...)
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /replication/checkpoint", controller.Checkpoint(engine))
+	mux.Handle("GET /replication/log", controller.Commits(engine))
+	server := httptest.NewServer(middleware.Admin(token)(mux))
+	t.Cleanup(server.Close)
+	return engine, server
+}
+
+func follow(t *testing.T, leader string, store *storage.Engine) (*replica.Follower, context.CancelFunc) {
+	t.Helper()
+	follower := replica.New(leader, store, replica.WithToken(token), replica.WithWait(100*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		follower.Run(ctx)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return follower, stop
+}
+
+func waitFor(t *testing.T, follower *replica.Follower, seq uint64) entity.ReplicaStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := follower.Status()
+		if status.Seq == seq && status.LeaderSeq == seq {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Follower did not reach seq %d: %+v", seq, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFollower(t *testing.T) {
+	t.Parallel()
+
+	leader, server := newLeader(t)
+	for i := range 10 {
+		id := fmt.Sprint(i)
+		leader.Set(id, entity.Quote{Id: id, Phrase: "Q" + id})
+	}
+	store, _ := storage.NewEngine()
+	follower, _ := follow(t, server.URL, store)
+
+	status := waitFor(t, follower, 10)
+	if status.Bootstraps != 1 || status.Err != "" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	leader.Set("10", entity.Quote{Id: "10", Phrase: "Q10"})
+	leader.SoftDel("3", time.Now())
+	leader.SetAuthor("a1", entity.Author{Id: "a1", Name: "Seneca"})
+	leader.AppendRevision("10", entity.Revision{Quote: entity.Quote{Id: "10", Phrase: "Q10 draft"}})
+	waitFor(t, follower, 14)
+	quotes, _ := store.GetAll()
+	trash, _ := store.GetTrash()
+	if len(quotes) != 10 || len(trash) != 1 {
+		t.Errorf("Follower diverged: %d quotes, %d trashed", len(quotes), len(trash))
+	}
+	if got, _ := store.Get("10"); got.Version != 11 {
+		t.Errorf("Version was not replicated: %+v", got)
+	}
+	if author, err := store.GetAuthor("a1"); err != nil || author.Name != "Seneca" {
+		t.Errorf("Author was not replicated: %+v %v", author, err)
+	}
+	if revs, _ := store.Revisions("10"); len(revs) != 1 || revs[0].Quote.Phrase != "Q10 draft" {
+		t.Errorf("Revision was not replicated: %+v", revs)
+	}
+}
+
+func TestFollowerResnapshots(t *testing.T) {
+	t.Parallel()
+
+	leader, server := newLeader(t, storage.WithLogRetention(2))
+	leader.Set("1", entity.Quote{Id: "1"})
+	store, _ := storage.NewEngine()
+	follower, stop := follow(t, server.URL, store)
+	waitFor(t, follower, 1)
+	stop()
+
+	for i := range 5 {
+		id := fmt.Sprint(i + 2)
+		leader.Set(id, entity.Quote{Id: id})
+	}
+	follower, _ = follow(t, server.URL, store)
+	status := waitFor(t, follower, 6)
+	if status.Bootstraps != 1 {
+		t.Errorf("Follower behind the log did not resnapshot: %+v", status)
+	}
+	if quotes, _ := store.GetAll(); len(quotes) != 6 {
+		t.Errorf("Expected 6 quotes, got %d", len(quotes))
+	}
+}
+
+func TestFollowerLeaderRestarted(t *testing.T) {
+	t.Parallel()
+
+	// The leader keeps its log in memory, so a restarted leader counts
+	// seqs from 0 again under the same address.
+	var leader atomic.Pointer[storage.Engine]
+	serve := func(handler func(repo.ChangeLog) http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			handler(leader.Load())(w, r)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /replication/checkpoint", serve(controller.Checkpoint))
+	mux.Handle("GET /replication/log", serve(controller.Commits))
+	server := httptest.NewServer(middleware.Admin(token)(mux))
+	t.Cleanup(server.Close)
+
+	fill := func(prefix string, n int) {
+		engine, _ := storage.NewEngine()
+		for i := range n {
+			id := fmt.Sprint(prefix, i)
+			engine.Set(id, entity.Quote{Id: id})
+		}
+		leader.Store(engine)
+	}
+	fill("a", 3)
+	store, _ := storage.NewEngine()
+	follower, _ := follow(t, server.URL, store)
+	waitFor(t, follower, 3)
+
+	fill("b", 5)
+	status := waitFor(t, follower, 5)
+	if status.Bootstraps != 2 {
+		t.Errorf("Follower did not resnapshot from the restarted leader: %+v", status)
+	}
+	quotes, _ := store.GetAll()
+	for _, quote := range quotes {
+		if !strings.HasPrefix(quote.Id, "b") {
+			t.Errorf("Follower kept %s from the leader's old log", quote.Id)
+		}
+	}
+	if len(quotes) != 5 {
+		t.Errorf("Expected 5 quotes, got %d", len(quotes))
+	}
+}
+
+func TestFollowerRejectedByLeader(t *testing.T) {
+	t.Parallel()
+
+	_, server := newLeader(t)
+	store, _ := storage.NewEngine()
+	follower := replica.New(server.URL, store, replica.WithWait(10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	follower.Run(ctx)
+	if status := follower.Status(); status.Err == "" || status.Bootstraps != 0 {
+		t.Errorf("Expected the missing token to be reported: %+v", status)
+	}
+}
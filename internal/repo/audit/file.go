@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type FileLog struct {
+	mutex    sync.Mutex
+	file     *os.File
+	seq      uint64
+	last     string
+	size     int64
+	failures atomic.Uint64
+}
+
+type line struct {
+	Entry json.RawMessage `json:"entry"`
+	Hash  string          `json:"hash"`
+}
+
+type record struct {
+	Seq          uint64         `json:"seq"`
+	At           string         `json:"at"`
+	Actor        string         `json:"actor"`
+	IP           string         `json:"ip,omitempty"`
+	RequestId    string         `json:"request_id,omitempty"`
+	Operation    string         `json:"operation"`
+	QuoteId      string         `json:"quote_id"`
+	Before       *entity.Quote  `json:"before,omitempty"`
+	After        *entity.Quote  `json:"after,omitempty"`
+	Subject      string         `json:"subject,omitempty"`
+	AuthorBefore *entity.Author `json:"author_before,omitempty"`
+	AuthorAfter  *entity.Author `json:"author_after,omitempty"`
+	ValueBefore  string         `json:"value_before,omitempty"`
+	ValueAfter   string         `json:"value_after,omitempty"`
+	PrevHash     string         `json:"prev_hash"`
+}
+
+func Open(path string) (*FileLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := &FileLog{file: file}
+	l.size, err = l.scan(func(entry entity.AuditEntry) {
+		l.seq = entry.Seq
+		l.last = entry.Hash
+	})
+	if err == nil {
+		err = l.truncateTail()
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// truncateTail drops an unterminated last line. Append writes a line and
+// its newline in one call, so only a crash mid-write leaves one behind.
+func (l *FileLog) truncateTail() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == l.size {
+		return nil
+	}
+	log.Printf("audit log: dropping %d bytes of a torn last entry", info.Size()-l.size)
+	return l.file.Truncate(l.size)
+}
+
+func (l *FileLog) Append(entry entity.AuditEntry) (entity.AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entry, err := l.append(entry)
+	if err != nil {
+		l.failures.Add(1)
+	}
+	return entry, err
+}
+
+// Failures reports how many entries Append failed to write.
+func (l *FileLog) Failures() uint64 {
+	return l.failures.Load()
+}
+
+func (l *FileLog) append(entry entity.AuditEntry) (entity.AuditEntry, error) {
+	entry.Seq = l.seq + 1
+	entry.PrevHash = l.last
+	data, err := json.Marshal(fromEntry(entry))
+	if err != nil {
+		return entity.AuditEntry{}, err
+	}
+	entry.Hash = chainHash(entry.PrevHash, data)
+	out, err := json.Marshal(line{Entry: data, Hash: entry.Hash})
+	if err != nil {
+		return entity.AuditEntry{}, err
+	}
+	out = append(out, '\n')
+	if _, err := l.file.Write(out); err != nil {
+		return entity.AuditEntry{}, l.rollback(err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return entity.AuditEntry{}, l.rollback(err)
+	}
+	l.seq = entry.Seq
+	l.last = entry.Hash
+	l.size += int64(len(out))
+	return entry, nil
+}
+
+// rollback cuts off what a failed Append may have written, so the entry
+// does not reappear on the next open with a seq the chain has reused.
+func (l *FileLog) rollback(err error) error {
+	if truncErr := l.file.Truncate(l.size); truncErr != nil {
+		return errors.Join(err, truncErr)
+	}
+	return err
+}
+
+func (l *FileLog) Query(filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var res []entity.AuditEntry
+	_, err := l.scan(func(entry entity.AuditEntry) {
+		if filter.Match(entry) {
+			res = append(res, entry)
+		}
+	})
+	return res, err
+}
+
+func (l *FileLog) Close() error {
+	return l.file.Close()
+}
+
+// scan calls fn for every entry in order and returns the offset after the
+// last complete line. An unterminated last line is left to the caller.
+func (l *FileLog) scan(fn func(entity.AuditEntry)) (int64, error) {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	reader := bufio.NewReaderSize(l.file, 64*1024)
+	prev := ""
+	var seq uint64
+	var offset int64
+	for {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		var ln line
+		if err := json.Unmarshal(data, &ln); err != nil {
+			return offset, fmt.Errorf("%w: entry %d is malformed", repo.ErrAuditTampered, seq+1)
+		}
+		var rec record
+		if err := json.Unmarshal(ln.Entry, &rec); err != nil {
+			return offset, fmt.Errorf("%w: entry %d is malformed", repo.ErrAuditTampered, seq+1)
+		}
+		if rec.Seq != seq+1 || rec.PrevHash != prev || chainHash(prev, ln.Entry) != ln.Hash {
+			return offset, fmt.Errorf("%w: entry %d does not match the chain", repo.ErrAuditTampered, seq+1)
+		}
+		entry, err := rec.toEntry()
+		if err != nil {
+			return offset, fmt.Errorf("%w: entry %d is malformed", repo.ErrAuditTampered, seq+1)
+		}
+		entry.Hash = ln.Hash
+		fn(entry)
+		seq = rec.Seq
+		prev = ln.Hash
+		offset += int64(len(data))
+	}
+}
+
+func chainHash(prev string, data []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(prev))
+	sum.Write(data)
+	return hex.EncodeToString(sum.Sum(nil))
+}
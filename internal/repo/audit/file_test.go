@@ -0,0 +1,151 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	"github.com/paxaf/BrandScoutTest/internal/repo/audit"
+)
+
+func TestFileLog(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	quote := entity.Quote{Id: "1", Author: "Seneca", Phrase: "Original"}
+	entries := []entity.AuditEntry{
+		{Actor: entity.Actor{Name: "alice", IP: "10.0.0.1", RequestId: "r1"}, Operation: entity.AuditCreate, QuoteId: "1", After: &quote},
+		{Actor: entity.Actor{Name: "bob"}, Operation: entity.AuditDelete, QuoteId: "1", Before: &quote},
+	}
+	for _, entry := range entries {
+		entry.At = time.Now().UTC()
+		if _, err := log.Append(entry); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Failed to close log: %v", err)
+	}
+
+	log, err = audit.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen log: %v", err)
+	}
+	third, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Actor: entity.Actor{Name: "alice"}, Operation: entity.AuditRestore, QuoteId: "1", After: &quote})
+	if err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	if third.Seq != 3 || third.PrevHash == "" {
+		t.Errorf("Chain was not continued after reopen: %+v", third)
+	}
+
+	got, err := log.Query(entity.AuditFilter{Actor: "alice"})
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(got) != 2 || got[0].Actor.IP != "10.0.0.1" || got[0].After.Phrase != "Original" || got[1].Seq != 3 {
+		t.Errorf("Unexpected entries: %+v", got)
+	}
+
+	author := entity.Author{Id: "7", Name: "Seneca"}
+	if _, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Operation: entity.AuditAuthorCreate, Subject: "7", AuthorAfter: &author}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	got, err = log.Query(entity.AuditFilter{Subject: "7"})
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(got) != 1 || got[0].AuthorAfter == nil || got[0].AuthorAfter.Name != "Seneca" {
+		t.Errorf("Unexpected author entries: %+v", got)
+	}
+
+	log.Close()
+	if _, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Operation: entity.AuditPin, Subject: "2026-01-01", ValueAfter: "1"}); err == nil {
+		t.Error("Append to a closed log succeeded")
+	}
+	if n := log.Failures(); n != 1 {
+		t.Errorf("Expected 1 failed append, got %d", n)
+	}
+}
+
+func TestFileLogTampering(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	for _, actor := range []string{"alice", "bob"} {
+		if _, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Actor: entity.Actor{Name: actor}, Operation: entity.AuditDelete, QuoteId: "1"}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	log.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if err := os.WriteFile(path, bytes.Replace(data, []byte(`"actor":"bob"`), []byte(`"actor":"eve"`), 1), 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	if _, err := audit.Open(path); !errors.Is(err, repo.ErrAuditTampered) {
+		t.Errorf("Expected tampering to be detected, got %v", err)
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if err := os.WriteFile(path, lines[1], 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	if _, err := audit.Open(path); !errors.Is(err, repo.ErrAuditTampered) {
+		t.Errorf("Expected removed entry to be detected, got %v", err)
+	}
+}
+
+func TestFileLogTornTail(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if _, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Actor: entity.Actor{Name: "alice"}, Operation: entity.AuditCreate, QuoteId: "1"}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	log.Close()
+
+	// A crash in the middle of the next append.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open log file: %v", err)
+	}
+	file.WriteString(`{"entry":{"seq":2,"at":"2026-`)
+	file.Close()
+
+	log, err = audit.Open(path)
+	if err != nil {
+		t.Fatalf("Expected a torn last line to be dropped, got %v", err)
+	}
+	defer log.Close()
+	next, err := log.Append(entity.AuditEntry{At: time.Now().UTC(), Actor: entity.Actor{Name: "bob"}, Operation: entity.AuditDelete, QuoteId: "1"})
+	if err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	if next.Seq != 2 {
+		t.Errorf("Expected the chain to continue at 2, got %d", next.Seq)
+	}
+	if got, err := log.Query(entity.AuditFilter{}); err != nil || len(got) != 2 {
+		t.Errorf("Unexpected entries after recovery: %+v %v", got, err)
+	}
+}
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+func fromEntry(entry entity.AuditEntry) record {
+	return record{
+		Seq:          entry.Seq,
+		At:           entry.At.UTC().Format(time.RFC3339Nano),
+		Actor:        entry.Actor.Name,
+		IP:           entry.Actor.IP,
+		RequestId:    entry.Actor.RequestId,
+		Operation:    entry.Operation,
+		QuoteId:      entry.QuoteId,
+		Before:       entry.Before,
+		After:        entry.After,
+		Subject:      entry.Subject,
+		AuthorBefore: entry.AuthorBefore,
+		AuthorAfter:  entry.AuthorAfter,
+		ValueBefore:  entry.ValueBefore,
+		ValueAfter:   entry.ValueAfter,
+		PrevHash:     entry.PrevHash,
+	}
+}
+
+func (rec record) toEntry() (entity.AuditEntry, error) {
+	at, err := time.Parse(time.RFC3339Nano, rec.At)
+	if err != nil {
+		return entity.AuditEntry{}, err
+	}
+	return entity.AuditEntry{
+		Seq:          rec.Seq,
+		At:           at,
+		Actor:        entity.Actor{Name: rec.Actor, IP: rec.IP, RequestId: rec.RequestId},
+		Operation:    rec.Operation,
+		QuoteId:      rec.QuoteId,
+		Before:       rec.Before,
+		After:        rec.After,
+		Subject:      rec.Subject,
+		AuthorBefore: rec.AuthorBefore,
+		AuthorAfter:  rec.AuthorAfter,
+		ValueBefore:  rec.ValueBefore,
+		ValueAfter:   rec.ValueAfter,
+		PrevHash:     rec.PrevHash,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package btree
+
+import (
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (s *Store) SetAlias(alias, canonical string) error {
+	return s.update(func(tx *txn) error {
+		return tx.put(nameKey(prefixAlias, alias), []byte(canonical))
+	})
+}
+
+func (s *Store) DelAlias(alias string) error {
+	return s.delName(prefixAlias, alias)
+}
+
+func (s *Store) GetAlias(alias string) (string, error) {
+	return s.getName(prefixAlias, alias)
+}
+
+func (s *Store) Aliases() (map[string]string, error) {
+	return s.names(prefixAlias)
+}
+
+func (s *Store) getName(prefix byte, name string) (string, error) {
+	var raw []byte
+	err := s.view(func(r reader, root pgid) error {
+		value, ok, err := get(r, root, nameKey(prefix, name))
+		if err == nil && !ok {
+			err = repo.ErrNotFound
+		}
+		raw = value
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func (s *Store) delName(prefix byte, name string) error {
+	return s.update(func(tx *txn) error {
+		key := nameKey(prefix, name)
+		_, ok, err := tx.get(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return repo.ErrNotFound
+		}
+		return tx.del(key)
+	})
+}
+
+func (s *Store) names(prefix byte) (map[string]string, error) {
+	res := make(map[string]string)
+	err := s.view(func(r reader, root pgid) error {
+		return scanPrefix(r, root, []byte{prefix}, func(key, value []byte) bool {
+			res[string(key[1:])] = string(value)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,181 @@
+package btree
+
+import (
+	"errors"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (s *Store) SetAuthor(key string, value entity.Author) (entity.Author, error) {
+	err := s.update(func(tx *txn) error {
+		raw, ok, err := tx.get(idKey(prefixAuthor, key))
+		if err != nil {
+			return err
+		}
+		if ok {
+			old, err := decode[entity.Author](raw)
+			if err != nil {
+				return err
+			}
+			if err := tx.del(nameKey(prefixName, entity.NormalizeAuthor(old.Name))); err != nil {
+				return err
+			}
+		}
+		if raw, err = encode(value); err != nil {
+			return err
+		}
+		if err := tx.put(idKey(prefixAuthor, key), raw); err != nil {
+			return err
+		}
+		return tx.put(nameKey(prefixName, entity.NormalizeAuthor(value.Name)), []byte(key))
+	})
+	if err != nil {
+		return entity.Author{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, error) {
+	var existing entity.Author
+	err := s.update(func(tx *txn) error {
+		var err error
+		existing, err = tx.setAuthorIfAbsent(key, value)
+		return err
+	})
+	if err != nil {
+		return existing, err
+	}
+	return value, nil
+}
+
+func (tx *txn) setAuthorIfAbsent(key string, value entity.Author) (entity.Author, error) {
+	holder := key
+	named, ok, err := tx.get(nameKey(prefixName, entity.NormalizeAuthor(value.Name)))
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if ok {
+		holder = string(named)
+	}
+	existing, err := readAuthor(tx, tx.root, holder)
+	if err == nil {
+		return existing, repo.ErrExists
+	}
+	if !errors.Is(err, repo.ErrNotFound) {
+		return entity.Author{}, err
+	}
+	raw, err := encode(value)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if err := tx.put(idKey(prefixAuthor, key), raw); err != nil {
+		return entity.Author{}, err
+	}
+	return value, tx.put(nameKey(prefixName, entity.NormalizeAuthor(value.Name)), []byte(key))
+}
+
+func (tx *txn) GetAuthorByName(name string) (entity.Author, bool) {
+	key, ok, err := tx.get(nameKey(prefixName, entity.NormalizeAuthor(name)))
+	if err != nil || !ok {
+		tx.fail(err)
+		return entity.Author{}, false
+	}
+	value, err := readAuthor(tx, tx.root, string(key))
+	tx.fail(err)
+	return value, err == nil
+}
+
+func (tx *txn) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, bool) {
+	existing, err := tx.setAuthorIfAbsent(key, value)
+	if errors.Is(err, repo.ErrExists) {
+		return existing, false
+	}
+	tx.fail(err)
+	return existing, err == nil
+}
+
+func (s *Store) GetAuthor(key string) (entity.Author, error) {
+	var value entity.Author
+	err := s.view(func(r reader, root pgid) error {
+		var err error
+		value, err = readAuthor(r, root, key)
+		return err
+	})
+	if err != nil {
+		return entity.Author{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) GetAuthorByName(name string) (entity.Author, error) {
+	var value entity.Author
+	err := s.view(func(r reader, root pgid) error {
+		key, found, err := get(r, root, nameKey(prefixName, entity.NormalizeAuthor(name)))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return repo.ErrNotFound
+		}
+		value, err = readAuthor(r, root, string(key))
+		return err
+	})
+	if err != nil {
+		return entity.Author{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) DelAuthor(key string) error {
+	return s.update(func(tx *txn) error {
+		raw, ok, err := tx.get(idKey(prefixAuthor, key))
+		if err != nil || !ok {
+			return err
+		}
+		old, err := decode[entity.Author](raw)
+		if err != nil {
+			return err
+		}
+		if err := tx.del(nameKey(prefixName, entity.NormalizeAuthor(old.Name))); err != nil {
+			return err
+		}
+		return tx.del(idKey(prefixAuthor, key))
+	})
+}
+
+func (s *Store) GetAllAuthors() ([]entity.Author, error) {
+	var (
+		res       []entity.Author
+		decodeErr error
+	)
+	err := s.view(func(r reader, root pgid) error {
+		return scanPrefix(r, root, []byte{prefixAuthor}, func(_, raw []byte) bool {
+			value, err := decode[entity.Author](raw)
+			if err != nil {
+				decodeErr = err
+				return false
+			}
+			res = append(res, value)
+			return true
+		})
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func readAuthor(r reader, root pgid, key string) (entity.Author, error) {
+	raw, ok, err := get(r, root, idKey(prefixAuthor, key))
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if !ok {
+		return entity.Author{}, repo.ErrNotFound
+	}
+	return decode[entity.Author](raw)
+}
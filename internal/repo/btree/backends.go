@@ -0,0 +1,19 @@
+package btree
+
+import (
+	"errors"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func init() {
+	repo.Register("btree", func(cfg repo.Config) (repo.Store, error) {
+		if cfg.Path == "" {
+			return nil, errors.New("btree backend requires a path")
+		}
+		if cfg.MaxEntries > 0 || cfg.MaxBytes > 0 {
+			return nil, errors.New("btree backend does not support STORAGE_MAX_ENTRIES and STORAGE_MAX_BYTES")
+		}
+		return Open(cfg.Path, WithHistoryCap(cfg.HistoryCap))
+	})
+}
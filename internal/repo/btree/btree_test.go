@@ -0,0 +1,381 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+var errInjected = errors.New("injected write failure")
+
+// memFile is an in-memory File. Once budget bytes have been written, the
+// next write is torn at the budget and every later write fails, which is
+// what the disk looks like after a crash at that point.
+type memFile struct {
+	mutex  sync.Mutex
+	data   []byte
+	budget int
+}
+
+func newMemFile() *memFile {
+	return &memFile{budget: -1}
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var err error
+	if f.budget >= 0 && len(p) > f.budget {
+		p, err = p[:f.budget], errInjected
+	}
+	if f.budget >= 0 {
+		f.budget -= len(p)
+	}
+	if end := int(off) + len(p); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	copy(f.data[off:], p)
+	return len(p), err
+}
+
+func (f *memFile) Sync() error  { return nil }
+func (f *memFile) Close() error { return nil }
+
+// crash returns what survives on disk.
+func (f *memFile) crash() *memFile {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return &memFile{data: slices.Clone(f.data), budget: -1}
+}
+
+func newStore(t *testing.T, file File, opts ...Option) *Store {
+	t.Helper()
+	s, err := New(file, opts...)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	return s
+}
+
+// checkPages verifies every page below the high-water mark is used by the
+// tree or free, and never both.
+func checkPages(t *testing.T, s *Store) {
+	t.Helper()
+	reachable, err := s.reachable(s.meta.root)
+	if err != nil {
+		t.Fatalf("Tree is broken: %v", err)
+	}
+	owner := make(map[pgid]string, len(reachable))
+	for id := range reachable {
+		owner[id] = "tree"
+	}
+	for _, id := range s.free {
+		if prev, ok := owner[id]; ok {
+			t.Fatalf("Free page %d is also used by the %s", id, prev)
+		}
+		owner[id] = "free list"
+	}
+	for id := pgid(firstPage); id < s.meta.pages; id++ {
+		if _, ok := owner[id]; !ok {
+			t.Fatalf("Page %d leaked", id)
+		}
+	}
+}
+
+func phrase(r *rand.Rand, id string) string {
+	size := 10 + r.IntN(60)
+	if r.IntN(8) == 0 {
+		size = 1000 + r.IntN(6000)
+	}
+	return id + ":" + strings.Repeat(string(rune('a'+r.IntN(26))), size)
+}
+
+func checkModel(t *testing.T, s *Store, model map[string]string) {
+	t.Helper()
+	all, _ := s.GetAll()
+	if len(all) != len(model) {
+		t.Fatalf("Expected %d quotes, got %d", len(model), len(all))
+	}
+	for _, q := range all {
+		if model[q.Id] != q.Phrase {
+			t.Fatalf("Quote %s: expected %.20q, got %.20q", q.Id, model[q.Id], q.Phrase)
+		}
+	}
+	for id, want := range model {
+		if got, err := s.Get(id); err != nil || got.Phrase != want {
+			t.Fatalf("Get(%s) = %.20q %v, want %.20q", id, got.Phrase, err, want)
+		}
+	}
+}
+
+func TestRandomOperations(t *testing.T) {
+	file := newMemFile()
+	s := newStore(t, file, WithPoolSize(8))
+	r := rand.New(rand.NewPCG(1, 2))
+	model := make(map[string]string)
+	trash := make(map[string]string)
+	for i := range 3000 {
+		id := fmt.Sprint(r.IntN(400))
+		switch op := r.IntN(10); {
+		case op < 6:
+			q := entity.Quote{Id: id, Phrase: phrase(r, id), Tags: []string{"t" + fmt.Sprint(r.IntN(5))}}
+			s.Set(id, q)
+			model[id] = q.Phrase
+			delete(trash, id)
+		case op < 8:
+			s.Del(id)
+			delete(model, id)
+			delete(trash, id)
+		case op < 9:
+			if s.SoftDel(id, time.Now()) == nil {
+				trash[id] = model[id]
+				delete(model, id)
+			}
+		default:
+			if q, err := s.Restore(id); err == nil {
+				model[id] = q.Phrase
+				delete(trash, id)
+			}
+		}
+		if i%500 == 0 {
+			checkModel(t, s, model)
+			checkPages(t, s)
+		}
+	}
+	checkModel(t, s, model)
+	checkPages(t, s)
+	if got, _ := s.GetTrash(); len(got) != len(trash) {
+		t.Errorf("Expected %d trashed quotes, got %d", len(trash), len(got))
+	}
+	if n := s.pool.len(); n > 8 {
+		t.Errorf("Buffer pool grew past its limit: %d pages", n)
+	}
+
+	keys := make([]string, 0, len(model))
+	for id := range model {
+		keys = append(keys, id)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	seq, _ := s.Version()
+	var listed []string
+	after := ""
+	for {
+		page, err := s.ListAt(seq, entity.Filter{}, after, 37)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, q := range page {
+			listed = append(listed, q.Id)
+		}
+		after = page[len(page)-1].Id
+	}
+	if !slices.Equal(listed, keys) {
+		t.Errorf("Pages are not in key order:\n%v\n%v", listed, keys)
+	}
+
+	reopened := newStore(t, file.crash())
+	checkModel(t, reopened, model)
+	checkPages(t, reopened)
+	counts, _ := reopened.TagCounts()
+	total := 0
+	for _, c := range counts {
+		total += c.Count
+	}
+	if total != len(model) {
+		t.Errorf("Tag index holds %d entries for %d quotes", total, len(model))
+	}
+}
+
+func TestSnapshotSurvivesPageReuse(t *testing.T) {
+	s := newStore(t, newMemFile())
+	for i := range 200 {
+		s.Set(fmt.Sprint(i), entity.Quote{Phrase: fmt.Sprint("old ", i)})
+	}
+	seq := s.Snapshot(time.Minute)
+	for round := range 5 {
+		for i := range 200 {
+			s.Set(fmt.Sprint(i), entity.Quote{Phrase: fmt.Sprint("new ", round, i)})
+		}
+	}
+	old, err := s.ListAt(seq, entity.Filter{}, "", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(old) != 200 || old[0].Phrase != "old 0" || old[199].Phrase != "old 199" {
+		t.Errorf("Pinned snapshot was overwritten: %d quotes, first %+v", len(old), old[0])
+	}
+	if len(s.pending) == 0 {
+		t.Error("Pages of a pinned snapshot were released")
+	}
+
+	s.pins[seq] = pin{txid: s.pins[seq].txid, root: s.pins[seq].root, expires: time.Now()}
+	s.Set("0", entity.Quote{Phrase: "after expiry"})
+	if len(s.pending) != 0 {
+		t.Errorf("Pages of an expired snapshot are still reserved: %d batches", len(s.pending))
+	}
+	if _, err := s.ListAt(seq, entity.Filter{}, "", 0); !errors.Is(err, repo.ErrSnapshotExpired) {
+		t.Errorf("Expected expired snapshot, got %v", err)
+	}
+	checkPages(t, s)
+}
+
+// TestCrashRecovery tears the workload's writes at every point in turn and
+// checks the file reopens at the last commit that reported success, or at
+// the failed one when its meta page happened to reach the disk whole.
+func TestCrashRecovery(t *testing.T) {
+	workload := func(s *Store, commit func(model map[string]string, ok bool)) {
+		r := rand.New(rand.NewPCG(3, 4))
+		model := make(map[string]string)
+		for range 40 {
+			next := make(map[string]string, len(model))
+			for id, phrase := range model {
+				next[id] = phrase
+			}
+			err := s.Update(func(tx repo.Tx) error {
+				for range 1 + r.IntN(4) {
+					id := fmt.Sprint(r.IntN(30))
+					if r.IntN(4) == 0 {
+						tx.Del(id)
+						delete(next, id)
+						continue
+					}
+					q := tx.Set(id, entity.Quote{Id: id, Phrase: phrase(r, id)})
+					next[id] = q.Phrase
+				}
+				return nil
+			})
+			commit(next, err == nil)
+			if err != nil {
+				return
+			}
+			model = next
+		}
+	}
+
+	probe := newMemFile()
+	workload(newStore(t, probe), func(map[string]string, bool) {})
+	total := len(probe.data)
+	if total < 20*pageSize {
+		t.Fatalf("Workload too small to be interesting: %d bytes", total)
+	}
+
+	for budget := 0; budget < total; budget += 1531 {
+		file := newMemFile()
+		s := newStore(t, file)
+		file.budget = budget
+		var committed, attempted map[string]string
+		workload(s, func(model map[string]string, ok bool) {
+			if ok {
+				committed = model
+			} else {
+				attempted = model
+			}
+		})
+
+		disk := file.crash()
+		reopened, err := New(disk)
+		if err != nil {
+			t.Fatalf("Budget %d: failed to reopen: %v", budget, err)
+		}
+		if version, _ := reopened.Version(); version > s.meta.seq {
+			committed = attempted
+		}
+		checkModel(t, reopened, committed)
+		checkPages(t, reopened)
+		reopened.Set("after", entity.Quote{Phrase: "crash"})
+		checkPages(t, reopened)
+	}
+}
+
+func TestCorruptPages(t *testing.T) {
+	file := newMemFile()
+	s := newStore(t, file)
+	s.Set("1", entity.Quote{Phrase: "first"})
+	s.Set("1", entity.Quote{Phrase: "second"})
+
+	disk := file.crash()
+	slot := int(s.meta.txid%2) * pageSize
+	disk.data[slot+20] ^= 0xff
+	reopened := newStore(t, disk)
+	if got, _ := reopened.Get("1"); got.Phrase != "first" {
+		t.Errorf("Expected fallback to the previous commit, got %+v", got)
+	}
+
+	disk = file.crash()
+	disk.data[int(s.meta.root)*pageSize+100] ^= 0xff
+	if _, err := New(disk); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("Expected corrupt page error, got %v", err)
+	}
+
+	if _, err := New(&memFile{data: make([]byte, 3*pageSize), budget: -1}); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("Expected an unreadable file to be rejected, got %v", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	file := newMemFile()
+	s := newStore(t, file)
+	s.Set("1", entity.Quote{Phrase: "first"})
+	s.SetAuthor("a1", entity.Author{Id: "a1", Name: "Seneca"})
+
+	file.data[int(s.meta.root)*pageSize+100] ^= 0xff
+	s.pool = newPool(defaultPoolSize)
+	if _, err := s.Get("1"); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("Get: expected corrupt page error, got %v", err)
+	}
+	if _, err := s.GetAll(); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("GetAll: expected corrupt page error, got %v", err)
+	}
+	if _, err := s.GetAuthor("a1"); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("GetAuthor: expected corrupt page error, got %v", err)
+	}
+	if _, err := s.Revisions("1"); !errors.Is(err, ErrCorruptPage) {
+		t.Errorf("Revisions: expected corrupt page error, got %v", err)
+	}
+}
+
+func TestKeyTooLarge(t *testing.T) {
+	s := newStore(t, newMemFile())
+	id := strings.Repeat("x", maxKeySize)
+	err := s.Update(func(tx repo.Tx) error {
+		tx.Set(id, entity.Quote{Phrase: "Q"})
+		return nil
+	})
+	if !errors.Is(err, ErrKeyTooLarge) {
+		t.Errorf("Expected key too large, got %v", err)
+	}
+	if version, _ := s.Version(); version != 0 {
+		t.Errorf("Failed write changed the version to %d", version)
+	}
+	checkPages(t, s)
+}
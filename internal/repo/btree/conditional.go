@@ -0,0 +1,71 @@
+package btree
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (s *Store) SetIfAbsent(key string, value entity.Quote) (entity.Quote, error) {
+	err := s.update(func(tx *txn) error {
+		if _, exists := tx.Get(key); exists {
+			return repo.ErrExists
+		}
+		_, trashed, err := tx.quote(prefixTrash, key)
+		if err != nil {
+			return err
+		}
+		if trashed {
+			return repo.ErrExists
+		}
+		value = tx.Set(key, value)
+		return nil
+	})
+	return value, err
+}
+
+func (s *Store) CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error) {
+	err := s.update(func(tx *txn) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		value = tx.Set(key, value)
+		return nil
+	})
+	return value, err
+}
+
+func (s *Store) DeleteIfVersion(key string, expected uint64) error {
+	return s.update(func(tx *txn) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		tx.Del(key)
+		return nil
+	})
+}
+
+func (s *Store) SoftDelIfVersion(key string, expected uint64, at time.Time) error {
+	return s.update(func(tx *txn) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		tx.SoftDel(key, at)
+		return nil
+	})
+}
+
+func checkVersion(tx *txn, key string, expected uint64) error {
+	current, ok, err := tx.quote(prefixQuote, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return repo.ErrNotFound
+	}
+	if current.Version != expected {
+		return repo.ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package btree_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	_ "github.com/paxaf/BrandScoutTest/internal/repo/btree"
+	"github.com/paxaf/BrandScoutTest/internal/repo/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.Store {
+		return open(t, filepath.Join(t.TempDir(), "quotes.db"))
+	})
+	repotest.RunDurable(t, open)
+}
+
+func open(t *testing.T, path string) repo.Store {
+	t.Helper()
+	store, err := repo.Open("btree", repo.Config{Path: path})
+	if err != nil {
+		t.Fatalf("Failed to open btree backend: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestLimitsRefused(t *testing.T) {
+	cfg := repo.Config{Path: filepath.Join(t.TempDir(), "quotes.db"), MaxEntries: 10}
+	if _, err := repo.Open("btree", cfg); err == nil {
+		t.Error("Btree backend accepted STORAGE_MAX_ENTRIES it cannot enforce")
+	}
+}
@@ -0,0 +1,68 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+)
+
+// All tables share one tree; the first byte of a key names the table.
+// Quote ids are stored length-prefixed so the tree orders them the way
+// pagination expects: shorter ids first, then lexically.
+const (
+	prefixQuote    byte = 'q'
+	prefixTrash    byte = 't'
+	prefixTag      byte = 'g'
+	prefixAuthor   byte = 'u'
+	prefixName     byte = 'n'
+	prefixAlias    byte = 'a'
+	prefixPin      byte = 'p'
+	prefixRevision byte = 'r'
+	prefixCounter  byte = 'c'
+)
+
+func idKey(prefix byte, id string) []byte {
+	key := make([]byte, 3, 3+len(id))
+	key[0] = prefix
+	binary.BigEndian.PutUint16(key[1:], uint16(len(id)))
+	return append(key, id...)
+}
+
+func idFromKey(key []byte) string {
+	return string(key[3:])
+}
+
+func nameKey(prefix byte, name string) []byte {
+	return append([]byte{prefix}, name...)
+}
+
+func tagPrefix(tag string) []byte {
+	key := make([]byte, 0, len(tag)+2)
+	key = append(key, prefixTag)
+	key = append(key, tag...)
+	return append(key, 0)
+}
+
+func tagKey(tag, id string) []byte {
+	return append(tagPrefix(tag), id...)
+}
+
+// splitTagKey returns the tag and quote id of an index key.
+func splitTagKey(key []byte) (string, string) {
+	tag, id, _ := bytes.Cut(key[1:], []byte{0})
+	return string(tag), string(id)
+}
+
+func revisionKey(id string, n int) []byte {
+	return binary.BigEndian.AppendUint32(idKey(prefixRevision, id), uint32(n))
+}
+
+func encode(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func decode[T any](raw []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(raw, &value)
+	return value, err
+}
@@ -0,0 +1,117 @@
+package btree
+
+import (
+	"bytes"
+	"slices"
+)
+
+// node is a decoded page. Committed nodes are shared through the buffer
+// pool and never modified; a transaction clones a node before changing it.
+type node struct {
+	leaf     bool
+	keys     [][]byte
+	vals     [][]byte
+	children []pgid
+	overflow []pgid
+}
+
+// child points a branch at a subtree whose smallest key is key.
+type child struct {
+	key []byte
+	id  pgid
+}
+
+func (n *node) clone() *node {
+	return &node{
+		leaf:     n.leaf,
+		keys:     slices.Clone(n.keys),
+		vals:     slices.Clone(n.vals),
+		children: slices.Clone(n.children),
+	}
+}
+
+func (n *node) entrySize(i int) int {
+	key := n.keys[i]
+	if !n.leaf {
+		return 10 + len(key)
+	}
+	if spills(key, n.vals[i]) {
+		return 14 + len(key)
+	}
+	return 6 + len(key) + len(n.vals[i])
+}
+
+func (n *node) size() int {
+	size := pageHeader
+	for i := range n.keys {
+		size += n.entrySize(i)
+	}
+	return size
+}
+
+func (n *node) search(key []byte) (int, bool) {
+	return slices.BinarySearchFunc(n.keys, key, bytes.Compare)
+}
+
+// childIndex picks the subtree that may hold key. Keys smaller than the
+// first separator belong to the leftmost child.
+func (n *node) childIndex(key []byte) int {
+	i, found := n.search(key)
+	if found {
+		return i
+	}
+	return max(i-1, 0)
+}
+
+func (n *node) put(key, value []byte) {
+	i, found := n.search(key)
+	if found {
+		n.vals[i] = value
+		return
+	}
+	n.keys = slices.Insert(n.keys, i, key)
+	n.vals = slices.Insert(n.vals, i, value)
+}
+
+func (n *node) remove(i int) {
+	n.keys = slices.Delete(n.keys, i, i+1)
+	if n.leaf {
+		n.vals = slices.Delete(n.vals, i, i+1)
+	} else {
+		n.children = slices.Delete(n.children, i, i+1)
+	}
+}
+
+func (n *node) replaceChild(i int, parts []child) {
+	keys := make([][]byte, len(parts))
+	ids := make([]pgid, len(parts))
+	for j, part := range parts {
+		keys[j], ids[j] = part.key, part.id
+	}
+	n.keys = slices.Replace(n.keys, i, i+1, keys...)
+	n.children = slices.Replace(n.children, i, i+1, ids...)
+}
+
+// halve splits an oversized node in two halves of roughly equal bytes.
+func (n *node) halve() (*node, *node) {
+	half := n.size() / 2
+	mid, acc := 1, pageHeader
+	for i := range n.keys {
+		acc += n.entrySize(i)
+		if acc >= half {
+			mid = i + 1
+			break
+		}
+	}
+	mid = min(max(mid, 1), len(n.keys)-1)
+	right := &node{leaf: n.leaf, keys: slices.Clone(n.keys[mid:])}
+	n.keys = n.keys[:mid:mid]
+	if n.leaf {
+		right.vals = slices.Clone(n.vals[mid:])
+		n.vals = n.vals[:mid:mid]
+	} else {
+		right.children = slices.Clone(n.children[mid:])
+		n.children = n.children[:mid:mid]
+	}
+	return n, right
+}
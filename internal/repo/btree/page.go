@@ -0,0 +1,229 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+var (
+	ErrCorruptPage = errors.New("page checksum mismatch")
+	ErrKeyTooLarge = errors.New("key too large")
+)
+
+// Every page starts with a crc32 of the rest of the page and a kind byte.
+// Pages 0 and 1 hold two alternating copies of the meta page; a commit
+// only becomes visible once its meta page is written, so a torn write
+// leaves the previous meta and the tree it points to intact.
+const (
+	pageSize   = 4096
+	pageHeader = 10
+	maxKeySize = 1000
+	maxInline  = 1024
+	metaMagic  = 0x51545242
+	firstPage  = 2
+)
+
+const (
+	kindMeta byte = iota + 1
+	kindLeaf
+	kindBranch
+	kindOverflow
+)
+
+type pgid uint64
+
+type meta struct {
+	txid     uint64
+	seq      uint64
+	modified time.Time
+	root     pgid
+	pages    pgid
+}
+
+type pageBuf struct {
+	id   pgid
+	data []byte
+}
+
+func seal(buf []byte) {
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+}
+
+func verify(buf []byte) bool {
+	return binary.LittleEndian.Uint32(buf[0:4]) == crc32.ChecksumIEEE(buf[4:])
+}
+
+func encodeMeta(m meta) []byte {
+	buf := make([]byte, pageSize)
+	buf[4] = kindMeta
+	binary.LittleEndian.PutUint32(buf[8:], metaMagic)
+	binary.LittleEndian.PutUint32(buf[12:], pageSize)
+	binary.LittleEndian.PutUint64(buf[16:], m.txid)
+	binary.LittleEndian.PutUint64(buf[24:], m.seq)
+	if !m.modified.IsZero() {
+		binary.LittleEndian.PutUint64(buf[32:], uint64(m.modified.UnixNano()))
+	}
+	binary.LittleEndian.PutUint64(buf[40:], uint64(m.root))
+	binary.LittleEndian.PutUint64(buf[48:], uint64(m.pages))
+	seal(buf)
+	return buf
+}
+
+func decodeMeta(buf []byte) (meta, error) {
+	if !verify(buf) || buf[4] != kindMeta || binary.LittleEndian.Uint32(buf[8:]) != metaMagic {
+		return meta{}, ErrCorruptPage
+	}
+	if size := binary.LittleEndian.Uint32(buf[12:]); size != pageSize {
+		return meta{}, fmt.Errorf("unsupported page size %d", size)
+	}
+	m := meta{
+		txid:  binary.LittleEndian.Uint64(buf[16:]),
+		seq:   binary.LittleEndian.Uint64(buf[24:]),
+		root:  pgid(binary.LittleEndian.Uint64(buf[40:])),
+		pages: pgid(binary.LittleEndian.Uint64(buf[48:])),
+	}
+	if nanos := binary.LittleEndian.Uint64(buf[32:]); nanos != 0 {
+		m.modified = time.Unix(0, int64(nanos))
+	}
+	return m, nil
+}
+
+// spills reports whether a leaf entry keeps its value in a chain of
+// overflow pages instead of inline.
+func spills(key, value []byte) bool {
+	return len(key)+len(value) > maxInline
+}
+
+// encode lays the node out as a page and, for large values, a chain of
+// overflow pages allocated with alloc. The overflow pages are remembered
+// on the node so they are freed together with it.
+func (n *node) encode(id pgid, alloc func() pgid) []pageBuf {
+	buf := make([]byte, pageSize)
+	res := []pageBuf{{id: id, data: buf}}
+	n.overflow = nil
+	buf[4] = kindBranch
+	if n.leaf {
+		buf[4] = kindLeaf
+	}
+	binary.LittleEndian.PutUint16(buf[8:], uint16(len(n.keys)))
+	off := pageHeader
+	for i, key := range n.keys {
+		binary.LittleEndian.PutUint16(buf[off:], uint16(len(key)))
+		off += 2
+		if !n.leaf {
+			binary.LittleEndian.PutUint64(buf[off:], uint64(n.children[i]))
+			off += 8
+			off += copy(buf[off:], key)
+			continue
+		}
+		value := n.vals[i]
+		binary.LittleEndian.PutUint32(buf[off:], uint32(len(value)))
+		off += 4
+		off += copy(buf[off:], key)
+		if !spills(key, value) {
+			off += copy(buf[off:], value)
+			continue
+		}
+		chain := n.spill(value, alloc)
+		res = append(res, chain...)
+		binary.LittleEndian.PutUint64(buf[off:], uint64(chain[0].id))
+		off += 8
+	}
+	seal(buf)
+	return res
+}
+
+func (n *node) spill(value []byte, alloc func() pgid) []pageBuf {
+	var res []pageBuf
+	for len(value) > 0 {
+		buf := make([]byte, pageSize)
+		buf[4] = kindOverflow
+		chunk := copy(buf[16:], value)
+		value = value[chunk:]
+		id := alloc()
+		n.overflow = append(n.overflow, id)
+		if len(res) > 0 {
+			prev := res[len(res)-1].data
+			binary.LittleEndian.PutUint64(prev[8:], uint64(id))
+			seal(prev)
+		}
+		seal(buf)
+		res = append(res, pageBuf{id: id, data: buf})
+	}
+	return res
+}
+
+func decodeNode(buf []byte, read func(pgid) ([]byte, error)) (*node, error) {
+	if !verify(buf) || (buf[4] != kindLeaf && buf[4] != kindBranch) {
+		return nil, ErrCorruptPage
+	}
+	n := &node{leaf: buf[4] == kindLeaf}
+	count := int(binary.LittleEndian.Uint16(buf[8:]))
+	off := pageHeader
+	for range count {
+		if off+2 > len(buf) {
+			return nil, ErrCorruptPage
+		}
+		klen := int(binary.LittleEndian.Uint16(buf[off:]))
+		off += 2
+		if !n.leaf {
+			if off+8+klen > len(buf) {
+				return nil, ErrCorruptPage
+			}
+			n.children = append(n.children, pgid(binary.LittleEndian.Uint64(buf[off:])))
+			off += 8
+			n.keys = append(n.keys, buf[off:off+klen:off+klen])
+			off += klen
+			continue
+		}
+		if off+4+klen > len(buf) {
+			return nil, ErrCorruptPage
+		}
+		vlen := int(binary.LittleEndian.Uint32(buf[off:]))
+		off += 4
+		key := buf[off : off+klen : off+klen]
+		off += klen
+		n.keys = append(n.keys, key)
+		if klen+vlen <= maxInline {
+			if off+vlen > len(buf) {
+				return nil, ErrCorruptPage
+			}
+			n.vals = append(n.vals, buf[off:off+vlen:off+vlen])
+			off += vlen
+			continue
+		}
+		if off+8 > len(buf) {
+			return nil, ErrCorruptPage
+		}
+		value, err := n.gather(pgid(binary.LittleEndian.Uint64(buf[off:])), vlen, read)
+		if err != nil {
+			return nil, err
+		}
+		n.vals = append(n.vals, value)
+		off += 8
+	}
+	return n, nil
+}
+
+func (n *node) gather(id pgid, size int, read func(pgid) ([]byte, error)) ([]byte, error) {
+	value := make([]byte, 0, size)
+	for len(value) < size {
+		if id < firstPage {
+			return nil, ErrCorruptPage
+		}
+		buf, err := read(id)
+		if err != nil {
+			return nil, err
+		}
+		if buf[4] != kindOverflow {
+			return nil, ErrCorruptPage
+		}
+		n.overflow = append(n.overflow, id)
+		value = append(value, buf[16:16+min(size-len(value), pageSize-16)]...)
+		id = pgid(binary.LittleEndian.Uint64(buf[8:]))
+	}
+	return value, nil
+}
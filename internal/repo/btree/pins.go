@@ -0,0 +1,19 @@
+package btree
+
+func (s *Store) SetPin(date, key string) error {
+	return s.update(func(tx *txn) error {
+		return tx.put(nameKey(prefixPin, date), []byte(key))
+	})
+}
+
+func (s *Store) DelPin(date string) error {
+	return s.delName(prefixPin, date)
+}
+
+func (s *Store) GetPin(date string) (string, error) {
+	return s.getName(prefixPin, date)
+}
+
+func (s *Store) Pins() (map[string]string, error) {
+	return s.names(prefixPin)
+}
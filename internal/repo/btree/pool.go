@@ -0,0 +1,62 @@
+package btree
+
+import (
+	"container/list"
+	"sync"
+)
+
+const defaultPoolSize = 1024
+
+// pool is the buffer pool: an LRU cache of decoded committed pages.
+type pool struct {
+	mutex sync.Mutex
+	limit int
+	items map[pgid]*list.Element
+	order *list.List
+}
+
+type poolEntry struct {
+	id   pgid
+	node *node
+}
+
+func newPool(limit int) *pool {
+	return &pool{
+		limit: limit,
+		items: make(map[pgid]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (p *pool) get(id pgid) (*node, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	elem, ok := p.items[id]
+	if !ok {
+		return nil, false
+	}
+	p.order.MoveToFront(elem)
+	return elem.Value.(*poolEntry).node, true
+}
+
+func (p *pool) put(id pgid, n *node) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if elem, ok := p.items[id]; ok {
+		elem.Value.(*poolEntry).node = n
+		p.order.MoveToFront(elem)
+		return
+	}
+	p.items[id] = p.order.PushFront(&poolEntry{id: id, node: n})
+	for p.limit > 0 && p.order.Len() > p.limit {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.items, oldest.Value.(*poolEntry).id)
+	}
+}
+
+func (p *pool) len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.order.Len()
+}
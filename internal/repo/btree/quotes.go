@@ -0,0 +1,386 @@
+package btree
+
+import (
+	"slices"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (tx *txn) quote(prefix byte, key string) (entity.Quote, bool, error) {
+	raw, ok, err := tx.get(idKey(prefix, key))
+	if err != nil || !ok {
+		return entity.Quote{}, false, err
+	}
+	value, err := decode[entity.Quote](raw)
+	return value, err == nil, err
+}
+
+func (tx *txn) putQuote(prefix byte, key string, value entity.Quote) error {
+	raw, err := encode(value)
+	if err != nil {
+		return err
+	}
+	if err := tx.put(idKey(prefix, key), raw); err != nil {
+		return err
+	}
+	if prefix != prefixQuote {
+		return nil
+	}
+	for _, tag := range value.Tags {
+		if err := tx.put(tagKey(tag, key), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlink removes a quote from the live table, the tag index and the trash.
+func (tx *txn) unlink(key string) error {
+	old, live, err := tx.quote(prefixQuote, key)
+	if err != nil {
+		return err
+	}
+	if live {
+		for _, tag := range old.Tags {
+			if err := tx.del(tagKey(tag, key)); err != nil {
+				return err
+			}
+		}
+		if err := tx.del(idKey(prefixQuote, key)); err != nil {
+			return err
+		}
+	}
+	return tx.del(idKey(prefixTrash, key))
+}
+
+func (tx *txn) Get(key string) (entity.Quote, bool) {
+	value, ok, err := tx.quote(prefixQuote, key)
+	tx.fail(err)
+	return value, ok
+}
+
+func (tx *txn) Set(key string, value entity.Quote) entity.Quote {
+	tx.seq++
+	value.Version = tx.seq
+	value.DeletedAt = time.Time{}
+	tx.fail(tx.unlink(key))
+	tx.fail(tx.putQuote(prefixQuote, key, value))
+	return value
+}
+
+func (tx *txn) Del(key string) {
+	tx.seq++
+	tx.fail(tx.unlink(key))
+	tx.fail(tx.delRevisions(key))
+}
+
+func (tx *txn) SoftDel(key string, at time.Time) bool {
+	value, ok := tx.Get(key)
+	if !ok {
+		return false
+	}
+	tx.seq++
+	value.Version = tx.seq
+	value.DeletedAt = at
+	tx.fail(tx.unlink(key))
+	tx.fail(tx.putQuote(prefixTrash, key, value))
+	return true
+}
+
+func (s *Store) Update(fn func(tx repo.Tx) error) error {
+	return s.update(func(tx *txn) error {
+		return fn(tx)
+	})
+}
+
+func (s *Store) Set(key string, value entity.Quote) (entity.Quote, error) {
+	err := s.update(func(tx *txn) error {
+		value = tx.Set(key, value)
+		return nil
+	})
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) Get(key string) (entity.Quote, error) {
+	var value entity.Quote
+	err := s.view(func(r reader, root pgid) error {
+		var (
+			ok  bool
+			err error
+		)
+		value, ok, err = readQuote(r, root, prefixQuote, key)
+		if err == nil && !ok {
+			err = repo.ErrNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) Del(key string) error {
+	return s.update(func(tx *txn) error {
+		_, live, err := tx.quote(prefixQuote, key)
+		if err != nil {
+			return err
+		}
+		_, trashed, err := tx.quote(prefixTrash, key)
+		if err != nil {
+			return err
+		}
+		if live || trashed {
+			tx.Del(key)
+			return nil
+		}
+		return tx.delRevisions(key)
+	})
+}
+
+func (s *Store) SoftDel(key string, at time.Time) error {
+	return s.update(func(tx *txn) error {
+		if !tx.SoftDel(key, at) {
+			return repo.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Store) Restore(key string) (entity.Quote, error) {
+	var value entity.Quote
+	err := s.update(func(tx *txn) error {
+		var (
+			ok  bool
+			err error
+		)
+		value, ok, err = tx.quote(prefixTrash, key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return repo.ErrNotFound
+		}
+		tx.seq++
+		value.Version = tx.seq
+		value.DeletedAt = time.Time{}
+		tx.fail(tx.unlink(key))
+		tx.fail(tx.putQuote(prefixQuote, key, value))
+		return nil
+	})
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	return value, nil
+}
+
+func (s *Store) GetTrash() ([]entity.Quote, error) {
+	return s.quotes(prefixTrash)
+}
+
+func (s *Store) PurgeBefore(before time.Time) ([]entity.Quote, error) {
+	var purged []entity.Quote
+	err := s.update(func(tx *txn) error {
+		var keys []string
+		err := tx.scanPrefix([]byte{prefixTrash}, func(key, raw []byte) bool {
+			value, err := decode[entity.Quote](raw)
+			if err != nil {
+				tx.fail(err)
+				return false
+			}
+			if value.DeletedAt.Before(before) {
+				keys = append(keys, idFromKey(key))
+				purged = append(purged, value)
+			}
+			return true
+		})
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		tx.seq++
+		for _, key := range keys {
+			tx.fail(tx.del(idKey(prefixTrash, key)))
+			tx.fail(tx.delRevisions(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+func (s *Store) Version() (uint64, time.Time) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.meta.seq, s.meta.modified
+}
+
+func (s *Store) GetAll() ([]entity.Quote, error) {
+	return s.quotes(prefixQuote)
+}
+
+func (s *Store) GetAllByAuthor(author string) ([]entity.Quote, error) {
+	return s.Find(entity.Filter{Author: author})
+}
+
+func (s *Store) GetAllByTags(tags []string, matchAll bool) ([]entity.Quote, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return s.Find(entity.Filter{Tags: tags, AnyTag: !matchAll})
+}
+
+func (s *Store) Find(filter entity.Filter) ([]entity.Quote, error) {
+	var res []entity.Quote
+	err := s.view(func(r reader, root pgid) error {
+		if len(filter.Tags) == 0 {
+			return scanQuotes(r, root, prefixQuote, func(_ string, value entity.Quote) bool {
+				if filter.Match(value) {
+					res = append(res, value)
+				}
+				return true
+			})
+		}
+		keys, err := matchTags(r, root, filter.Tags, !filter.AnyTag)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			value, ok, err := readQuote(r, root, prefixQuote, key)
+			if err != nil {
+				return err
+			}
+			if ok && filter.Match(value) {
+				res = append(res, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Store) GetRandom(opts entity.RandomOptions) (entity.Quote, error) {
+	sampler := entity.NewSampler(opts)
+	err := s.view(func(r reader, root pgid) error {
+		if len(opts.Filter.Tags) == 0 {
+			return scanQuotes(r, root, prefixQuote, func(key string, value entity.Quote) bool {
+				sampler.Consider(key, value)
+				return true
+			})
+		}
+		keys, err := matchTags(r, root, opts.Filter.Tags, !opts.Filter.AnyTag)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			value, ok, err := readQuote(r, root, prefixQuote, key)
+			if err != nil {
+				return err
+			}
+			if ok {
+				sampler.Consider(key, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	value, ok := sampler.Result()
+	if !ok {
+		return entity.Quote{}, repo.ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *Store) TagCounts() ([]entity.TagCount, error) {
+	var res []entity.TagCount
+	err := s.view(func(r reader, root pgid) error {
+		return scanPrefix(r, root, []byte{prefixTag}, func(key, _ []byte) bool {
+			tag, _ := splitTagKey(key)
+			if n := len(res); n > 0 && res[n-1].Tag == tag {
+				res[n-1].Count++
+			} else {
+				res = append(res, entity.TagCount{Tag: tag, Count: 1})
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Store) quotes(prefix byte) ([]entity.Quote, error) {
+	var res []entity.Quote
+	err := s.view(func(r reader, root pgid) error {
+		return scanQuotes(r, root, prefix, func(_ string, value entity.Quote) bool {
+			res = append(res, value)
+			return true
+		})
+	})
+	return res, err
+}
+
+func readQuote(r reader, root pgid, prefix byte, key string) (entity.Quote, bool, error) {
+	raw, ok, err := get(r, root, idKey(prefix, key))
+	if err != nil || !ok {
+		return entity.Quote{}, false, err
+	}
+	value, err := decode[entity.Quote](raw)
+	return value, err == nil, err
+}
+
+func scanQuotes(r reader, root pgid, prefix byte, fn func(key string, value entity.Quote) bool) error {
+	var decodeErr error
+	err := scanPrefix(r, root, []byte{prefix}, func(key, raw []byte) bool {
+		value, err := decode[entity.Quote](raw)
+		if err != nil {
+			decodeErr = err
+			return false
+		}
+		return fn(idFromKey(key), value)
+	})
+	if err != nil {
+		return err
+	}
+	return decodeErr
+}
+
+// matchTags returns the ids of quotes carrying all (or any) of the tags.
+func matchTags(r reader, root pgid, tags []string, matchAll bool) ([]string, error) {
+	tags = slices.Compact(slices.Sorted(slices.Values(tags)))
+	counts := make(map[string]int)
+	var order []string
+	for _, tag := range tags {
+		prefix := tagPrefix(tag)
+		err := scanPrefix(r, root, prefix, func(key, _ []byte) bool {
+			id := string(key[len(prefix):])
+			if counts[id] == 0 {
+				order = append(order, id)
+			}
+			counts[id]++
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := order[:0]
+	for _, id := range order {
+		if !matchAll || counts[id] == len(tags) {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
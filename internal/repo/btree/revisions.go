@@ -0,0 +1,114 @@
+package btree
+
+import (
+	"encoding/binary"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (s *Store) AppendRevision(key string, rev entity.Revision) (entity.Revision, error) {
+	err := s.update(func(tx *txn) error {
+		counter := idKey(prefixCounter, key)
+		raw, ok, err := tx.get(counter)
+		if err != nil {
+			return err
+		}
+		rev.Number = 1
+		if ok {
+			rev.Number = int(binary.BigEndian.Uint64(raw)) + 1
+		}
+		if err := tx.put(counter, binary.BigEndian.AppendUint64(nil, uint64(rev.Number))); err != nil {
+			return err
+		}
+		if raw, err = encode(rev); err != nil {
+			return err
+		}
+		if err := tx.put(revisionKey(key, rev.Number), raw); err != nil {
+			return err
+		}
+		if s.historyCap <= 0 {
+			return nil
+		}
+		keys, err := tx.revisionKeys(key)
+		if err != nil || len(keys) <= s.historyCap {
+			return err
+		}
+		for _, old := range keys[:len(keys)-s.historyCap] {
+			if err := tx.del(old); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return entity.Revision{}, err
+	}
+	return rev, nil
+}
+
+func (s *Store) Revisions(key string) ([]entity.Revision, error) {
+	var (
+		res       []entity.Revision
+		decodeErr error
+	)
+	err := s.view(func(r reader, root pgid) error {
+		return scanPrefix(r, root, idKey(prefixRevision, key), func(_, raw []byte) bool {
+			rev, err := decode[entity.Revision](raw)
+			if err != nil {
+				decodeErr = err
+				return false
+			}
+			res = append(res, rev)
+			return true
+		})
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Store) GetRevision(key string, n int) (entity.Revision, error) {
+	var rev entity.Revision
+	err := s.view(func(r reader, root pgid) error {
+		raw, found, err := get(r, root, revisionKey(key, n))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return repo.ErrNotFound
+		}
+		rev, err = decode[entity.Revision](raw)
+		return err
+	})
+	if err != nil {
+		return entity.Revision{}, err
+	}
+	return rev, nil
+}
+
+func (tx *txn) revisionKeys(key string) ([][]byte, error) {
+	var keys [][]byte
+	err := tx.scanPrefix(idKey(prefixRevision, key), func(key, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys, err
+}
+
+func (tx *txn) delRevisions(key string) error {
+	keys, err := tx.revisionKeys(key)
+	if err != nil {
+		return err
+	}
+	for _, old := range keys {
+		if err := tx.del(old); err != nil {
+			return err
+		}
+	}
+	return tx.del(idKey(prefixCounter, key))
+}
@@ -0,0 +1,66 @@
+package btree
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// Snapshot pins the current root. Copy-on-write keeps every page of a
+// pinned tree in place, so a snapshot costs nothing until it is read.
+func (s *Store) Snapshot(ttl time.Duration) uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.expirePins(now)
+	p, ok := s.pins[s.meta.seq]
+	if !ok {
+		p = pin{txid: s.meta.txid, root: s.meta.root}
+	}
+	if expires := now.Add(ttl); expires.After(p.expires) {
+		p.expires = expires
+	}
+	s.pins[s.meta.seq] = p
+	return s.meta.seq
+}
+
+func (s *Store) ListAt(seq uint64, filter entity.Filter, after string, limit int) ([]entity.Quote, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	root := s.meta.root
+	if seq != s.meta.seq {
+		p, ok := s.pins[seq]
+		if !ok || !time.Now().Before(p.expires) {
+			return nil, repo.ErrSnapshotExpired
+		}
+		root = p.root
+	}
+	start := idKey(prefixQuote, after)
+	var (
+		res       []entity.Quote
+		decodeErr error
+	)
+	err := scan(s, root, start, func(key, raw []byte) bool {
+		if key[0] != prefixQuote {
+			return false
+		}
+		if bytes.Equal(key, start) {
+			return true
+		}
+		value, err := decode[entity.Quote](raw)
+		if err != nil {
+			decodeErr = err
+			return false
+		}
+		if filter.Match(value) {
+			res = append(res, value)
+		}
+		return limit <= 0 || len(res) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, decodeErr
+}
@@ -0,0 +1,320 @@
+// Package btree is an on-disk storage backend: a copy-on-write B+tree of
+// fixed-size checksummed pages with shadow paging for crash safety.
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// File is the storage the tree lives in. *os.File satisfies it; tests
+// substitute files that fail or tear writes.
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
+	Close() error
+}
+
+type Store struct {
+	mutex      sync.RWMutex
+	file       File
+	meta       meta
+	free       []pgid
+	pending    []pendingFree
+	pool       *pool
+	pins       map[uint64]pin
+	historyCap int
+	err        error
+}
+
+// pendingFree holds pages the commit after txid stopped referencing.
+// They stay reserved while a snapshot of txid or older is pinned.
+type pendingFree struct {
+	txid  uint64
+	pages []pgid
+}
+
+type pin struct {
+	txid    uint64
+	root    pgid
+	expires time.Time
+}
+
+type Option func(*Store)
+
+func WithHistoryCap(limit int) Option {
+	return func(s *Store) {
+		s.historyCap = limit
+	}
+}
+
+func WithPoolSize(pages int) Option {
+	return func(s *Store) {
+		s.pool.limit = pages
+	}
+}
+
+func Open(path string, opts ...Option) (*Store, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(file, opts...)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func New(file File, opts ...Option) (*Store, error) {
+	s := &Store{
+		file: file,
+		pool: newPool(defaultPoolSize),
+		pins: make(map[uint64]pin),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+func (s *Store) load() error {
+	found, short := false, false
+	for slot := range 2 {
+		buf := make([]byte, pageSize)
+		if _, err := s.file.ReadAt(buf, int64(slot)*pageSize); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				short = true
+				continue
+			}
+			return err
+		}
+		m, err := decodeMeta(buf)
+		if err != nil {
+			continue
+		}
+		if !found || m.txid > s.meta.txid {
+			s.meta, found = m, true
+		}
+	}
+	if !found {
+		if !short {
+			return fmt.Errorf("%w: no valid meta page", ErrCorruptPage)
+		}
+		return s.init()
+	}
+	reachable, err := s.reachable(s.meta.root)
+	if err != nil {
+		return err
+	}
+	for id := s.meta.pages - 1; id >= firstPage; id-- {
+		if _, ok := reachable[id]; !ok {
+			s.free = append(s.free, id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) init() error {
+	s.meta = meta{pages: firstPage}
+	buf := encodeMeta(s.meta)
+	for slot := range 2 {
+		if _, err := s.file.WriteAt(buf, int64(slot)*pageSize); err != nil {
+			return err
+		}
+	}
+	return s.file.Sync()
+}
+
+// reachable walks the tree under root and returns every page it uses.
+func (s *Store) reachable(root pgid) (map[pgid]struct{}, error) {
+	res := make(map[pgid]struct{})
+	var walk func(id pgid) error
+	mark := func(id pgid) error {
+		if id < firstPage || id >= s.meta.pages {
+			return fmt.Errorf("%w: page %d out of range", ErrCorruptPage, id)
+		}
+		if _, seen := res[id]; seen {
+			return fmt.Errorf("%w: page %d referenced twice", ErrCorruptPage, id)
+		}
+		res[id] = struct{}{}
+		return nil
+	}
+	walk = func(id pgid) error {
+		if err := mark(id); err != nil {
+			return err
+		}
+		n, err := s.node(id)
+		if err != nil {
+			return err
+		}
+		for _, id := range n.overflow {
+			if err := mark(id); err != nil {
+				return err
+			}
+		}
+		for _, id := range n.children {
+			if err := walk(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if root == 0 {
+		return res, nil
+	}
+	return res, walk(root)
+}
+
+func (s *Store) readPage(id pgid) ([]byte, error) {
+	buf := make([]byte, pageSize)
+	if _, err := s.file.ReadAt(buf, int64(id)*pageSize); err != nil {
+		return nil, fmt.Errorf("read page %d: %w", id, err)
+	}
+	if !verify(buf) {
+		return nil, fmt.Errorf("%w: page %d", ErrCorruptPage, id)
+	}
+	return buf, nil
+}
+
+func (s *Store) node(id pgid) (*node, error) {
+	if n, ok := s.pool.get(id); ok {
+		return n, nil
+	}
+	buf, err := s.readPage(id)
+	if err != nil {
+		return nil, err
+	}
+	n, err := decodeNode(buf, s.readPage)
+	if err != nil {
+		return nil, fmt.Errorf("page %d: %w", id, err)
+	}
+	s.pool.put(id, n)
+	return n, nil
+}
+
+func (s *Store) view(fn func(r reader, root pgid) error) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return fn(s, s.meta.root)
+}
+
+// update runs fn in a write transaction and commits it unless fn or one
+// of the writes it made failed.
+func (s *Store) update(fn func(tx *txn) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	tx := &txn{
+		s:     s,
+		root:  s.meta.root,
+		pages: s.meta.pages,
+		seq:   s.meta.seq,
+		dirty: make(map[pgid]*node),
+	}
+	err := fn(tx)
+	if err == nil {
+		err = tx.err
+	}
+	if err == nil {
+		err = s.commit(tx)
+	}
+	if err != nil {
+		s.rollback(tx)
+	}
+	return err
+}
+
+func (s *Store) rollback(tx *txn) {
+	for _, id := range tx.allocated {
+		if id < s.meta.pages {
+			s.free = append(s.free, id)
+		}
+	}
+}
+
+// commit writes the new pages, syncs them and only then publishes the new
+// root through the meta page. A failure before the meta write leaves the
+// file as it was; after it the on-disk state is unknown and the store
+// refuses further writes.
+func (s *Store) commit(tx *txn) error {
+	if tx.root == s.meta.root && tx.seq == s.meta.seq {
+		s.rollback(tx)
+		return nil
+	}
+	pages := tx.pagesToWrite()
+	for _, page := range pages {
+		if _, err := s.file.WriteAt(page.data, int64(page.id)*pageSize); err != nil {
+			return err
+		}
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	now := time.Now()
+	next := meta{
+		txid:     s.meta.txid + 1,
+		seq:      tx.seq,
+		modified: s.meta.modified,
+		root:     tx.root,
+		pages:    tx.pages,
+	}
+	if next.seq != s.meta.seq {
+		next.modified = now
+	}
+	_, err := s.file.WriteAt(encodeMeta(next), int64(next.txid%2)*pageSize)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		s.err = fmt.Errorf("store is read-only after failed commit: %w", err)
+		return err
+	}
+	for id, n := range tx.dirty {
+		s.pool.put(id, n)
+	}
+	s.pending = append(s.pending, pendingFree{txid: s.meta.txid, pages: tx.freed})
+	s.free = append(s.free, tx.spare...)
+	s.meta = next
+	s.expirePins(now)
+	return nil
+}
+
+func (s *Store) expirePins(now time.Time) {
+	for seq, p := range s.pins {
+		if !now.Before(p.expires) {
+			delete(s.pins, seq)
+		}
+	}
+	oldest := uint64(math.MaxUint64)
+	for _, p := range s.pins {
+		oldest = min(oldest, p.txid)
+	}
+	kept := s.pending[:0]
+	for _, pending := range s.pending {
+		if pending.txid < oldest {
+			s.free = append(s.free, pending.pages...)
+			continue
+		}
+		kept = append(kept, pending)
+	}
+	s.pending = kept
+}
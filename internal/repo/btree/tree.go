@@ -0,0 +1,275 @@
+package btree
+
+import (
+	"bytes"
+	"slices"
+)
+
+type reader interface {
+	node(id pgid) (*node, error)
+}
+
+func get(r reader, root pgid, key []byte) ([]byte, bool, error) {
+	id := root
+	for id != 0 {
+		n, err := r.node(id)
+		if err != nil {
+			return nil, false, err
+		}
+		if !n.leaf {
+			id = n.children[n.childIndex(key)]
+			continue
+		}
+		i, found := n.search(key)
+		if !found {
+			return nil, false, nil
+		}
+		return n.vals[i], true, nil
+	}
+	return nil, false, nil
+}
+
+// scan calls fn for every key >= start in order until fn returns false.
+func scan(r reader, root pgid, start []byte, fn func(key, value []byte) bool) error {
+	if root == 0 {
+		return nil
+	}
+	_, err := scanNode(r, root, start, fn)
+	return err
+}
+
+func scanNode(r reader, id pgid, start []byte, fn func(key, value []byte) bool) (bool, error) {
+	n, err := r.node(id)
+	if err != nil {
+		return false, err
+	}
+	if n.leaf {
+		i, _ := n.search(start)
+		for ; i < len(n.keys); i++ {
+			if !fn(n.keys[i], n.vals[i]) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	for i := n.childIndex(start); i < len(n.children); i++ {
+		more, err := scanNode(r, n.children[i], start, fn)
+		if err != nil || !more {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func scanPrefix(r reader, root pgid, prefix []byte, fn func(key, value []byte) bool) error {
+	return scan(r, root, prefix, func(key, value []byte) bool {
+		return bytes.HasPrefix(key, prefix) && fn(key, value)
+	})
+}
+
+// txn is a copy-on-write write transaction. Nodes it touches are cloned
+// onto freshly allocated pages; the pages they replace are only handed
+// back to the free list once no reader can reach them.
+type txn struct {
+	s         *Store
+	root      pgid
+	pages     pgid
+	seq       uint64
+	dirty     map[pgid]*node
+	allocated []pgid
+	spare     []pgid
+	freed     []pgid
+	err       error
+}
+
+func (tx *txn) node(id pgid) (*node, error) {
+	if n, ok := tx.dirty[id]; ok {
+		return n, nil
+	}
+	return tx.s.node(id)
+}
+
+func (tx *txn) fail(err error) {
+	if tx.err == nil {
+		tx.err = err
+	}
+}
+
+func (tx *txn) allocate() pgid {
+	if n := len(tx.spare); n > 0 {
+		id := tx.spare[n-1]
+		tx.spare = tx.spare[:n-1]
+		return id
+	}
+	var id pgid
+	if n := len(tx.s.free); n > 0 {
+		id = tx.s.free[n-1]
+		tx.s.free = tx.s.free[:n-1]
+	} else {
+		id = tx.pages
+		tx.pages++
+	}
+	tx.allocated = append(tx.allocated, id)
+	return id
+}
+
+// discard drops a node the new tree no longer references.
+func (tx *txn) discard(id pgid, n *node) {
+	if _, ok := tx.dirty[id]; ok {
+		delete(tx.dirty, id)
+		tx.spare = append(tx.spare, id)
+		return
+	}
+	tx.freed = append(tx.freed, id)
+	tx.freed = append(tx.freed, n.overflow...)
+}
+
+func (tx *txn) writable(id pgid) (*node, pgid, error) {
+	if n, ok := tx.dirty[id]; ok {
+		return n, id, nil
+	}
+	n, err := tx.s.node(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	tx.discard(id, n)
+	clone := n.clone()
+	id = tx.allocate()
+	tx.dirty[id] = clone
+	return clone, id, nil
+}
+
+func (tx *txn) get(key []byte) ([]byte, bool, error) {
+	return get(tx, tx.root, key)
+}
+
+func (tx *txn) scanPrefix(prefix []byte, fn func(key, value []byte) bool) error {
+	return scanPrefix(tx, tx.root, prefix, fn)
+}
+
+func (tx *txn) put(key, value []byte) error {
+	if len(key) > maxKeySize {
+		return ErrKeyTooLarge
+	}
+	if tx.root == 0 {
+		id := tx.allocate()
+		tx.dirty[id] = &node{leaf: true, keys: [][]byte{key}, vals: [][]byte{value}}
+		tx.root = id
+		return nil
+	}
+	parts, err := tx.insert(tx.root, key, value)
+	if err != nil {
+		return err
+	}
+	return tx.setRoot(parts)
+}
+
+func (tx *txn) del(key []byte) error {
+	if _, ok, err := tx.get(key); err != nil || !ok {
+		return err
+	}
+	parts, err := tx.remove(tx.root, key)
+	if err != nil {
+		return err
+	}
+	return tx.setRoot(parts)
+}
+
+func (tx *txn) insert(id pgid, key, value []byte) ([]child, error) {
+	n, id, err := tx.writable(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.leaf {
+		n.put(key, value)
+		return tx.split(id, n), nil
+	}
+	i := n.childIndex(key)
+	parts, err := tx.insert(n.children[i], key, value)
+	if err != nil {
+		return nil, err
+	}
+	n.replaceChild(i, parts)
+	return tx.split(id, n), nil
+}
+
+// remove deletes a key known to exist. Nodes left empty are dropped; the
+// tree does not rebalance half-empty nodes.
+func (tx *txn) remove(id pgid, key []byte) ([]child, error) {
+	n, id, err := tx.writable(id)
+	if err != nil {
+		return nil, err
+	}
+	if n.leaf {
+		if i, found := n.search(key); found {
+			n.remove(i)
+		}
+	} else {
+		i := n.childIndex(key)
+		parts, err := tx.remove(n.children[i], key)
+		if err != nil {
+			return nil, err
+		}
+		n.replaceChild(i, parts)
+	}
+	if len(n.keys) == 0 {
+		tx.discard(id, n)
+		return nil, nil
+	}
+	return []child{{key: n.keys[0], id: id}}, nil
+}
+
+func (tx *txn) split(id pgid, n *node) []child {
+	if n.size() <= pageSize {
+		return []child{{key: n.keys[0], id: id}}
+	}
+	left, right := n.halve()
+	rightID := tx.allocate()
+	tx.dirty[rightID] = right
+	return append(tx.split(id, left), tx.split(rightID, right)...)
+}
+
+// setRoot grows the tree by a level when the old root split and shrinks
+// it while the root is a branch with a single child.
+func (tx *txn) setRoot(parts []child) error {
+	for len(parts) > 1 {
+		root := &node{}
+		for _, part := range parts {
+			root.keys = append(root.keys, part.key)
+			root.children = append(root.children, part.id)
+		}
+		id := tx.allocate()
+		tx.dirty[id] = root
+		parts = tx.split(id, root)
+	}
+	if len(parts) == 0 {
+		tx.root = 0
+		return nil
+	}
+	tx.root = parts[0].id
+	for {
+		n, err := tx.node(tx.root)
+		if err != nil {
+			return err
+		}
+		if n.leaf || len(n.children) > 1 {
+			return nil
+		}
+		tx.discard(tx.root, n)
+		tx.root = n.children[0]
+	}
+}
+
+// pagesToWrite encodes the dirty nodes in page order.
+func (tx *txn) pagesToWrite() []pageBuf {
+	ids := make([]pgid, 0, len(tx.dirty))
+	for id := range tx.dirty {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var res []pageBuf
+	for _, id := range ids {
+		res = append(res, tx.dirty[id].encode(id, tx.allocate)...)
+	}
+	return res
+}
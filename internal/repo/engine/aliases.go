@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type aliasTable struct {
+	mutex sync.RWMutex
+	data  map[string]string
+}
+
+func newAliasTable() *aliasTable {
+	return &aliasTable{
+		data: make(map[string]string),
+	}
+}
+
+func (t *aliasTable) apply(op walOp) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if op.Kind == opSetAlias {
+		t.data[op.Key] = op.Target
+	} else {
+		delete(t.data, op.Key)
+	}
+}
+
+func (e *Engine) SetAlias(alias, canonical string) error {
+	return e.partition.commitTables(func() ([]walOp, error) {
+		return []walOp{{Kind: opSetAlias, Key: alias, Target: canonical}}, nil
+	})
+}
+
+func (e *Engine) DelAlias(alias string) error {
+	return e.partition.commitTables(func() ([]walOp, error) {
+		if _, err := e.GetAlias(alias); err != nil {
+			return nil, err
+		}
+		return []walOp{{Kind: opDelAlias, Key: alias}}, nil
+	})
+}
+
+func (e *Engine) GetAlias(alias string) (string, error) {
+	e.aliases.mutex.RLock()
+	defer e.aliases.mutex.RUnlock()
+	canonical, ok := e.aliases.data[alias]
+	if !ok {
+		return "", repo.ErrNotFound
+	}
+	return canonical, nil
+}
+
+func (e *Engine) Aliases() (map[string]string, error) {
+	e.aliases.mutex.RLock()
+	defer e.aliases.mutex.RUnlock()
+	res := make(map[string]string, len(e.aliases.data))
+	for alias, canonical := range e.aliases.data {
+		res[alias] = canonical
+	}
+	return res, nil
+}
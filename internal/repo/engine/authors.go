@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type authorTable struct {
+	mutex  sync.RWMutex
+	data   map[string]entity.Author
+	byName map[string]string
+}
+
+func newAuthorTable() *authorTable {
+	return &authorTable{
+		data:   make(map[string]entity.Author),
+		byName: make(map[string]string),
+	}
+}
+
+func (t *authorTable) apply(op walOp) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if old, ok := t.data[op.Key]; ok {
+		delete(t.byName, entity.NormalizeAuthor(old.Name))
+		delete(t.data, op.Key)
+	}
+	if op.Kind == opSetAuthor {
+		t.data[op.Key] = *op.Author
+		t.byName[entity.NormalizeAuthor(op.Author.Name)] = op.Key
+	}
+}
+
+func (e *Engine) SetAuthor(key string, value entity.Author) (entity.Author, error) {
+	err := e.partition.commitTables(func() ([]walOp, error) {
+		return []walOp{{Kind: opSetAuthor, Key: key, Author: &value}}, nil
+	})
+	if err != nil {
+		return entity.Author{}, err
+	}
+	return value, nil
+}
+
+func (e *Engine) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, error) {
+	var existing entity.Author
+	err := e.partition.commitTables(func() ([]walOp, error) {
+		var err error
+		if existing, err = e.GetAuthor(key); err == nil {
+			return nil, repo.ErrExists
+		}
+		if existing, err = e.GetAuthorByName(value.Name); err == nil {
+			return nil, repo.ErrExists
+		}
+		return []walOp{{Kind: opSetAuthor, Key: key, Author: &value}}, nil
+	})
+	if err != nil {
+		return existing, err
+	}
+	return value, nil
+}
+
+func (e *Engine) GetAuthor(key string) (entity.Author, error) {
+	e.authors.mutex.RLock()
+	defer e.authors.mutex.RUnlock()
+	value, ok := e.authors.data[key]
+	if !ok {
+		return entity.Author{}, repo.ErrNotFound
+	}
+	return value, nil
+}
+
+func (e *Engine) GetAuthorByName(name string) (entity.Author, error) {
+	e.authors.mutex.RLock()
+	defer e.authors.mutex.RUnlock()
+	key, ok := e.authors.byName[entity.NormalizeAuthor(name)]
+	if !ok {
+		return entity.Author{}, repo.ErrNotFound
+	}
+	return e.authors.data[key], nil
+}
+
+func (e *Engine) DelAuthor(key string) error {
+	return e.partition.commitTables(func() ([]walOp, error) {
+		if _, err := e.GetAuthor(key); err != nil {
+			return nil, nil
+		}
+		return []walOp{{Kind: opDelAuthor, Key: key}}, nil
+	})
+}
+
+// tableAuthor returns the author stored under key or, failing that, under
+// name. An empty key or name is not looked up.
+func (e *Engine) tableAuthor(key, name string) (entity.Author, bool) {
+	e.authors.mutex.RLock()
+	defer e.authors.mutex.RUnlock()
+	if value, ok := e.authors.data[key]; ok && key != "" {
+		return value, true
+	}
+	if key, ok := e.authors.byName[entity.NormalizeAuthor(name)]; ok && name != "" {
+		return e.authors.data[key], true
+	}
+	return entity.Author{}, false
+}
+
+func (e *Engine) GetAllAuthors() ([]entity.Author, error) {
+	e.authors.mutex.RLock()
+	defer e.authors.mutex.RUnlock()
+	res := make([]entity.Author, 0, len(e.authors.data))
+	for _, value := range e.authors.data {
+		res = append(res, value)
+	}
+	return res, nil
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func init() {
+	repo.Register("memory", func(cfg repo.Config) (repo.Store, error) {
+		return NewEngine(WithHistoryCap(cfg.HistoryCap), WithLimits(limitsFrom(cfg)), WithLogRetention(logRetention(cfg)))
+	})
+	repo.Register("wal", func(cfg repo.Config) (repo.Store, error) {
+		if cfg.Path == "" {
+			return nil, errors.New("wal backend requires a path")
+		}
+		return NewEngine(WithHistoryCap(cfg.HistoryCap), WithLimits(limitsFrom(cfg)), WithLogRetention(logRetention(cfg)), WithWAL(cfg.Path))
+	})
+}
+
+func limitsFrom(cfg repo.Config) Limits {
+	return Limits{MaxEntries: cfg.MaxEntries, MaxBytes: cfg.MaxBytes}
+}
+
+func logRetention(cfg repo.Config) int {
+	if cfg.LogRetention > 0 {
+		return cfg.LogRetention
+	}
+	return defaultLogRetention
+}
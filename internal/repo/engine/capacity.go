@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// Limits caps what the engine holds, live and trashed quotes together.
+// Zero means unlimited. At the limit writes that would grow the engine are
+// refused: it holds the only copy of a quote, so it cannot evict one.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+func WithLimits(limits Limits) Option {
+	return func(e *Engine) {
+		e.partition.limits = limits
+	}
+}
+
+const (
+	quoteOverhead  = 256
+	tagOverhead    = 64
+	sourceOverhead = 64
+)
+
+// quoteSize approximates the memory a stored quote takes: its strings plus
+// a fixed overhead for the struct, the map entry and its index entries.
+func quoteSize(key string, value entity.Quote) int64 {
+	size := quoteOverhead + len(key) + len(value.Id) + len(value.AuthorId) + len(value.Author) +
+		len(value.Phrase) + len(value.Language) + len(value.UpdatedBy)
+	for _, tag := range value.Tags {
+		size += tagOverhead + len(tag)
+	}
+	if source := value.Source; source != nil {
+		size += sourceOverhead + len(source.Kind) + len(source.Title) + len(source.URL)
+	}
+	return int64(size)
+}
+
+func (h *HashTable) exceeds(entries int, bytes int64) bool {
+	return (h.limits.MaxEntries > 0 && entries > h.limits.MaxEntries) ||
+		(h.limits.MaxBytes > 0 && bytes > h.limits.MaxBytes)
+}
+
+func (h *HashTable) sizeOf(key string) (int64, bool) {
+	if value, ok := h.data[key]; ok {
+		return quoteSize(key, value), true
+	}
+	if value, ok := h.trash[key]; ok {
+		return quoteSize(key, value), true
+	}
+	return 0, false
+}
+
+func (h *HashTable) account(key string, value entity.Quote, sign int) {
+	h.entries += sign
+	h.bytes += int64(sign) * quoteSize(key, value)
+}
+
+// projected returns the engine's size after ops were applied.
+func (h *HashTable) projected(ops []walOp) (int, int64) {
+	entries, bytes := h.entries, h.bytes
+	touched := make(map[string]*entity.Quote)
+	for _, op := range ops {
+		if !op.quote() {
+			continue
+		}
+		if value, seen := touched[op.Key]; seen {
+			if value != nil {
+				entries--
+				bytes -= quoteSize(op.Key, *value)
+			}
+		} else if size, ok := h.sizeOf(op.Key); ok {
+			entries--
+			bytes -= size
+		}
+		touched[op.Key] = nil
+		if op.Kind != opDel {
+			entries++
+			bytes += quoteSize(op.Key, *op.Value)
+			touched[op.Key] = op.Value
+		}
+	}
+	return entries, bytes
+}
+
+// admit refuses a commit that would take the engine past its limits.
+// Commits that shrink it are always let through, so an engine already over
+// a lowered limit can still be cleaned up.
+func (h *HashTable) admit(ops []walOp) error {
+	entries, bytes := h.projected(ops)
+	if h.exceeds(entries, bytes) && (entries > h.entries || bytes > h.bytes) {
+		h.rejected++
+		return fmt.Errorf("%w: %d entries, %d bytes", repo.ErrInsufficientStorage, entries, bytes)
+	}
+	return nil
+}
+
+func (h *HashTable) Stats() entity.StorageStats {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return entity.StorageStats{
+		Entries:    h.entries,
+		Bytes:      h.bytes,
+		MaxEntries: h.limits.MaxEntries,
+		MaxBytes:   h.limits.MaxBytes,
+		Rejected:   h.rejected,
+	}
+}
@@ -0,0 +1,65 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+)
+
+func newLimited(t *testing.T, limits storage.Limits) *storage.Engine {
+	t.Helper()
+	engine, err := storage.NewEngine(storage.WithLimits(limits))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	return engine
+}
+
+func TestEntryLimit(t *testing.T) {
+	t.Parallel()
+
+	engine := newLimited(t, storage.Limits{MaxEntries: 2})
+	for _, id := range []string{"1", "2"} {
+		if _, err := engine.SetIfAbsent(id, entity.Quote{Id: id, Phrase: "Q" + id}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, err := engine.SetIfAbsent("3", entity.Quote{Id: "3"}); !errors.Is(err, repo.ErrInsufficientStorage) {
+		t.Errorf("Expected insufficient storage, got %v", err)
+	}
+	if _, err := engine.CompareAndSwap("1", 1, entity.Quote{Id: "1", Phrase: "edited"}); err != nil {
+		t.Errorf("Update at the limit was refused: %v", err)
+	}
+	engine.Del("2")
+	if _, err := engine.SetIfAbsent("3", entity.Quote{Id: "3"}); err != nil {
+		t.Errorf("Insert after a delete was refused: %v", err)
+	}
+
+	stats := engine.Stats()
+	if stats.Entries != 2 || stats.MaxEntries != 2 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestByteLimit(t *testing.T) {
+	t.Parallel()
+
+	engine := newLimited(t, storage.Limits{MaxBytes: 1024})
+	if _, err := engine.SetIfAbsent("1", entity.Quote{Id: "1", Phrase: "short"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	big := entity.Quote{Id: "2", Phrase: string(make([]byte, 2048))}
+	if _, err := engine.SetIfAbsent("2", big); !errors.Is(err, repo.ErrInsufficientStorage) {
+		t.Errorf("Expected insufficient storage, got %v", err)
+	}
+	if stats := engine.Stats(); stats.Bytes <= 0 || stats.Bytes > stats.MaxBytes {
+		t.Errorf("Unexpected byte accounting: %+v", stats)
+	}
+	engine.Del("1")
+	if stats := engine.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Delete was not accounted for: %+v", stats)
+	}
+}
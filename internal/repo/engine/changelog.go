@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const defaultLogRetention = 10000
+
+func WithLogRetention(commits int) Option {
+	return func(e *Engine) {
+		e.partition.changes.limit = commits
+	}
+}
+
+// changelog keeps the latest commits in seq order. base is the seq right
+// before the oldest commit kept: a reader positioned earlier has missed
+// commits. notify is closed and replaced on every commit, so waiters can
+// select on it without holding the table lock.
+type changelog struct {
+	records []walRecord
+	base    uint64
+	limit   int
+	notify  chan struct{}
+}
+
+func newChangelog() changelog {
+	return changelog{limit: defaultLogRetention, notify: make(chan struct{})}
+}
+
+func (c *changelog) add(rec walRecord) {
+	c.records = append(c.records, rec)
+	if drop := len(c.records) - c.limit; c.limit > 0 && drop > 0 {
+		c.base = c.records[drop-1].Seq
+		clear(c.records[:drop])
+		c.records = c.records[drop:]
+	}
+	c.wake()
+}
+
+func (c *changelog) reset(seq uint64) {
+	c.records = nil
+	c.base = seq
+	c.wake()
+}
+
+func (c *changelog) wake() {
+	close(c.notify)
+	c.notify = make(chan struct{})
+}
+
+func (c *changelog) since(seq uint64, limit int) ([]walRecord, bool) {
+	if seq < c.base {
+		return nil, false
+	}
+	i := sort.Search(len(c.records), func(i int) bool { return c.records[i].Seq > seq })
+	end := len(c.records)
+	if limit > 0 && i+limit < end {
+		end = i + limit
+	}
+	return c.records[i:end], true
+}
+
+// newLogID names a new history. The WAL keeps the name across restarts;
+// a table without one starts a new history every time.
+func newLogID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Offset is the position of the latest commit.
+func (h *HashTable) Offset() entity.Offset {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return entity.Offset{Log: h.log, Seq: h.seq}
+}
+
+func (h *HashTable) Checkpoint() entity.Checkpoint {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.checkpoint()
+}
+
+func (h *HashTable) checkpoint() entity.Checkpoint {
+	res := entity.Checkpoint{Log: h.log, Seq: h.seq, Ops: make([]entity.Mutation, 0, len(h.data)+len(h.trash))}
+	for key, value := range h.data {
+		res.Ops = append(res.Ops, entity.Mutation{Kind: entity.MutationSet, Key: key, Quote: &value})
+	}
+	for key, value := range h.trash {
+		res.Ops = append(res.Ops, entity.Mutation{Kind: entity.MutationTrash, Key: key, Quote: &value})
+	}
+	return res
+}
+
+func (h *HashTable) Commits(since entity.Offset, limit int) ([]entity.Commit, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	records, err := h.since(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]entity.Commit, len(records))
+	for i, rec := range records {
+		res[i] = entity.Commit{Seq: rec.Seq, Ops: make([]entity.Mutation, 0, len(rec.Ops))}
+		for _, op := range rec.Ops {
+			res[i].Ops = append(res[i].Ops, op.mutation())
+		}
+	}
+	return res, nil
+}
+
+// Changes reads the changelog as quotes being created, updated and deleted.
+// Restoring a quote from the trash creates it again; purging it from the
+// trash changes nothing visible.
+func (h *HashTable) Changes(since entity.Offset, limit int) ([]entity.Change, entity.Offset, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	records, err := h.since(since, limit)
+	if err != nil {
+		return nil, since, err
+	}
+	var res []entity.Change
+	next := entity.Offset{Log: h.log, Seq: since.Seq}
+	for _, rec := range records {
+		next.Seq = rec.Seq
+		for _, op := range rec.Ops {
+			change := entity.Change{Seq: rec.Seq, Key: op.Key}
+			switch {
+			case op.Kind == opSet && op.live:
+				change.Type = entity.ChangeUpdate
+			case op.Kind == opSet:
+				change.Type = entity.ChangeCreate
+			case op.live:
+				change.Type = entity.ChangeDelete
+			default:
+				continue
+			}
+			if op.Value != nil {
+				value := *op.Value
+				change.Quote = &value
+			}
+			res = append(res, change)
+		}
+	}
+	return res, next, nil
+}
+
+// since returns the commits after offset, which has to be in this log,
+// or at seq 0 of any log.
+func (h *HashTable) since(offset entity.Offset, limit int) ([]walRecord, error) {
+	if offset.Log != h.log && (offset.Log != "" || offset.Seq != 0) {
+		return nil, fmt.Errorf("%w: %s is not in log %s", repo.ErrOffsetOutOfRange, offset, h.log)
+	}
+	records, ok := h.changes.since(offset.Seq, limit)
+	if !ok || offset.Seq > h.seq {
+		return nil, fmt.Errorf("%w: %d is not between %d and %d", repo.ErrOffsetOutOfRange, offset.Seq, h.changes.base, h.seq)
+	}
+	return records, nil
+}
+
+// WaitCommit blocks while the table is still at since. An offset into
+// another log returns at once, for the read after it to refuse.
+func (h *HashTable) WaitCommit(ctx context.Context, since entity.Offset) error {
+	for {
+		h.mutex.RLock()
+		at, notify := entity.Offset{Log: h.log, Seq: h.seq}, h.changes.notify
+		h.mutex.RUnlock()
+		if at.Seq != since.Seq || since.Log != "" && at.Log != since.Log {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// Load replaces everything with a checkpoint and takes its log, so the
+// table goes on with the history it copied. Pinned snapshots are dropped
+// since they no longer describe this history.
+func (h *HashTable) Load(checkpoint entity.Checkpoint) error {
+	return h.load(walRecord{Log: checkpoint.Log, Seq: checkpoint.Seq, Ops: toOps(checkpoint.Ops)})
+}
+
+// load replaces the quotes and the side tables with what rec writes. A
+// record naming no log starts a new one.
+func (h *HashTable) load(rec walRecord) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if rec.Log == "" {
+		rec.Log = newLogID()
+	}
+	if h.wal != nil {
+		if err := h.wal.reset(rec); err != nil {
+			return err
+		}
+	}
+	h.data = make(map[string]entity.Quote)
+	h.trash = make(map[string]entity.Quote)
+	h.tags = make(tagIndex)
+	h.pins = make(map[uint64]time.Time)
+	h.chains = make(map[string][]mvccVersion)
+	h.entries, h.bytes = 0, 0
+	h.tables.resetTables()
+	for _, op := range rec.Ops {
+		h.apply(op)
+	}
+	h.seq = rec.Seq
+	h.log = rec.Log
+	h.modified = time.Now()
+	h.changes.reset(rec.Seq)
+	return nil
+}
+
+// Apply writes a commit read from another table's log. Limits were checked
+// where the commit was made. Commits at or below the current seq were
+// applied before and are skipped.
+func (h *HashTable) Apply(commit entity.Commit) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if commit.Seq <= h.seq {
+		return nil
+	}
+	return h.write(commit.Seq, toOps(commit.Ops))
+}
+
+func (op walOp) mutation() entity.Mutation {
+	return entity.Mutation{Kind: op.Kind, Key: op.Key, Quote: op.Value, Target: op.Target, Author: op.Author, Revision: op.Revision}
+}
+
+func toOps(mutations []entity.Mutation) []walOp {
+	ops := make([]walOp, len(mutations))
+	for i, m := range mutations {
+		ops[i] = walOp{Kind: m.Kind, Key: m.Key, Target: m.Target}
+		if m.Quote != nil {
+			value := *m.Quote
+			ops[i].Value = &value
+		}
+		if m.Author != nil {
+			author := *m.Author
+			ops[i].Author = &author
+		}
+		if m.Revision != nil {
+			rev := *m.Revision
+			ops[i].Revision = &rev
+		}
+	}
+	return ops
+}
@@ -0,0 +1,272 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+)
+
+func sortedQuotes(quotes []entity.Quote, err error) []string {
+	if err != nil {
+		return []string{err.Error()}
+	}
+	res := make([]string, 0, len(quotes))
+	for _, q := range quotes {
+		res = append(res, fmt.Sprintf("%s/%d/%s", q.Id, q.Version, q.Phrase))
+	}
+	slices.Sort(res)
+	return res
+}
+
+func TestCommits(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine(storage.WithLogRetention(3))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	for i := range 5 {
+		id := fmt.Sprint(i)
+		engine.Set(id, entity.Quote{Id: id, Phrase: "Q" + id})
+	}
+
+	at := func(seq uint64) entity.Offset {
+		return entity.Offset{Log: engine.Offset().Log, Seq: seq}
+	}
+	commits, err := engine.Commits(at(3), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Seq != 4 || commits[1].Seq != 5 {
+		t.Fatalf("Unexpected commits: %+v", commits)
+	}
+	if op := commits[1].Ops[0]; op.Kind != entity.MutationSet || op.Key != "4" || op.Quote.Version != 5 {
+		t.Errorf("Unexpected mutation: %+v", op)
+	}
+	if commits, _ := engine.Commits(at(2), 1); len(commits) != 1 || commits[0].Seq != 3 {
+		t.Errorf("Limit was not applied: %+v", commits)
+	}
+	if commits, err := engine.Commits(at(5), 0); err != nil || len(commits) != 0 {
+		t.Errorf("Expected no commits at the head, got %+v %v", commits, err)
+	}
+	if _, err := engine.Commits(at(1), 0); !errors.Is(err, repo.ErrOffsetOutOfRange) {
+		t.Errorf("Expected out of range for a trimmed offset, got %v", err)
+	}
+	if _, err := engine.Commits(at(6), 0); !errors.Is(err, repo.ErrOffsetOutOfRange) {
+		t.Errorf("Expected out of range for an offset ahead of the log, got %v", err)
+	}
+
+	// A store without a WAL starts a new log on every start, with the
+	// same seqs: an offset into the old one must not resume in it.
+	restarted, err := storage.NewEngine(storage.WithLogRetention(3))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	for i := range 5 {
+		id := fmt.Sprint(i)
+		restarted.Set(id, entity.Quote{Id: id})
+	}
+	if _, err := restarted.Commits(at(3), 0); !errors.Is(err, repo.ErrOffsetOutOfRange) {
+		t.Errorf("Expected out of range for an offset into another log, got %v", err)
+	}
+	if _, err := restarted.Commits(entity.Offset{Seq: 3}, 0); !errors.Is(err, repo.ErrOffsetOutOfRange) {
+		t.Errorf("Expected out of range for an offset naming no log, got %v", err)
+	}
+}
+
+func TestWaitCommit(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := engine.WaitCommit(ctx, engine.Offset()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to time out, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.WaitCommit(context.Background(), entity.Offset{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	engine.Set("1", entity.Quote{Id: "1"})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Commit did not wake the waiter")
+	}
+}
+
+func TestFollowLog(t *testing.T) {
+	t.Parallel()
+
+	leader, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	leader.Set("1", entity.Quote{Id: "1", Phrase: "Q1", Tags: []string{"a"}})
+	leader.Set("2", entity.Quote{Id: "2", Phrase: "Q2"})
+	leader.SoftDel("2", time.Now())
+	leader.SetAuthor("a1", entity.Author{Id: "a1", Name: "Seneca"})
+	leader.SetAlias("lucius annaeus seneca", "Seneca")
+
+	path := filepath.Join(t.TempDir(), "replica.wal")
+	follower, err := storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	follower.Set("stale", entity.Quote{Id: "stale"})
+	if err := follower.Load(leader.Checkpoint()); err != nil {
+		t.Fatalf("Failed to load checkpoint: %v", err)
+	}
+	if _, err := follower.Get("stale"); err == nil {
+		t.Error("Checkpoint did not replace the old state")
+	}
+
+	if got, want := follower.Offset(), leader.Offset(); got != want {
+		t.Errorf("Follower at %s did not take the leader's log at %s", got, want)
+	}
+	leader.Set("3", entity.Quote{Id: "3", Phrase: "Q3", Tags: []string{"a"}})
+	leader.Restore("2")
+	leader.Del("1")
+	leader.SetPin("2026-01-01", "3")
+	leader.AppendRevision("3", entity.Revision{Quote: entity.Quote{Id: "3", Phrase: "Q3 draft"}})
+	commits, err := leader.Commits(follower.Offset(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, commit := range commits {
+		if err := follower.Apply(commit); err != nil {
+			t.Fatalf("Failed to apply commit: %v", err)
+		}
+	}
+	if err := follower.Apply(commits[0]); err != nil {
+		t.Errorf("Replayed commit was not skipped: %v", err)
+	}
+
+	check := func(follower *storage.Engine) {
+		t.Helper()
+		if got, want := sortedQuotes(follower.GetAll()), sortedQuotes(leader.GetAll()); !slices.Equal(got, want) {
+			t.Errorf("Follower diverged:\n%v\n%v", got, want)
+		}
+		got, _ := follower.TagCounts()
+		want, _ := leader.TagCounts()
+		if !slices.Equal(got, want) {
+			t.Errorf("Tag index diverged: %v %v", got, want)
+		}
+		if got, _ := follower.Version(); got != commits[len(commits)-1].Seq {
+			t.Errorf("Follower is at seq %d", got)
+		}
+		if author, err := follower.GetAuthorByName("seneca"); err != nil || author.Id != "a1" {
+			t.Errorf("Author was not replicated: %+v %v", author, err)
+		}
+		if canonical, err := follower.GetAlias("lucius annaeus seneca"); err != nil || canonical != "Seneca" {
+			t.Errorf("Alias was not replicated: %q %v", canonical, err)
+		}
+		if key, err := follower.GetPin("2026-01-01"); err != nil || key != "3" {
+			t.Errorf("Pin was not replicated: %q %v", key, err)
+		}
+		if revs, _ := follower.Revisions("3"); len(revs) != 1 || revs[0].Quote.Phrase != "Q3 draft" {
+			t.Errorf("Revisions were not replicated: %+v", revs)
+		}
+	}
+	check(follower)
+
+	follower.Close()
+	reopened, err := storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to reopen follower: %v", err)
+	}
+	defer reopened.Close()
+	check(reopened)
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "changes.wal")
+	engine, err := storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1"})
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1 edited"})
+	err = engine.Update(func(tx repo.Tx) error {
+		tx.Set("2", entity.Quote{Id: "2", Phrase: "Q2"})
+		tx.SoftDel("1", time.Now())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	engine.Restore("1")
+	engine.SoftDel("2", time.Now())
+	engine.PurgeBefore(time.Now().Add(time.Hour))
+	engine.Del("1")
+
+	describe := func(changes []entity.Change) []string {
+		res := make([]string, 0, len(changes))
+		for _, change := range changes {
+			res = append(res, fmt.Sprintf("%d %s %s", change.Seq, change.Type, change.Key))
+		}
+		return res
+	}
+	want := []string{
+		"1 create 1", "2 update 1",
+		"4 create 2", "4 delete 1",
+		"5 create 1", "6 delete 2",
+		"8 delete 1",
+	}
+	at := func(seq uint64) entity.Offset {
+		return entity.Offset{Log: engine.Offset().Log, Seq: seq}
+	}
+	changes, next, err := engine.Changes(entity.Offset{}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := describe(changes); !slices.Equal(got, want) {
+		t.Errorf("Unexpected changes:\n%v\n%v", got, want)
+	}
+	if next != at(8) {
+		t.Errorf("Expected to resume from 8, got %s", next)
+	}
+	if changes[3].Quote == nil || changes[3].Quote.DeletedAt.IsZero() {
+		t.Errorf("Soft delete did not carry the trashed quote: %+v", changes[3])
+	}
+	if changes, next, _ := engine.Changes(at(6), 1); len(changes) != 0 || next != at(7) {
+		t.Errorf("Purge from the trash should only move the position: %v %s", describe(changes), next)
+	}
+
+	// Offsets survive a restart: the log is replayed with the same seqs
+	// and keeps its id.
+	engine.Close()
+	reopened, err := storage.NewEngine(storage.WithWAL(path), storage.WithLogRetention(3))
+	if err != nil {
+		t.Fatalf("Failed to reopen engine: %v", err)
+	}
+	defer reopened.Close()
+	changes, _, err = reopened.Changes(at(5), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error after reopen: %v", err)
+	}
+	if got := describe(changes); !slices.Equal(got, want[5:]) {
+		t.Errorf("Unexpected changes after reopen:\n%v\n%v", got, want[5:])
+	}
+	if _, _, err := reopened.Changes(at(2), 0); !errors.Is(err, repo.ErrOffsetOutOfRange) {
+		t.Errorf("Expected an offset behind the retention to be out of range, got %v", err)
+	}
+}
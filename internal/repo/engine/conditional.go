@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (h *HashTable) SetIfAbsent(key string, value entity.Quote) (entity.Quote, error) {
+	err := h.Update(func(tx repo.Tx) error {
+		if _, exists := tx.Get(key); exists {
+			return repo.ErrExists
+		}
+		if _, trashed := h.trash[key]; trashed {
+			return repo.ErrExists
+		}
+		value = tx.Set(key, value)
+		return nil
+	})
+	return value, err
+}
+
+func (h *HashTable) CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error) {
+	err := h.Update(func(tx repo.Tx) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		value = tx.Set(key, value)
+		return nil
+	})
+	return value, err
+}
+
+func (h *HashTable) DeleteIfVersion(key string, expected uint64) error {
+	return h.Update(func(tx repo.Tx) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		tx.Del(key)
+		return nil
+	})
+}
+
+func (h *HashTable) SoftDelIfVersion(key string, expected uint64, at time.Time) error {
+	return h.Update(func(tx repo.Tx) error {
+		if err := checkVersion(tx, key, expected); err != nil {
+			return err
+		}
+		tx.SoftDel(key, at)
+		return nil
+	})
+}
+
+func checkVersion(tx repo.Tx, key string, expected uint64) error {
+	current, ok := tx.Get(key)
+	if !ok {
+		return repo.ErrNotFound
+	}
+	if current.Version != expected {
+		return repo.ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+)
+
+func TestConditionalWrites(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+
+	first, err := engine.SetIfAbsent("1", entity.Quote{Id: "1", Phrase: "Q1"})
+	if err != nil || first.Version == 0 {
+		t.Fatalf("Expected insert, got %+v %v", first, err)
+	}
+	if _, err := engine.SetIfAbsent("1", entity.Quote{Id: "1", Phrase: "other"}); !errors.Is(err, repo.ErrExists) {
+		t.Errorf("Expected existing key, got %v", err)
+	}
+
+	if _, err := engine.CompareAndSwap("1", first.Version+1, entity.Quote{Id: "1", Phrase: "stale"}); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	second, err := engine.CompareAndSwap("1", first.Version, entity.Quote{Id: "1", Phrase: "Q1 edited"})
+	if err != nil || second.Version <= first.Version {
+		t.Fatalf("Unexpected swap result: %+v %v", second, err)
+	}
+	if _, err := engine.CompareAndSwap("2", 1, entity.Quote{Id: "2"}); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	if err := engine.SoftDelIfVersion("1", first.Version, time.Now()); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	if err := engine.SoftDelIfVersion("1", second.Version, time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := engine.SetIfAbsent("1", entity.Quote{Id: "1"}); !errors.Is(err, repo.ErrExists) {
+		t.Errorf("SetIfAbsent reused the key of a trashed quote: %v", err)
+	}
+
+	third, _ := engine.SetIfAbsent("3", entity.Quote{Id: "3"})
+	if err := engine.DeleteIfVersion("3", third.Version+1); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	if err := engine.DeleteIfVersion("3", third.Version); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := engine.Get("3"); err == nil {
+		t.Error("Quote was not deleted")
+	}
+}
@@ -0,0 +1,35 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	_ "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/repo/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repo.Store {
+			return open(t, "memory", repo.Config{})
+		})
+	})
+	t.Run("wal", func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repo.Store {
+			return open(t, "wal", repo.Config{Path: t.TempDir() + "/quotes.wal"})
+		})
+		repotest.RunDurable(t, func(t *testing.T, path string) repo.Store {
+			return open(t, "wal", repo.Config{Path: path})
+		})
+	})
+}
+
+func open(t *testing.T, backend string, cfg repo.Config) repo.Store {
+	t.Helper()
+	store, err := repo.Open(backend, cfg)
+	if err != nil {
+		t.Fatalf("Failed to open %s backend: %v", backend, err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"slices"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+// Dump copies the whole engine, quotes and side tables alike.
+func (e *Engine) Dump() entity.StoreDump {
+	e.partition.mutex.RLock()
+	defer e.partition.mutex.RUnlock()
+	// Reads from memory cannot fail.
+	authors, _ := e.GetAllAuthors()
+	aliases, _ := e.Aliases()
+	pins, _ := e.Pins()
+	dump := entity.StoreDump{
+		Quotes:    e.partition.checkpoint(),
+		Authors:   authors,
+		Aliases:   aliases,
+		Pins:      pins,
+		Revisions: make(map[string][]entity.Revision),
+	}
+	e.revisions.mutex.RLock()
+	defer e.revisions.mutex.RUnlock()
+	for key, revs := range e.revisions.data {
+		dump.Revisions[key] = slices.Clone(revs)
+	}
+	return dump
+}
+
+// LoadDump replaces the whole engine with dump in a single commit.
+func (e *Engine) LoadDump(dump entity.StoreDump) error {
+	ops := append(toOps(dump.Quotes.Ops), tableOps(dump)...)
+	return e.partition.load(walRecord{Log: dump.Quotes.Log, Seq: dump.Quotes.Seq, Ops: ops})
+}
+
+// tableOps are the ops that write the side tables of dump.
+func tableOps(dump entity.StoreDump) []walOp {
+	var ops []walOp
+	for _, author := range dump.Authors {
+		ops = append(ops, walOp{Kind: opSetAuthor, Key: author.Id, Author: &author})
+	}
+	for alias, canonical := range dump.Aliases {
+		ops = append(ops, walOp{Kind: opSetAlias, Key: alias, Target: canonical})
+	}
+	for date, key := range dump.Pins {
+		ops = append(ops, walOp{Kind: opSetPin, Key: date, Target: key})
+	}
+	for key, revs := range dump.Revisions {
+		for _, rev := range revs {
+			ops = append(ops, walOp{Kind: opRevision, Key: key, Revision: &rev})
+		}
+	}
+	return ops
+}
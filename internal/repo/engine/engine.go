@@ -1,63 +1,241 @@
 package storage
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
 )
 
 type Engine struct {
 	partition *HashTable
+	aliases   *aliasTable
+	authors   *authorTable
+	pins      *pinTable
+	revisions *revisionTable
+	walPath   string
 }
 
-func NewEngine() (*Engine, error) {
+type Option func(*Engine)
+
+func WithHistoryCap(limit int) Option {
+	return func(e *Engine) {
+		e.revisions.limit = limit
+	}
+}
+
+func WithWAL(path string) Option {
+	return func(e *Engine) {
+		e.walPath = path
+	}
+}
+
+func NewEngine(opts ...Option) (*Engine, error) {
 	engine := &Engine{
 		partition: NewHashTable(),
+		aliases:   newAliasTable(),
+		authors:   newAuthorTable(),
+		pins:      newPinTable(),
+		revisions: newRevisionTable(0),
+	}
+	engine.partition.tables = engine
+	for _, opt := range opts {
+		opt(engine)
+	}
+	if engine.walPath != "" {
+		wal, err := openWAL(engine.walPath, engine.partition.log, engine.partition.replay)
+		if err != nil {
+			return nil, err
+		}
+		engine.partition.wal = wal
+		engine.partition.log = wal.log
 	}
-	engine.partition = NewHashTable()
 	return engine, nil
 }
 
-func (e *Engine) Set(key string, value entity.Quote) {
-	e.partition.Set(key, value)
+func (e *Engine) applyTable(op walOp) {
+	switch op.Kind {
+	case opSetAuthor, opDelAuthor:
+		e.authors.apply(op)
+	case opSetAlias, opDelAlias:
+		e.aliases.apply(op)
+	case opSetPin, opDelPin:
+		e.pins.apply(op)
+	case opRevision, opDelRevisions:
+		e.revisions.apply(op)
+	}
+}
+
+func (e *Engine) resetTables() {
+	e.authors.mutex.Lock()
+	e.authors.data = make(map[string]entity.Author)
+	e.authors.byName = make(map[string]string)
+	e.authors.mutex.Unlock()
+
+	e.aliases.mutex.Lock()
+	e.aliases.data = make(map[string]string)
+	e.aliases.mutex.Unlock()
+
+	e.pins.mutex.Lock()
+	e.pins.data = make(map[string]string)
+	e.pins.mutex.Unlock()
+
+	e.revisions.mutex.Lock()
+	e.revisions.data = make(map[string][]entity.Revision)
+	e.revisions.next = make(map[string]int)
+	e.revisions.mutex.Unlock()
+}
+
+// Checkpoint is the dump as one list of mutations, so a replica loading
+// it gets the side tables along with the quotes.
+func (e *Engine) Checkpoint() entity.Checkpoint {
+	dump := e.Dump()
+	res := dump.Quotes
+	for _, op := range tableOps(dump) {
+		res.Ops = append(res.Ops, op.mutation())
+	}
+	return res
+}
+
+func (e *Engine) Offset() entity.Offset {
+	return e.partition.Offset()
+}
+
+func (e *Engine) Commits(since entity.Offset, limit int) ([]entity.Commit, error) {
+	return e.partition.Commits(since, limit)
+}
+
+func (e *Engine) Changes(since entity.Offset, limit int) ([]entity.Change, entity.Offset, error) {
+	return e.partition.Changes(since, limit)
+}
+
+func (e *Engine) WaitCommit(ctx context.Context, since entity.Offset) error {
+	return e.partition.WaitCommit(ctx, since)
+}
+
+func (e *Engine) Load(checkpoint entity.Checkpoint) error {
+	return e.partition.Load(checkpoint)
+}
+
+func (e *Engine) Apply(commit entity.Commit) error {
+	return e.partition.Apply(commit)
+}
+
+func (e *Engine) Stats() entity.StorageStats {
+	return e.partition.Stats()
+}
+
+func (e *Engine) Close() error {
+	if e.partition.wal == nil {
+		return nil
+	}
+	return e.partition.wal.close()
+}
+
+func (e *Engine) Set(key string, value entity.Quote) (entity.Quote, error) {
+	value, err := e.partition.Set(key, value)
+	if err != nil {
+		return entity.Quote{}, err
+	}
 	log.Println("succeseful set query")
+	return value, nil
 }
 
-func (e *Engine) Get(key string) (entity.Quote, bool) {
+func (e *Engine) Get(key string) (entity.Quote, error) {
 	value, found := e.partition.Get(key)
+	if !found {
+		return entity.Quote{}, repo.ErrNotFound
+	}
 	log.Println("succesefull get query")
-	return value, found
+	return value, nil
 }
 
-func (e *Engine) Del(key string) {
-	e.partition.Del(key)
+func (e *Engine) Del(key string) error {
+	if err := e.partition.Del(key); err != nil {
+		return err
+	}
 	log.Println("succesefull delete query")
+	return nil
 }
 
-func (e *Engine) GetAllByAuthor(author string) ([]entity.Quote, bool) {
-	var res []entity.Quote
-	for _, val := range e.partition.data {
-		if val.Author == author {
-			res = append(res, val)
-		}
-	}
-	if len(res) < 1 {
-		return nil, false
-	}
-	return res, true
+func (e *Engine) SetIfAbsent(key string, value entity.Quote) (entity.Quote, error) {
+	return e.partition.SetIfAbsent(key, value)
 }
 
-func (e *Engine) GetRandom() (entity.Quote, bool) {
-	for _, val := range e.partition.data {
-		return val, true
+func (e *Engine) CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error) {
+	return e.partition.CompareAndSwap(key, expected, value)
+}
+
+func (e *Engine) DeleteIfVersion(key string, expected uint64) error {
+	return e.partition.DeleteIfVersion(key, expected)
+}
+
+func (e *Engine) SoftDelIfVersion(key string, expected uint64, at time.Time) error {
+	return e.partition.SoftDelIfVersion(key, expected, at)
+}
+
+func (e *Engine) GetAllByAuthor(author string) ([]entity.Quote, error) {
+	return e.partition.Find(entity.Filter{Author: author}), nil
+}
+
+func (e *Engine) GetRandom(opts entity.RandomOptions) (entity.Quote, error) {
+	value, found := e.partition.Random(opts)
+	if !found {
+		return entity.Quote{}, repo.ErrNotFound
 	}
-	return entity.Quote{}, false
+	return value, nil
+}
+
+func (e *Engine) GetAll() ([]entity.Quote, error) {
+	return e.partition.All(), nil
+}
+
+func (e *Engine) Snapshot(ttl time.Duration) uint64 {
+	return e.partition.Snapshot(ttl)
 }
 
-func (e *Engine) GetAll() []entity.Quote {
-	var res []entity.Quote
-	for _, val := range e.partition.data {
-		res = append(res, val)
+func (e *Engine) ListAt(seq uint64, filter entity.Filter, after string, limit int) ([]entity.Quote, error) {
+	return e.partition.ListAt(seq, filter, after, limit)
+}
+
+func (e *Engine) Version() (uint64, time.Time) {
+	return e.partition.Version()
+}
+
+func (e *Engine) GetAllByTags(tags []string, matchAll bool) ([]entity.Quote, error) {
+	return e.partition.GetByTags(tags, matchAll), nil
+}
+
+func (e *Engine) TagCounts() ([]entity.TagCount, error) {
+	return e.partition.TagCounts(), nil
+}
+
+func (e *Engine) Find(filter entity.Filter) ([]entity.Quote, error) {
+	return e.partition.Find(filter), nil
+}
+
+func (e *Engine) SoftDel(key string, at time.Time) error {
+	if err := e.partition.SoftDel(key, at); err != nil {
+		return err
 	}
-	return res
+	log.Println("succesefull soft delete query")
+	return nil
+}
+
+func (e *Engine) Restore(key string) (entity.Quote, error) {
+	return e.partition.Restore(key)
+}
+
+func (e *Engine) Update(fn func(tx repo.Tx) error) error {
+	return e.partition.Update(fn)
+}
+
+func (e *Engine) GetTrash() ([]entity.Quote, error) {
+	return e.partition.GetTrash(), nil
+}
+
+func (e *Engine) PurgeBefore(before time.Time) ([]entity.Quote, error) {
+	return e.partition.PurgeBefore(before)
 }
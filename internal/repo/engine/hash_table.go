@@ -2,31 +2,144 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
 )
 
 type HashTable struct {
-	mutex sync.RWMutex
-	data  map[string]entity.Quote
+	mutex    sync.RWMutex
+	data     map[string]entity.Quote
+	trash    map[string]entity.Quote
+	tags     tagIndex
+	seq      uint64
+	log      string
+	modified time.Time
+	wal      *wal
+	pins     map[uint64]time.Time
+	chains   map[string][]mvccVersion
+	limits   Limits
+	entries  int
+	bytes    int64
+	rejected uint64
+	changes  changelog
+	tables   tables
+}
+
+// tables is what an owner keeps next to the quotes. Its ops go through the
+// table's commits, so they share the WAL, its order and its replay.
+type tables interface {
+	applyTable(op walOp)
+	resetTables()
+	tableAuthor(key, name string) (entity.Author, bool)
+}
+
+type noTables struct{}
+
+func (noTables) applyTable(walOp) {}
+
+func (noTables) resetTables() {}
+
+func (noTables) tableAuthor(string, string) (entity.Author, bool) {
+	return entity.Author{}, false
 }
 
 func NewHashTable() *HashTable {
 	return &HashTable{
-		data: make(map[string]entity.Quote),
+		data:    make(map[string]entity.Quote),
+		trash:   make(map[string]entity.Quote),
+		tags:    make(tagIndex),
+		pins:    make(map[uint64]time.Time),
+		chains:  make(map[string][]mvccVersion),
+		changes: newChangelog(),
+		log:     newLogID(),
+		tables:  noTables{},
+	}
+}
+
+func (h *HashTable) Set(key string, value entity.Quote) (entity.Quote, error) {
+	err := h.Update(func(tx repo.Tx) error {
+		value = tx.Set(key, value)
+		return nil
+	})
+	if err != nil {
+		return entity.Quote{}, err
 	}
+	return value, nil
 }
 
-func (h *HashTable) Set(key string, value entity.Quote) {
+func (h *HashTable) Del(key string) error {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	h.data[key] = value
+	_, live := h.data[key]
+	_, trashed := h.trash[key]
+	if !live && !trashed {
+		return nil
+	}
+	return h.commit(h.seq+1, []walOp{{Kind: opDel, Key: key}, {Kind: opDelRevisions, Key: key}})
+}
+
+func (h *HashTable) SoftDel(key string, at time.Time) error {
+	return h.Update(func(tx repo.Tx) error {
+		if !tx.SoftDel(key, at) {
+			return repo.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (h *HashTable) Restore(key string) (entity.Quote, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	value, ok := h.trash[key]
+	if !ok {
+		return entity.Quote{}, repo.ErrNotFound
+	}
+	value.Version = h.seq + 1
+	value.DeletedAt = time.Time{}
+	if err := h.commit(value.Version, []walOp{{Kind: opSet, Key: key, Value: &value}}); err != nil {
+		return entity.Quote{}, err
+	}
+	return value, nil
+}
+
+func (h *HashTable) GetTrash() []entity.Quote {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	res := make([]entity.Quote, 0, len(h.trash))
+	for _, value := range h.trash {
+		res = append(res, value)
+	}
+	return res
 }
 
-func (h *HashTable) Del(key string) {
+func (h *HashTable) PurgeBefore(before time.Time) ([]entity.Quote, error) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	delete(h.data, key)
+	var (
+		purged []entity.Quote
+		ops    []walOp
+	)
+	for key, value := range h.trash {
+		if value.DeletedAt.Before(before) {
+			purged = append(purged, value)
+			ops = append(ops, walOp{Kind: opDel, Key: key}, walOp{Kind: opDelRevisions, Key: key})
+		}
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	if err := h.commit(h.seq+1, ops); err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+func (h *HashTable) Version() (uint64, time.Time) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.seq, h.modified
 }
 
 func (h *HashTable) Get(key string) (entity.Quote, bool) {
@@ -35,3 +148,40 @@ func (h *HashTable) Get(key string) (entity.Quote, bool) {
 	value, found := h.data[key]
 	return value, found
 }
+
+func (h *HashTable) GetByTags(tags []string, matchAll bool) []entity.Quote {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	keys := h.tags.match(tags, matchAll)
+	res := make([]entity.Quote, 0, len(keys))
+	for key := range keys {
+		res = append(res, h.data[key])
+	}
+	return res
+}
+
+func (h *HashTable) TagCounts() []entity.TagCount {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.tags.counts()
+}
+
+func (h *HashTable) Find(filter entity.Filter) []entity.Quote {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var res []entity.Quote
+	if len(filter.Tags) > 0 {
+		for key := range h.tags.match(filter.Tags, !filter.AnyTag) {
+			if value := h.data[key]; filter.Match(value) {
+				res = append(res, value)
+			}
+		}
+		return res
+	}
+	for _, value := range h.data {
+		if filter.Match(value) {
+			res = append(res, value)
+		}
+	}
+	return res
+}
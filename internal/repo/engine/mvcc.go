@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// mvccVersion is one committed state of a key. Chains are only kept for keys
+// written while a snapshot is pinned; every other key is read from data.
+type mvccVersion struct {
+	seq   uint64
+	value entity.Quote
+	live  bool
+}
+
+func (h *HashTable) All() []entity.Quote {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	res := make([]entity.Quote, 0, len(h.data))
+	for _, value := range h.data {
+		res = append(res, value)
+	}
+	return res
+}
+
+func (h *HashTable) Snapshot(ttl time.Duration) uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	now := time.Now()
+	h.expirePins(now)
+	if expires := now.Add(ttl); expires.After(h.pins[h.seq]) {
+		h.pins[h.seq] = expires
+	}
+	return h.seq
+}
+
+func (h *HashTable) ListAt(seq uint64, filter entity.Filter, after string, limit int) ([]entity.Quote, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if seq != h.seq && !time.Now().Before(h.pins[seq]) {
+		return nil, repo.ErrSnapshotExpired
+	}
+	var res []entity.Quote
+	consider := func(key string, value entity.Quote) {
+		if compareKeys(key, after) > 0 && filter.Match(value) {
+			res = append(res, value)
+		}
+	}
+	for key, value := range h.data {
+		if _, ok := h.chains[key]; !ok {
+			consider(key, value)
+		}
+	}
+	for key, chain := range h.chains {
+		if v, ok := visibleAt(chain, seq); ok && v.live {
+			consider(key, v.value)
+		}
+	}
+	slices.SortFunc(res, func(a, b entity.Quote) int { return compareKeys(a.Id, b.Id) })
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// track records the state a key is about to get. Called before op is
+// applied, so data still holds the state older snapshots must see.
+func (h *HashTable) track(seq uint64, op walOp) {
+	if len(h.pins) == 0 {
+		return
+	}
+	chain, ok := h.chains[op.Key]
+	if !ok {
+		old, live := h.data[op.Key]
+		chain = []mvccVersion{{value: old, live: live}}
+	}
+	next := mvccVersion{seq: seq, live: op.Kind == opSet}
+	if next.live {
+		next.value = *op.Value
+	}
+	h.chains[op.Key] = append(chain, next)
+}
+
+func (h *HashTable) expirePins(now time.Time) {
+	expired := false
+	for seq, expires := range h.pins {
+		if !now.Before(expires) {
+			delete(h.pins, seq)
+			expired = true
+		}
+	}
+	if expired {
+		h.collect()
+	}
+}
+
+// collect drops versions no pinned snapshot can see any more.
+func (h *HashTable) collect() {
+	if len(h.pins) == 0 {
+		clear(h.chains)
+		return
+	}
+	oldest := h.seq
+	for seq := range h.pins {
+		oldest = min(oldest, seq)
+	}
+	for key, chain := range h.chains {
+		i, _ := slices.BinarySearchFunc(chain, oldest+1, func(v mvccVersion, seq uint64) int {
+			return compareSeq(v.seq, seq)
+		})
+		chain = chain[max(i-1, 0):]
+		if len(chain) == 1 {
+			delete(h.chains, key)
+			continue
+		}
+		h.chains[key] = slices.Clone(chain)
+	}
+}
+
+func visibleAt(chain []mvccVersion, seq uint64) (mvccVersion, bool) {
+	i, _ := slices.BinarySearchFunc(chain, seq+1, func(v mvccVersion, seq uint64) int {
+		return compareSeq(v.seq, seq)
+	})
+	if i == 0 {
+		return mvccVersion{}, false
+	}
+	return chain[i-1], true
+}
+
+func compareSeq(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareKeys(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
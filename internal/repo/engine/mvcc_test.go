@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func TestSnapshotReads(t *testing.T) {
+	t.Parallel()
+
+	h := NewHashTable()
+	for _, key := range []string{"1", "2", "3"} {
+		h.Set(key, entity.Quote{Id: key, Phrase: "Q" + key})
+	}
+	seq := h.Snapshot(time.Minute)
+
+	h.Set("2", entity.Quote{Id: "2", Phrase: "edited"})
+	h.SoftDel("3", time.Now())
+	h.Set("4", entity.Quote{Id: "4", Phrase: "Q4"})
+
+	old, err := h.ListAt(seq, entity.Filter{}, "", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(old) != 3 || old[1].Phrase != "Q2" || old[2].Id != "3" {
+		t.Errorf("Snapshot sees later writes: %+v", old)
+	}
+	page, _ := h.ListAt(seq, entity.Filter{}, "1", 1)
+	if len(page) != 1 || page[0].Id != "2" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+	current, _ := h.ListAt(h.seq, entity.Filter{}, "", 0)
+	if len(current) != 3 || current[1].Phrase != "edited" || current[2].Id != "4" {
+		t.Errorf("Unexpected current view: %+v", current)
+	}
+}
+
+func TestSnapshotExpiry(t *testing.T) {
+	t.Parallel()
+
+	h := NewHashTable()
+	h.Set("1", entity.Quote{Id: "1"})
+	seq := h.Snapshot(time.Millisecond)
+	h.Set("1", entity.Quote{Id: "1", Phrase: "edited"})
+	if len(h.chains) == 0 {
+		t.Fatal("Write under a pinned snapshot kept no old version")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := h.ListAt(seq, entity.Filter{}, "", 0); !errors.Is(err, repo.ErrSnapshotExpired) {
+		t.Errorf("Expected expired snapshot, got %v", err)
+	}
+	h.Set("2", entity.Quote{Id: "2"})
+	if len(h.pins) != 0 || len(h.chains) != 0 {
+		t.Errorf("Old versions were not collected: %d pins, %d chains", len(h.pins), len(h.chains))
+	}
+}
+
+func TestSnapshotCollectKeepsPinnedVersions(t *testing.T) {
+	t.Parallel()
+
+	h := NewHashTable()
+	h.Set("1", entity.Quote{Id: "1", Phrase: "v1"})
+	short := h.Snapshot(time.Millisecond)
+	h.Set("1", entity.Quote{Id: "1", Phrase: "v2"})
+	long := h.Snapshot(time.Minute)
+	h.Set("1", entity.Quote{Id: "1", Phrase: "v3"})
+
+	time.Sleep(5 * time.Millisecond)
+	h.Set("2", entity.Quote{Id: "2"})
+	if _, err := h.ListAt(short, entity.Filter{}, "", 0); !errors.Is(err, repo.ErrSnapshotExpired) {
+		t.Errorf("Expected expired snapshot, got %v", err)
+	}
+	quotes, err := h.ListAt(long, entity.Filter{}, "", 0)
+	if err != nil || len(quotes) != 1 || quotes[0].Phrase != "v2" {
+		t.Errorf("Pinned snapshot lost its version: %+v %v", quotes, err)
+	}
+	if chain := h.chains["1"]; len(chain) != 2 {
+		t.Errorf("Expected 2 versions after collection, got %d", len(chain))
+	}
+}
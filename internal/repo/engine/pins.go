@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type pinTable struct {
+	mutex sync.RWMutex
+	data  map[string]string
+}
+
+func newPinTable() *pinTable {
+	return &pinTable{
+		data: make(map[string]string),
+	}
+}
+
+func (t *pinTable) apply(op walOp) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if op.Kind == opSetPin {
+		t.data[op.Key] = op.Target
+	} else {
+		delete(t.data, op.Key)
+	}
+}
+
+func (e *Engine) SetPin(date, key string) error {
+	return e.partition.commitTables(func() ([]walOp, error) {
+		return []walOp{{Kind: opSetPin, Key: date, Target: key}}, nil
+	})
+}
+
+func (e *Engine) DelPin(date string) error {
+	return e.partition.commitTables(func() ([]walOp, error) {
+		if _, err := e.GetPin(date); err != nil {
+			return nil, err
+		}
+		return []walOp{{Kind: opDelPin, Key: date}}, nil
+	})
+}
+
+func (e *Engine) GetPin(date string) (string, error) {
+	e.pins.mutex.RLock()
+	defer e.pins.mutex.RUnlock()
+	key, ok := e.pins.data[date]
+	if !ok {
+		return "", repo.ErrNotFound
+	}
+	return key, nil
+}
+
+func (e *Engine) Pins() (map[string]string, error) {
+	e.pins.mutex.RLock()
+	defer e.pins.mutex.RUnlock()
+	res := make(map[string]string, len(e.pins.data))
+	for date, key := range e.pins.data {
+		res[date] = key
+	}
+	return res, nil
+}
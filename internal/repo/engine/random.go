@@ -0,0 +1,19 @@
+package storage
+
+import "github.com/paxaf/BrandScoutTest/internal/entity"
+
+func (h *HashTable) Random(opts entity.RandomOptions) (entity.Quote, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	sampler := entity.NewSampler(opts)
+	if len(opts.Filter.Tags) > 0 {
+		for key := range h.tags.match(opts.Filter.Tags, !opts.Filter.AnyTag) {
+			sampler.Consider(key, h.data[key])
+		}
+	} else {
+		for key, value := range h.data {
+			sampler.Consider(key, value)
+		}
+	}
+	return sampler.Result()
+}
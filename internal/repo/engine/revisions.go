@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type revisionTable struct {
+	mutex sync.RWMutex
+	data  map[string][]entity.Revision
+	next  map[string]int
+	limit int
+}
+
+func newRevisionTable(limit int) *revisionTable {
+	return &revisionTable{
+		data:  make(map[string][]entity.Revision),
+		next:  make(map[string]int),
+		limit: limit,
+	}
+}
+
+func (t *revisionTable) apply(op walOp) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if op.Kind == opDelRevisions {
+		delete(t.data, op.Key)
+		delete(t.next, op.Key)
+		return
+	}
+	t.next[op.Key] = op.Revision.Number
+	revs := append(t.data[op.Key], *op.Revision)
+	if t.limit > 0 && len(revs) > t.limit {
+		revs = slices.Clone(revs[len(revs)-t.limit:])
+	}
+	t.data[op.Key] = revs
+}
+
+func (e *Engine) AppendRevision(key string, rev entity.Revision) (entity.Revision, error) {
+	err := e.partition.commitTables(func() ([]walOp, error) {
+		e.revisions.mutex.RLock()
+		rev.Number = e.revisions.next[key] + 1
+		e.revisions.mutex.RUnlock()
+		return []walOp{{Kind: opRevision, Key: key, Revision: &rev}}, nil
+	})
+	if err != nil {
+		return entity.Revision{}, err
+	}
+	return rev, nil
+}
+
+func (e *Engine) Revisions(key string) ([]entity.Revision, error) {
+	e.revisions.mutex.RLock()
+	defer e.revisions.mutex.RUnlock()
+	return slices.Clone(e.revisions.data[key]), nil
+}
+
+func (e *Engine) GetRevision(key string, n int) (entity.Revision, error) {
+	e.revisions.mutex.RLock()
+	defer e.revisions.mutex.RUnlock()
+	revs := e.revisions.data[key]
+	i, ok := slices.BinarySearchFunc(revs, n, func(rev entity.Revision, n int) int { return rev.Number - n })
+	if !ok {
+		return entity.Revision{}, repo.ErrNotFound
+	}
+	return revs[i], nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import "github.com/paxaf/BrandScoutTest/internal/entity"
+
+type tagIndex map[string]map[string]struct{}
+
+func (t tagIndex) add(key string, tags []string) {
+	for _, tag := range tags {
+		keys, ok := t[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			t[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+func (t tagIndex) remove(key string, tags []string) {
+	for _, tag := range tags {
+		keys := t[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(t, tag)
+		}
+	}
+}
+
+func (t tagIndex) match(tags []string, matchAll bool) map[string]struct{} {
+	res := make(map[string]struct{})
+	if len(tags) == 0 {
+		return res
+	}
+	if !matchAll {
+		for _, tag := range tags {
+			for key := range t[tag] {
+				res[key] = struct{}{}
+			}
+		}
+		return res
+	}
+	smallest := t[tags[0]]
+	for _, tag := range tags[1:] {
+		if len(t[tag]) < len(smallest) {
+			smallest = t[tag]
+		}
+	}
+	for key := range smallest {
+		found := true
+		for _, tag := range tags {
+			if _, ok := t[tag][key]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			res[key] = struct{}{}
+		}
+	}
+	return res
+}
+
+func (t tagIndex) counts() []entity.TagCount {
+	res := make([]entity.TagCount, 0, len(t))
+	for tag, keys := range t {
+		res = append(res, entity.TagCount{Tag: tag, Count: len(keys)})
+	}
+	return res
+}
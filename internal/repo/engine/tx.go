@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type hashTx struct {
+	h       *HashTable
+	seq     uint64
+	view    map[string]*entity.Quote
+	authors map[string]entity.Author
+	ops     []walOp
+}
+
+func (h *HashTable) Update(fn func(tx repo.Tx) error) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	tx := &hashTx{
+		h:       h,
+		seq:     h.seq,
+		view:    make(map[string]*entity.Quote),
+		authors: make(map[string]entity.Author),
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+	// Side-table ops take no version, but the commit still needs a seq.
+	return h.commit(max(tx.seq, h.seq+1), tx.ops)
+}
+
+func (t *hashTx) Get(key string) (entity.Quote, bool) {
+	if value, ok := t.view[key]; ok {
+		if value == nil {
+			return entity.Quote{}, false
+		}
+		return *value, true
+	}
+	value, ok := t.h.data[key]
+	return value, ok
+}
+
+func (t *hashTx) Set(key string, value entity.Quote) entity.Quote {
+	t.seq++
+	value.Version = t.seq
+	value.DeletedAt = time.Time{}
+	t.view[key] = &value
+	t.ops = append(t.ops, walOp{Kind: opSet, Key: key, Value: &value})
+	return value
+}
+
+func (t *hashTx) Del(key string) {
+	t.seq++
+	t.view[key] = nil
+	t.ops = append(t.ops, walOp{Kind: opDel, Key: key}, walOp{Kind: opDelRevisions, Key: key})
+}
+
+func (t *hashTx) SoftDel(key string, at time.Time) bool {
+	value, ok := t.Get(key)
+	if !ok {
+		return false
+	}
+	t.seq++
+	value.Version = t.seq
+	value.DeletedAt = at
+	t.view[key] = nil
+	t.ops = append(t.ops, walOp{Kind: opTrash, Key: key, Value: &value})
+	return true
+}
+
+func (t *hashTx) GetAuthorByName(name string) (entity.Author, bool) {
+	for _, author := range t.authors {
+		if entity.NormalizeAuthor(author.Name) == entity.NormalizeAuthor(name) {
+			return author, true
+		}
+	}
+	return t.h.tables.tableAuthor("", name)
+}
+
+func (t *hashTx) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, bool) {
+	if existing, ok := t.authors[key]; ok {
+		return existing, false
+	}
+	if existing, ok := t.GetAuthorByName(value.Name); ok {
+		return existing, false
+	}
+	if existing, ok := t.h.tables.tableAuthor(key, ""); ok {
+		return existing, false
+	}
+	t.authors[key] = value
+	t.ops = append(t.ops, walOp{Kind: opSetAuthor, Key: key, Author: &value})
+	return value, true
+}
+
+func (h *HashTable) commit(seq uint64, ops []walOp) error {
+	if err := h.admit(ops); err != nil {
+		return err
+	}
+	return h.write(seq, ops)
+}
+
+func (h *HashTable) write(seq uint64, ops []walOp) error {
+	rec := walRecord{Seq: seq, Ops: ops}
+	if h.wal != nil {
+		if err := h.wal.append(rec); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	h.expirePins(now)
+	for i, op := range ops {
+		if op.quote() {
+			_, ops[i].live = h.data[op.Key]
+			h.track(seq, op)
+		}
+		h.apply(op)
+	}
+	h.seq = seq
+	h.modified = now
+	h.changes.add(rec)
+	return nil
+}
+
+// commitTables writes side-table ops as a commit of their own. build runs
+// under the table lock, so what it checks holds when the ops are written.
+func (h *HashTable) commitTables(build func() ([]walOp, error)) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	ops, err := build()
+	if err != nil || len(ops) == 0 {
+		return err
+	}
+	return h.write(h.seq+1, ops)
+}
+
+func (h *HashTable) apply(op walOp) {
+	if !op.quote() {
+		h.tables.applyTable(op)
+		return
+	}
+	if old, ok := h.data[op.Key]; ok {
+		h.tags.remove(op.Key, old.Tags)
+		delete(h.data, op.Key)
+		h.account(op.Key, old, -1)
+	}
+	if old, ok := h.trash[op.Key]; ok {
+		delete(h.trash, op.Key)
+		h.account(op.Key, old, -1)
+	}
+	switch op.Kind {
+	case opSet:
+		h.data[op.Key] = *op.Value
+		h.tags.add(op.Key, op.Value.Tags)
+	case opTrash:
+		h.trash[op.Key] = *op.Value
+	default:
+		return
+	}
+	h.account(op.Key, *op.Value, 1)
+}
+
+func (h *HashTable) replay(rec walRecord) {
+	for i, op := range rec.Ops {
+		if op.quote() {
+			_, rec.Ops[i].live = h.data[op.Key]
+		}
+		h.apply(op)
+	}
+	h.seq = rec.Seq
+	h.modified = time.Now()
+	h.changes.add(rec)
+}
@@ -0,0 +1,178 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+)
+
+func TestUpdateRollback(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1", Tags: []string{"a"}})
+	version, _ := engine.Version()
+
+	errAbort := errors.New("abort")
+	err = engine.Update(func(tx repo.Tx) error {
+		tx.Set("2", entity.Quote{Id: "2", Phrase: "Q2"})
+		tx.SoftDel("1", time.Now())
+		if _, ok := tx.Get("1"); ok {
+			t.Error("Soft deleted quote is visible inside the transaction")
+		}
+		if _, ok := tx.Get("2"); !ok {
+			t.Error("Written quote is not visible inside the transaction")
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected abort error, got %v", err)
+	}
+	if _, err := engine.Get("2"); err == nil {
+		t.Error("Rolled back write is visible")
+	}
+	if _, err := engine.Get("1"); err != nil {
+		t.Error("Rolled back delete is visible")
+	}
+	if after, _ := engine.Version(); after != version {
+		t.Errorf("Version changed after rollback: %d -> %d", version, after)
+	}
+}
+
+func TestWALReplay(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotes.wal")
+	engine, err := storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1", Tags: []string{"a"}})
+	err = engine.Update(func(tx repo.Tx) error {
+		tx.Set("2", entity.Quote{Id: "2", Phrase: "Q2", Tags: []string{"a"}})
+		tx.Set("3", entity.Quote{Id: "3", Phrase: "Q3"})
+		tx.SoftDel("1", time.Now())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	engine.Del("3")
+	version, _ := engine.Version()
+	engine.Close()
+
+	// Simulate a crash in the middle of the next append.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open wal: %v", err)
+	}
+	file.WriteString(`0badc0de {"seq":99,"ops":[{"op":"del","key":"2"`)
+	file.Close()
+
+	engine, err = storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to replay wal: %v", err)
+	}
+	defer engine.Close()
+	if got, _ := engine.Version(); got != version {
+		t.Errorf("Expected version %d after replay, got %d", version, got)
+	}
+	if _, err := engine.Get("2"); err != nil {
+		t.Error("Committed quote was lost")
+	}
+	if _, err := engine.Get("3"); err == nil {
+		t.Error("Deleted quote was resurrected")
+	}
+	if trash, _ := engine.GetTrash(); len(trash) != 1 || trash[0].Id != "1" {
+		t.Errorf("Unexpected trash after replay: %+v", trash)
+	}
+	if quotes, _ := engine.GetAllByTags([]string{"a"}, true); len(quotes) != 1 {
+		t.Errorf("Tag index was not rebuilt: %+v", quotes)
+	}
+}
+
+func TestWALCorruption(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotes.wal")
+	engine, err := storage.NewEngine(storage.WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1"})
+	engine.Set("2", entity.Quote{Id: "2", Phrase: "Q2"})
+	engine.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read wal: %v", err)
+	}
+	data[20] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write wal: %v", err)
+	}
+	if _, err := storage.NewEngine(storage.WithWAL(path)); !errors.Is(err, storage.ErrCorruptWAL) {
+		t.Errorf("Expected corruption error, got %v", err)
+	}
+}
+
+func TestWALSideTables(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotes.wal")
+	engine, err := storage.NewEngine(storage.WithWAL(path), storage.WithHistoryCap(2))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	engine.SetAuthor("1", entity.Author{Id: "1", Name: "Seneca"})
+	engine.SetAuthor("2", entity.Author{Id: "2", Name: "Cicero"})
+	engine.DelAuthor("2")
+	engine.SetAlias("lucius annaeus seneca", "Seneca")
+	engine.SetAlias("tully", "Cicero")
+	engine.DelAlias("tully")
+	engine.SetPin("2026-01-01", "1")
+	for _, phrase := range []string{"v1", "v2", "v3"} {
+		engine.AppendRevision("1", entity.Revision{Quote: entity.Quote{Id: "1", Phrase: phrase}})
+	}
+	engine.AppendRevision("2", entity.Revision{Quote: entity.Quote{Id: "2", Phrase: "Q2"}})
+	engine.Set("2", entity.Quote{Id: "2", Phrase: "Q2"})
+	engine.Del("2")
+	engine.Close()
+
+	engine, err = storage.NewEngine(storage.WithWAL(path), storage.WithHistoryCap(2))
+	if err != nil {
+		t.Fatalf("Failed to replay wal: %v", err)
+	}
+	defer engine.Close()
+	if author, err := engine.GetAuthorByName("seneca"); err != nil || author.Id != "1" {
+		t.Errorf("Author was lost: %+v %v", author, err)
+	}
+	if _, err := engine.GetAuthor("2"); err == nil {
+		t.Error("Deleted author was resurrected")
+	}
+	if aliases, _ := engine.Aliases(); len(aliases) != 1 || aliases["lucius annaeus seneca"] != "Seneca" {
+		t.Errorf("Unexpected aliases after replay: %v", aliases)
+	}
+	if key, err := engine.GetPin("2026-01-01"); err != nil || key != "1" {
+		t.Errorf("Pin was lost: %q %v", key, err)
+	}
+	revs, _ := engine.Revisions("1")
+	if len(revs) != 2 || revs[0].Number != 2 || revs[1].Quote.Phrase != "v3" {
+		t.Errorf("Unexpected revisions after replay: %+v", revs)
+	}
+	if rev, err := engine.AppendRevision("1", entity.Revision{}); err != nil || rev.Number != 4 {
+		t.Errorf("Revision numbers restarted after replay: %+v %v", rev, err)
+	}
+	if revs, _ := engine.Revisions("2"); len(revs) != 0 {
+		t.Errorf("Revisions of a deleted quote were replayed: %+v", revs)
+	}
+}
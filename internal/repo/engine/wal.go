@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/recordlog"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+var ErrCorruptWAL = errors.New("write-ahead log is corrupt")
+
+const (
+	opSet   = "set"
+	opDel   = "del"
+	opTrash = "trash"
+
+	// Side-table ops. Key is the author id, the alias, the pin date or the
+	// quote id the revisions belong to.
+	opSetAuthor    = "set_author"
+	opDelAuthor    = "del_author"
+	opSetAlias     = "set_alias"
+	opDelAlias     = "del_alias"
+	opSetPin       = "set_pin"
+	opDelPin       = "del_pin"
+	opRevision     = "revision"
+	opDelRevisions = "del_revisions"
+)
+
+type walOp struct {
+	Kind  string        `json:"op"`
+	Key   string        `json:"key"`
+	Value *entity.Quote `json:"value,omitempty"`
+	// Target is the canonical author of an alias or the quote of a pin.
+	Target   string           `json:"target,omitempty"`
+	Author   *entity.Author   `json:"author,omitempty"`
+	Revision *entity.Revision `json:"revision,omitempty"`
+	// live records whether the key was live before the op. It is not
+	// logged: replay recomputes it in the same order.
+	live bool
+}
+
+// quote reports whether op writes a quote rather than a side table.
+func (op walOp) quote() bool {
+	switch op.Kind {
+	case opSet, opDel, opTrash:
+		return true
+	}
+	return false
+}
+
+// walRecord is one commit. The first record of a file also carries Log,
+// the id of the log, so offsets into it survive a restart. A later record
+// with a log only names the log of a file written without one.
+type walRecord struct {
+	Log string  `json:"log,omitempty"`
+	Seq uint64  `json:"seq"`
+	Ops []walOp `json:"ops"`
+}
+
+// wal appends records at size, the end of the last one that committed. err
+// is set once a failed append could not be cut off; the log then refuses
+// appends, since what it holds no longer matches what callers were told.
+type wal struct {
+	path string
+	file *os.File
+	size int64
+	log  string
+	err  error
+}
+
+// openWAL replays the file at path. The log keeps the id the file names,
+// or takes log when the file names none.
+func openWAL(path, log string, replay func(walRecord)) (*wal, error) {
+	var (
+		first = true
+		named bool
+		seq   uint64
+	)
+	file, size, err := recordlog.Open(path, ErrCorruptWAL, func(rec walRecord) {
+		if rec.Log != "" {
+			log, named = rec.Log, true
+		}
+		if first || rec.Log == "" {
+			replay(rec)
+		}
+		first = false
+		seq = rec.Seq
+	})
+	if err != nil {
+		return nil, err
+	}
+	w := &wal{path: path, file: file, size: size, log: log}
+	if size > 0 && !named {
+		if err := w.append(walRecord{Log: log, Seq: seq}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *wal) append(rec walRecord) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.size == 0 {
+		rec.Log = w.log
+	}
+	line, err := recordlog.Encode(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(line); err != nil {
+		return w.rollback(err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return w.rollback(err)
+	}
+	w.size += int64(len(line))
+	return nil
+}
+
+// rollback cuts off what a failed append may have written, so the record
+// is not replayed on the next open after the caller was told it failed.
+func (w *wal) rollback(err error) error {
+	cut := w.file.Truncate(w.size)
+	if cut == nil {
+		_, cut = w.file.Seek(w.size, io.SeekStart)
+	}
+	if cut == nil {
+		cut = w.file.Sync()
+	}
+	if cut != nil {
+		w.err = fmt.Errorf("%w: write-ahead log failed: %w", repo.ErrUnavailable, errors.Join(err, cut))
+		return w.err
+	}
+	return err
+}
+
+// reset replaces the log with a single record, which names the new log.
+func (w *wal) reset(rec walRecord) error {
+	line, err := recordlog.Encode(rec)
+	if err != nil {
+		return err
+	}
+	file, err := recordlog.Replace(w.path, line)
+	if err != nil {
+		return err
+	}
+	w.file.Close()
+	w.file = file
+	w.size = int64(len(line))
+	w.log = rec.Log
+	w.err = nil
+	return nil
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/recordlog"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func TestWALFailedAppend(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotes.wal")
+	engine, err := NewEngine(WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	if _, err := engine.Set("1", entity.Quote{Id: "1", Phrase: "Q1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	version, _ := engine.Version()
+
+	// A log that can neither be written nor cut back must stop taking
+	// writes rather than leave an unknown tail behind.
+	engine.partition.wal.file.Close()
+	if _, err := engine.Set("2", entity.Quote{Id: "2", Phrase: "Q2"}); !errors.Is(err, repo.ErrUnavailable) {
+		t.Fatalf("Expected unavailable, got %v", err)
+	}
+	if err := engine.SetPin("2026-01-01", "1"); !errors.Is(err, repo.ErrUnavailable) {
+		t.Errorf("Expected the failure to stick, got %v", err)
+	}
+	if _, err := engine.Get("2"); err == nil {
+		t.Error("Failed write is visible")
+	}
+	if after, _ := engine.Version(); after != version {
+		t.Errorf("Failed write changed the version: %d -> %d", version, after)
+	}
+
+	engine, err = NewEngine(WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to replay wal: %v", err)
+	}
+	defer engine.Close()
+	if _, err := engine.Get("1"); err != nil {
+		t.Error("Committed quote was lost")
+	}
+	if _, err := engine.Get("2"); err == nil {
+		t.Error("Failed write was replayed")
+	}
+}
+
+func TestWALNamesLog(t *testing.T) {
+	t.Parallel()
+
+	// A log written before logs had ids gets one, and keeps it.
+	path := filepath.Join(t.TempDir(), "quotes.wal")
+	line, err := recordlog.Encode(walRecord{Seq: 1, Ops: []walOp{{Kind: opSet, Key: "1", Value: &entity.Quote{Id: "1", Version: 1}}}})
+	if err != nil {
+		t.Fatalf("Failed to encode record: %v", err)
+	}
+	if err := os.WriteFile(path, line, 0o600); err != nil {
+		t.Fatalf("Failed to write wal: %v", err)
+	}
+	engine, err := NewEngine(WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to replay wal: %v", err)
+	}
+	first := engine.Offset()
+	engine.Set("2", entity.Quote{Id: "2"})
+	engine.Close()
+
+	engine, err = NewEngine(WithWAL(path))
+	if err != nil {
+		t.Fatalf("Failed to replay wal: %v", err)
+	}
+	defer engine.Close()
+	if got := engine.Offset(); first.Log == "" || got.Log != first.Log || got.Seq != 2 {
+		t.Errorf("Reopened log at %s, want log %q at seq 2", got, first.Log)
+	}
+	if quotes, _ := engine.GetAll(); len(quotes) != 2 {
+		t.Errorf("Replayed %d quotes, want 2", len(quotes))
+	}
+}
@@ -1,12 +1,131 @@
 package repo
 
-import "github.com/paxaf/BrandScoutTest/internal/entity"
+import (
+	"context"
+	"errors"
+	"time"
 
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+var (
+	ErrNotFound            = errors.New("key not found")
+	ErrExists              = errors.New("key already exists")
+	ErrVersionMismatch     = errors.New("version mismatch")
+	ErrSnapshotExpired     = errors.New("snapshot expired")
+	ErrAuditTampered       = errors.New("audit log integrity check failed")
+	ErrInsufficientStorage = errors.New("storage capacity exceeded")
+	ErrOffsetOutOfRange    = errors.New("log offset out of range")
+	ErrUnavailable         = errors.New("storage unavailable")
+)
+
+// Repository writes fail with ErrNotFound when SoftDel or Restore find no
+// such quote, and with whatever kept the store from committing otherwise.
+// Get and GetRandom fail with ErrNotFound when no quote matches, and every
+// read fails when the store cannot read its data, for example a page that
+// does not match its checksum.
 type Repository interface {
-	Set(key string, value entity.Quote)
-	Del(key string)
+	Set(key string, value entity.Quote) (entity.Quote, error)
+	Del(key string) error
+	SetIfAbsent(key string, value entity.Quote) (entity.Quote, error)
+	CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error)
+	DeleteIfVersion(key string, expected uint64) error
+	SoftDelIfVersion(key string, expected uint64, at time.Time) error
+	Get(key string) (entity.Quote, error)
+	GetAllByAuthor(author string) ([]entity.Quote, error)
+	GetRandom(opts entity.RandomOptions) (entity.Quote, error)
+	GetAll() ([]entity.Quote, error)
+	Snapshot(ttl time.Duration) uint64
+	ListAt(seq uint64, filter entity.Filter, after string, limit int) ([]entity.Quote, error)
+	Version() (uint64, time.Time)
+	GetAllByTags(tags []string, matchAll bool) ([]entity.Quote, error)
+	TagCounts() ([]entity.TagCount, error)
+	Find(filter entity.Filter) ([]entity.Quote, error)
+	SoftDel(key string, at time.Time) error
+	Restore(key string) (entity.Quote, error)
+	GetTrash() ([]entity.Quote, error)
+	PurgeBefore(before time.Time) ([]entity.Quote, error)
+	Update(fn func(tx Tx) error) error
+}
+
+// ChangeLog is implemented by stores that keep their latest commits in
+// order. Commits fails with ErrOffsetOutOfRange for a position the log no
+// longer holds, or one in another log, and the reader has to start over
+// from a Checkpoint. WaitCommit blocks while the log is still at since.
+type ChangeLog interface {
+	Version() (uint64, time.Time)
+	Checkpoint() entity.Checkpoint
+	Commits(since entity.Offset, limit int) ([]entity.Commit, error)
+	WaitCommit(ctx context.Context, since entity.Offset) error
+}
+
+// ChangeFeed is a ChangeLog that tells creates from updates. Changes
+// returns the changes of up to limit commits after since, and the offset
+// to resume from, which moves past commits that changed nothing visible.
+type ChangeFeed interface {
+	ChangeLog
+	Changes(since entity.Offset, limit int) ([]entity.Change, entity.Offset, error)
+}
+
+// Replica is implemented by stores that can follow another store's log.
+// Loading a checkpoint makes the checkpoint's log the replica's own, so
+// Offset is where to ask the leader for the next commits.
+type Replica interface {
+	Version() (uint64, time.Time)
+	Offset() entity.Offset
+	Load(checkpoint entity.Checkpoint) error
+	Apply(commit entity.Commit) error
+}
+
+// GetAuthorByName and SetAuthorIfAbsent see the authors written earlier in
+// the transaction. SetAuthorIfAbsent refuses a taken key or name and
+// returns the author in the way, or a zero author when the read failed,
+// which fails the transaction.
+type Tx interface {
 	Get(key string) (entity.Quote, bool)
-	GetAllByAuthor(author string) ([]entity.Quote, bool)
-	GetRandom() (entity.Quote, bool)
-	GetAll() []entity.Quote
+	Set(key string, value entity.Quote) entity.Quote
+	Del(key string)
+	SoftDel(key string, at time.Time) bool
+	GetAuthorByName(name string) (entity.Author, bool)
+	SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, bool)
+}
+
+// GetAlias and DelAlias fail with ErrNotFound for an alias that is not set.
+type AliasRepository interface {
+	SetAlias(alias, canonical string) error
+	DelAlias(alias string) error
+	GetAlias(alias string) (string, error)
+	Aliases() (map[string]string, error)
+}
+
+// GetAuthor and GetAuthorByName fail with ErrNotFound for an unknown author.
+// SetAuthorIfAbsent fails with ErrExists when key or the author's name is
+// taken, and returns the author in the way.
+type AuthorRepository interface {
+	SetAuthor(key string, value entity.Author) (entity.Author, error)
+	SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, error)
+	GetAuthor(key string) (entity.Author, error)
+	GetAuthorByName(name string) (entity.Author, error)
+	DelAuthor(key string) error
+	GetAllAuthors() ([]entity.Author, error)
+}
+
+// GetPin and DelPin fail with ErrNotFound for a date without a pin.
+type PinRepository interface {
+	SetPin(date, key string) error
+	DelPin(date string) error
+	GetPin(date string) (string, error)
+	Pins() (map[string]string, error)
+}
+
+// GetRevision fails with ErrNotFound for a revision that is not kept.
+type RevisionRepository interface {
+	AppendRevision(key string, rev entity.Revision) (entity.Revision, error)
+	Revisions(key string) ([]entity.Revision, error)
+	GetRevision(key string, n int) (entity.Revision, error)
+}
+
+type AuditRepository interface {
+	Append(entry entity.AuditEntry) (entity.AuditEntry, error)
+	Query(filter entity.AuditFilter) ([]entity.AuditEntry, error)
 }
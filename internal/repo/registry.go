@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+type Store interface {
+	Repository
+	AliasRepository
+	AuthorRepository
+	PinRepository
+	RevisionRepository
+	Close() error
+}
+
+type Config struct {
+	Path         string
+	HistoryCap   int
+	MaxEntries   int
+	MaxBytes     int64
+	LogRetention int
+}
+
+// StatsReporter is implemented by backends that track their own size.
+type StatsReporter interface {
+	Stats() entity.StorageStats
+}
+
+type Factory func(cfg Config) (Store, error)
+
+var (
+	backendsMutex sync.RWMutex
+	backends      = make(map[string]Factory)
+)
+
+func Register(name string, factory Factory) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+	if _, exists := backends[name]; exists {
+		panic("repo: backend " + name + " registered twice")
+	}
+	backends[name] = factory
+}
+
+func Open(name string, cfg Config) (Store, error) {
+	backendsMutex.RLock()
+	factory, ok := backends[name]
+	backendsMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q, available: %v", name, Backends())
+	}
+	return factory(cfg)
+}
+
+func Backends() []string {
+	backendsMutex.RLock()
+	defer backendsMutex.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,512 @@
+// Package repotest is the conformance suite every storage backend must pass.
+package repotest
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type Opener func(t *testing.T) repo.Store
+
+type DurableOpener func(t *testing.T, path string) repo.Store
+
+func Run(t *testing.T, open Opener) {
+	t.Run("SetGet", func(t *testing.T) { testSetGet(t, open(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, open(t)) })
+	t.Run("ConditionalWrites", func(t *testing.T) { testConditionalWrites(t, open(t)) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, open(t)) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, open(t)) })
+	t.Run("FindAndTags", func(t *testing.T) { testFindAndTags(t, open(t)) })
+	t.Run("Random", func(t *testing.T) { testRandom(t, open(t)) })
+	t.Run("Snapshots", func(t *testing.T) { testSnapshots(t, open(t)) })
+	t.Run("Aliases", func(t *testing.T) { testAliases(t, open(t)) })
+	t.Run("Authors", func(t *testing.T) { testAuthors(t, open(t)) })
+	t.Run("Pins", func(t *testing.T) { testPins(t, open(t)) })
+	t.Run("Revisions", func(t *testing.T) { testRevisions(t, open(t)) })
+}
+
+// RunDurable checks that committed quotes, authors, aliases, pins and
+// revisions survive closing and reopening the store at the same path.
+func RunDurable(t *testing.T, open DurableOpener) {
+	path := filepath.Join(t.TempDir(), "store")
+	store := open(t, path)
+	store.Set("1", quote("1", "Q1", "a"))
+	store.Set("2", quote("2", "Q2", "b"))
+	store.Set("3", quote("3", "Q3"))
+	store.Set("1", quote("1", "Q1 edited", "a"))
+	store.SoftDel("2", time.Now())
+	for _, key := range []string{"1", "1", "3"} {
+		if _, err := store.AppendRevision(key, entity.Revision{Actor: "alice", Quote: quote(key, "Q")}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	store.Del("3")
+	store.SetAuthor("1", entity.Author{Id: "1", Name: "Лев Толстой"})
+	store.SetAuthor("2", entity.Author{Id: "2", Name: "Seneca"})
+	store.SetAuthor("1", entity.Author{Id: "1", Name: "Leo Tolstoy"})
+	store.DelAuthor("2")
+	store.SetAlias("tolstoy", "Leo Tolstoy")
+	store.SetAlias("seneca", "Seneca")
+	store.DelAlias("seneca")
+	store.SetPin("2026-01-01", "1")
+	store.SetPin("2026-01-02", "1")
+	store.DelPin("2026-01-02")
+	version, _ := store.Version()
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store = open(t, path)
+	defer store.Close()
+	if got, _ := store.Version(); got != version {
+		t.Errorf("Expected version %d after reopen, got %d", version, got)
+	}
+	if got, err := store.Get("1"); err != nil || got.Phrase != "Q1 edited" {
+		t.Errorf("Unexpected quote after reopen: %+v %v", got, err)
+	}
+	if _, err := store.Get("3"); err == nil {
+		t.Error("Deleted quote was resurrected")
+	}
+	if trash, _ := store.GetTrash(); len(trash) != 1 || trash[0].Id != "2" {
+		t.Errorf("Unexpected trash after reopen: %+v", trash)
+	}
+	if quotes, _ := store.GetAllByTags([]string{"a"}, true); len(quotes) != 1 {
+		t.Errorf("Tag index was not restored: %+v", quotes)
+	}
+	if authors, _ := store.GetAllAuthors(); len(authors) != 1 || authors[0].Name != "Leo Tolstoy" {
+		t.Errorf("Unexpected authors after reopen: %+v", authors)
+	}
+	if author, err := store.GetAuthorByName("leo tolstoy"); err != nil || author.Id != "1" {
+		t.Errorf("Name index was not restored: %+v %v", author, err)
+	}
+	if _, err := store.GetAuthorByName("Лев Толстой"); err == nil {
+		t.Error("Old author name resolves after reopen")
+	}
+	if aliases, _ := store.Aliases(); len(aliases) != 1 || aliases["tolstoy"] != "Leo Tolstoy" {
+		t.Errorf("Unexpected aliases after reopen: %v", aliases)
+	}
+	if pins, _ := store.Pins(); len(pins) != 1 || pins["2026-01-01"] != "1" {
+		t.Errorf("Unexpected pins after reopen: %v", pins)
+	}
+	if revs, _ := store.Revisions("1"); len(revs) != 2 || revs[1].Number != 2 {
+		t.Errorf("Unexpected revisions after reopen: %+v", revs)
+	}
+	if revs, _ := store.Revisions("3"); len(revs) != 0 {
+		t.Errorf("Revisions of a deleted quote came back: %+v", revs)
+	}
+	if rev, err := store.AppendRevision("1", entity.Revision{Actor: "alice", Quote: quote("1", "Q")}); err != nil || rev.Number != 3 {
+		t.Errorf("Revision numbering restarted after reopen: %+v %v", rev, err)
+	}
+	next, err := store.Set("4", quote("4", "Q4"))
+	if err != nil || next.Version <= version {
+		t.Errorf("Version went backwards after reopen: %d <= %d", next.Version, version)
+	}
+}
+
+func quote(id, phrase string, tags ...string) entity.Quote {
+	return entity.Quote{Id: id, Author: "Author " + id, Phrase: phrase, Tags: tags}
+}
+
+// ids takes a read's results as they are, and a failed read shows up in
+// the mismatch.
+func ids(quotes []entity.Quote, err error) []string {
+	if err != nil {
+		return []string{err.Error()}
+	}
+	res := make([]string, 0, len(quotes))
+	for _, q := range quotes {
+		res = append(res, q.Id)
+	}
+	slices.Sort(res)
+	return res
+}
+
+func testSetGet(t *testing.T, store repo.Store) {
+	before, _ := store.Version()
+	first, err := store.Set("1", quote("1", "Q1"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := store.Set("1", quote("1", "Q1 edited"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first.Version <= before || second.Version <= first.Version {
+		t.Errorf("Versions must increase: %d, %d, %d", before, first.Version, second.Version)
+	}
+	if after, modified := store.Version(); after != second.Version || modified.IsZero() {
+		t.Errorf("Unexpected store version %d at %v", after, modified)
+	}
+	got, err := store.Get("1")
+	if err != nil || got.Phrase != "Q1 edited" || got.Version != second.Version {
+		t.Errorf("Unexpected quote: %+v %v", got, err)
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, repo.ErrNotFound) {
+		t.Error("Missing key was found")
+	}
+	store.Set("2", quote("2", "Q2"))
+	if all := ids(store.GetAll()); !slices.Equal(all, []string{"1", "2"}) {
+		t.Errorf("Unexpected listing: %v", all)
+	}
+}
+
+func testDelete(t *testing.T, store repo.Store) {
+	store.Set("1", quote("1", "Q1", "a"))
+	if err := store.Del("1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.Get("1"); err == nil {
+		t.Error("Deleted quote is readable")
+	}
+	if counts, _ := store.TagCounts(); len(counts) != 0 {
+		t.Errorf("Deleted quote is still indexed: %+v", counts)
+	}
+	version, _ := store.Version()
+	if err := store.Del("missing"); err != nil {
+		t.Errorf("Deleting a missing key failed: %v", err)
+	}
+	if after, _ := store.Version(); after != version {
+		t.Error("Deleting a missing key changed the version")
+	}
+}
+
+func testConditionalWrites(t *testing.T, store repo.Store) {
+	first, err := store.SetIfAbsent("1", quote("1", "Q1"))
+	if err != nil {
+		t.Fatalf("SetIfAbsent refused a new key: %v", err)
+	}
+	if _, err := store.SetIfAbsent("1", quote("1", "other")); !errors.Is(err, repo.ErrExists) {
+		t.Errorf("Expected existing key, got %v", err)
+	}
+	if _, err := store.CompareAndSwap("1", first.Version+1, quote("1", "stale")); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	if _, err := store.CompareAndSwap("missing", 1, quote("missing", "Q")); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+	second, err := store.CompareAndSwap("1", first.Version, quote("1", "Q1 edited"))
+	if err != nil || second.Version <= first.Version {
+		t.Fatalf("Unexpected swap: %+v %v", second, err)
+	}
+	if err := store.SoftDelIfVersion("1", first.Version, time.Now()); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	if err := store.SoftDelIfVersion("1", second.Version, time.Now()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := store.SetIfAbsent("1", quote("1", "Q1")); !errors.Is(err, repo.ErrExists) {
+		t.Errorf("SetIfAbsent reused the key of a trashed quote: %v", err)
+	}
+	third, _ := store.SetIfAbsent("3", quote("3", "Q3"))
+	if err := store.DeleteIfVersion("3", third.Version+1); !errors.Is(err, repo.ErrVersionMismatch) {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+	if err := store.DeleteIfVersion("3", third.Version); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := store.DeleteIfVersion("3", third.Version); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func testTransactions(t *testing.T, store repo.Store) {
+	store.Set("1", quote("1", "Q1"))
+	version, _ := store.Version()
+	errAbort := errors.New("abort")
+	err := store.Update(func(tx repo.Tx) error {
+		tx.Set("2", quote("2", "Q2"))
+		tx.SoftDel("1", time.Now())
+		if _, ok := tx.Get("2"); !ok {
+			t.Error("Transaction does not see its own write")
+		}
+		if _, ok := tx.Get("1"); ok {
+			t.Error("Transaction sees a quote it deleted")
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected abort error, got %v", err)
+	}
+	if _, err := store.Get("2"); err == nil {
+		t.Error("Rolled back write is visible")
+	}
+	if _, err := store.Get("1"); err != nil {
+		t.Error("Rolled back delete is visible")
+	}
+	if after, _ := store.Version(); after != version {
+		t.Errorf("Rollback changed the version: %d -> %d", version, after)
+	}
+
+	err = store.Update(func(tx repo.Tx) error {
+		tx.Set("2", quote("2", "Q2"))
+		tx.Set("3", quote("3", "Q3"))
+		tx.Del("1")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if all := ids(store.GetAll()); !slices.Equal(all, []string{"2", "3"}) {
+		t.Errorf("Unexpected listing after commit: %v", all)
+	}
+}
+
+func testTrash(t *testing.T, store repo.Store) {
+	store.Set("1", quote("1", "Q1", "a"))
+	store.Set("2", quote("2", "Q2"))
+	old := time.Now().Add(-time.Hour)
+	if err := store.SoftDel("1", old); err != nil {
+		t.Fatalf("SoftDel refused an existing quote: %v", err)
+	}
+	if err := store.SoftDel("missing", old); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+	if _, err := store.Get("1"); err == nil {
+		t.Error("Trashed quote is readable")
+	}
+	if quotes, _ := store.GetAllByTags([]string{"a"}, true); len(quotes) != 0 {
+		t.Error("Trashed quote is still indexed")
+	}
+	trash, _ := store.GetTrash()
+	if len(trash) != 1 || trash[0].Id != "1" || !trash[0].DeletedAt.Equal(old) {
+		t.Fatalf("Unexpected trash: %+v", trash)
+	}
+	restored, err := store.Restore("1")
+	if err != nil || !restored.DeletedAt.IsZero() || restored.Version <= trash[0].Version {
+		t.Errorf("Unexpected restore: %+v %v", restored, err)
+	}
+	if _, err := store.Restore("1"); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+	if quotes, _ := store.GetAllByTags([]string{"a"}, true); len(quotes) != 1 {
+		t.Error("Restored quote is not indexed")
+	}
+	store.SoftDel("1", old)
+	store.SoftDel("2", time.Now())
+	purged, err := store.PurgeBefore(time.Now().Add(-time.Minute))
+	if err != nil || len(purged) != 1 || purged[0].Id != "1" {
+		t.Errorf("Unexpected purge: %+v %v", purged, err)
+	}
+	store.Del("2")
+	if trash, _ := store.GetTrash(); len(trash) != 0 {
+		t.Errorf("Del did not remove a trashed quote: %+v", trash)
+	}
+}
+
+func testFindAndTags(t *testing.T, store repo.Store) {
+	store.Set("1", quote("1", "short", "a", "b"))
+	store.Set("2", quote("2", "a longer phrase", "a"))
+	store.Set("3", quote("3", "untagged"))
+
+	if got := ids(store.GetAllByTags([]string{"a", "b"}, true)); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("Unexpected all-tags match: %v", got)
+	}
+	if got := ids(store.GetAllByTags([]string{"a", "b"}, false)); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("Unexpected any-tag match: %v", got)
+	}
+	if got := ids(store.Find(entity.Filter{Tags: []string{"a"}, MinLength: 6})); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("Unexpected filtered match: %v", got)
+	}
+	if got := ids(store.Find(entity.Filter{Phrase: "tagged"})); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("Unexpected phrase match: %v", got)
+	}
+	counts, _ := store.TagCounts()
+	slices.SortFunc(counts, func(a, b entity.TagCount) int { return a.Count - b.Count })
+	if len(counts) != 2 || counts[0] != (entity.TagCount{Tag: "b", Count: 1}) || counts[1] != (entity.TagCount{Tag: "a", Count: 2}) {
+		t.Errorf("Unexpected tag counts: %+v", counts)
+	}
+}
+
+func testRandom(t *testing.T, store repo.Store) {
+	if _, err := store.GetRandom(entity.RandomOptions{}); err == nil {
+		t.Error("Random returned a quote from an empty store")
+	}
+	for _, id := range []string{"1", "2", "3", "4"} {
+		store.Set(id, quote(id, "Q"+id, "t"+id))
+	}
+	opts := entity.RandomOptions{Seed: 42, Seeded: true}
+	first, err := store.GetRandom(opts)
+	if err != nil {
+		t.Fatal("Random returned nothing")
+	}
+	for range 5 {
+		if again, _ := store.GetRandom(opts); again.Id != first.Id {
+			t.Fatalf("Seeded random is not deterministic: %s != %s", again.Id, first.Id)
+		}
+	}
+	got, err := store.GetRandom(entity.RandomOptions{Filter: entity.Filter{Tags: []string{"t3"}}})
+	if err != nil || got.Id != "3" {
+		t.Errorf("Random ignored the filter: %+v", got)
+	}
+	if _, err := store.GetRandom(entity.RandomOptions{Exclude: []string{"1", "2", "3", "4"}}); err == nil {
+		t.Error("Random returned an excluded quote")
+	}
+}
+
+func testSnapshots(t *testing.T, store repo.Store) {
+	for _, id := range []string{"1", "2", "3"} {
+		store.Set(id, quote(id, "Q"+id))
+	}
+	seq := store.Snapshot(time.Minute)
+	store.Set("2", quote("2", "edited"))
+	store.SoftDel("3", time.Now())
+	store.Set("10", quote("10", "Q10"))
+
+	old, err := store.ListAt(seq, entity.Filter{}, "", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(old) != 3 || old[0].Id != "1" || old[1].Phrase != "Q2" || old[2].Id != "3" {
+		t.Errorf("Snapshot sees later writes: %+v", old)
+	}
+	page, _ := store.ListAt(seq, entity.Filter{}, "1", 1)
+	if len(page) != 1 || page[0].Id != "2" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+	current, _ := store.Version()
+	now, _ := store.ListAt(current, entity.Filter{}, "", 0)
+	if got := []string{now[0].Id, now[1].Id, now[2].Id}; len(now) != 3 || !slices.Equal(got, []string{"1", "2", "10"}) {
+		t.Errorf("Expected numeric key order, got %+v", now)
+	}
+
+	short := store.Snapshot(time.Millisecond)
+	store.Set("1", quote("1", "edited"))
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.ListAt(short, entity.Filter{}, "", 0); !errors.Is(err, repo.ErrSnapshotExpired) {
+		t.Errorf("Expected expired snapshot, got %v", err)
+	}
+}
+
+func testAliases(t *testing.T, store repo.Store) {
+	store.SetAlias("tolstoy", "Leo Tolstoy")
+	if canonical, err := store.GetAlias("tolstoy"); err != nil || canonical != "Leo Tolstoy" {
+		t.Errorf("Unexpected alias: %q %v", canonical, err)
+	}
+	if aliases, _ := store.Aliases(); len(aliases) != 1 {
+		t.Errorf("Unexpected aliases: %v", aliases)
+	}
+	if err := store.DelAlias("tolstoy"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := store.DelAlias("tolstoy"); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func testAuthors(t *testing.T, store repo.Store) {
+	store.SetAuthor("1", entity.Author{Id: "1", Name: "Лев Толстой"})
+	store.SetAuthor("2", entity.Author{Id: "2", Name: "Seneca"})
+	if author, err := store.GetAuthorByName("лев толстой"); err != nil || author.Id != "1" {
+		t.Errorf("Lookup by name is not normalized: %+v %v", author, err)
+	}
+	if author, err := store.SetAuthor("1", entity.Author{Id: "1", Name: "Leo Tolstoy"}); err != nil || author.Name != "Leo Tolstoy" {
+		t.Errorf("Unexpected author: %+v %v", author, err)
+	}
+	if _, err := store.GetAuthorByName("Лев Толстой"); err == nil {
+		t.Error("Old name still resolves after rename")
+	}
+	store.DelAuthor("2")
+	if _, err := store.GetAuthor("2"); err == nil {
+		t.Error("Deleted author is readable")
+	}
+	if authors, _ := store.GetAllAuthors(); len(authors) != 1 || authors[0].Name != "Leo Tolstoy" {
+		t.Errorf("Unexpected authors: %+v", authors)
+	}
+
+	if existing, err := store.SetAuthorIfAbsent("1", entity.Author{Id: "1", Name: "Seneca"}); !errors.Is(err, repo.ErrExists) || existing.Name != "Leo Tolstoy" {
+		t.Errorf("Taken id was overwritten: %+v %v", existing, err)
+	}
+	if existing, err := store.SetAuthorIfAbsent("3", entity.Author{Id: "3", Name: "leo tolstoy"}); !errors.Is(err, repo.ErrExists) || existing.Id != "1" {
+		t.Errorf("Taken name was added twice: %+v %v", existing, err)
+	}
+	if author, err := store.SetAuthorIfAbsent("3", entity.Author{Id: "3", Name: "Seneca"}); err != nil || author.Id != "3" {
+		t.Errorf("Unexpected author: %+v %v", author, err)
+	}
+	if author, err := store.GetAuthorByName("seneca"); err != nil || author.Id != "3" {
+		t.Errorf("Added author is not found by name: %+v %v", author, err)
+	}
+
+	errAbort := errors.New("abort")
+	for _, abort := range []bool{true, false} {
+		err := store.Update(func(tx repo.Tx) error {
+			if _, ok := tx.SetAuthorIfAbsent("4", entity.Author{Id: "4", Name: "SENECA"}); ok {
+				t.Error("Transaction added a taken name")
+			}
+			if _, ok := tx.SetAuthorIfAbsent("4", entity.Author{Id: "4", Name: "Cicero"}); !ok {
+				t.Error("Transaction refused a free author")
+			}
+			if author, ok := tx.GetAuthorByName("cicero"); !ok || author.Id != "4" {
+				t.Errorf("Transaction does not see its own author: %+v", author)
+			}
+			if _, ok := tx.SetAuthorIfAbsent("4", entity.Author{Id: "4", Name: "Cato"}); ok {
+				t.Error("Transaction reused an id it took")
+			}
+			tx.Set("1", entity.Quote{Id: "1", AuthorId: "4", Author: "Cicero", Phrase: "Q1"})
+			if abort {
+				return errAbort
+			}
+			return nil
+		})
+		_, getErr := store.GetAuthor("4")
+		if abort && (!errors.Is(err, errAbort) || getErr == nil) {
+			t.Errorf("Rolled back author is visible: %v", err)
+		}
+		if !abort && (err != nil || getErr != nil) {
+			t.Errorf("Author was not committed with the transaction: %v, %v", err, getErr)
+		}
+	}
+}
+
+func testPins(t *testing.T, store repo.Store) {
+	store.SetPin("2026-01-01", "1")
+	if key, err := store.GetPin("2026-01-01"); err != nil || key != "1" {
+		t.Errorf("Unexpected pin: %q %v", key, err)
+	}
+	if pins, _ := store.Pins(); len(pins) != 1 {
+		t.Errorf("Unexpected pins: %v", pins)
+	}
+	if err := store.DelPin("2026-01-01"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := store.DelPin("2026-01-01"); !errors.Is(err, repo.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func testRevisions(t *testing.T, store repo.Store) {
+	for i, phrase := range []string{"v1", "v2"} {
+		rev, err := store.AppendRevision("1", entity.Revision{Actor: "alice", Quote: quote("1", phrase)})
+		if err != nil || rev.Number != i+1 {
+			t.Fatalf("Unexpected revision: %+v %v", rev, err)
+		}
+	}
+	revs, _ := store.Revisions("1")
+	if len(revs) != 2 || revs[0].Number != 1 || revs[1].Number != 2 {
+		t.Fatalf("Unexpected revisions: %+v", revs)
+	}
+	if rev, err := store.GetRevision("1", 2); err != nil || rev.Quote.Phrase != "v2" {
+		t.Errorf("Unexpected revision: %+v %v", rev, err)
+	}
+	if _, err := store.GetRevision("1", 3); err == nil {
+		t.Error("Missing revision was found")
+	}
+	store.Set("1", quote("1", "Q"))
+	store.Del("1")
+	if revs, _ := store.Revisions("1"); len(revs) != 0 {
+		t.Errorf("Hard delete kept revisions: %+v", revs)
+	}
+	second, _ := store.Set("2", quote("2", "Q"))
+	store.AppendRevision("2", entity.Revision{Actor: "alice", Quote: second})
+	if err := store.DeleteIfVersion("2", second.Version); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if revs, _ := store.Revisions("2"); len(revs) != 0 {
+		t.Errorf("Conditional delete kept revisions: %+v", revs)
+	}
+}
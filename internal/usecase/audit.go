@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor entity.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFrom(ctx context.Context) entity.Actor {
+	actor, _ := ctx.Value(actorKey{}).(entity.Actor)
+	if actor.Name == "" {
+		actor.Name = systemActor
+	}
+	return actor
+}
+
+func WithAudit(audit repo.AuditRepository) Option {
+	return func(uc *usecase) {
+		uc.audit = audit
+	}
+}
+
+func (uc *usecase) Audit(filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	if uc.audit == nil {
+		return nil, nil
+	}
+	return uc.audit.Query(filter)
+}
+
+func (uc *usecase) record(ctx context.Context, operation, key string, before, after *entity.Quote) {
+	uc.appendAudit(ctx, entity.AuditEntry{Operation: operation, QuoteId: key, Before: before, After: after})
+}
+
+// recordAuthor audits a change of the author with the given id.
+func (uc *usecase) recordAuthor(ctx context.Context, operation, key string, before, after *entity.Author) {
+	uc.appendAudit(ctx, entity.AuditEntry{Operation: operation, Subject: key, AuthorBefore: before, AuthorAfter: after})
+}
+
+// recordValue audits a change of an alias or a pin, given the value it
+// pointed to before and after, empty when it was not set.
+func (uc *usecase) recordValue(ctx context.Context, operation, subject, before, after string) {
+	uc.appendAudit(ctx, entity.AuditEntry{Operation: operation, Subject: subject, ValueBefore: before, ValueAfter: after})
+}
+
+// appendAudit writes entry after the change it describes is committed.
+// Failing the request then would make the client retry a write that
+// already happened, so a failed append is logged and counted by the audit
+// log for the metrics instead.
+func (uc *usecase) appendAudit(ctx context.Context, entry entity.AuditEntry) {
+	if uc.audit == nil {
+		return
+	}
+	entry.At = time.Now().UTC()
+	entry.Actor = ActorFrom(ctx)
+	if _, err := uc.audit.Append(entry); err != nil {
+		log.Printf("failed to write audit entry %s %s%s: %v", entry.Operation, entry.QuoteId, entry.Subject, err)
+	}
+}
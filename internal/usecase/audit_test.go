@@ -0,0 +1,179 @@
+package usecase_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo/audit"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	uc := usecase.New(repo, repo, repo, repo, repo, usecase.WithAudit(log))
+	ctx := usecase.WithActor(context.Background(), entity.Actor{Name: "alice", IP: "10.0.0.1", RequestId: "req-1"})
+
+	quote, err := uc.Set(ctx, entity.Quote{Author: "Seneca", Phrase: "Original"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.Update(ctx, quote.Id, entity.Quote{Author: "Seneca", Phrase: "Edited"}, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.Delete(context.Background(), quote.Id, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := uc.Audit(entity.AuditFilter{QuoteId: quote.Id})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	created, updated, deleted := entries[0], entries[1], entries[2]
+	if created.Operation != entity.AuditCreate || created.Before != nil || created.After.Phrase != "Original" {
+		t.Errorf("Unexpected create entry: %+v", created)
+	}
+	if created.Actor != (entity.Actor{Name: "alice", IP: "10.0.0.1", RequestId: "req-1"}) {
+		t.Errorf("Unexpected actor: %+v", created.Actor)
+	}
+	if updated.Operation != entity.AuditUpdate || updated.Before.Phrase != "Original" || updated.After.Phrase != "Edited" {
+		t.Errorf("Unexpected update entry: %+v", updated)
+	}
+	if deleted.Operation != entity.AuditDelete || deleted.Actor.Name != "system" || deleted.After != nil {
+		t.Errorf("Unexpected delete entry: %+v", deleted)
+	}
+
+	if entries, _ := uc.Audit(entity.AuditFilter{Operation: entity.AuditDelete}); len(entries) != 1 {
+		t.Errorf("Expected 1 delete entry, got %d", len(entries))
+	}
+}
+
+func TestAuditBulkRewrites(t *testing.T) {
+	t.Parallel()
+
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	uc := usecase.New(repo, repo, repo, repo, repo, usecase.WithAudit(log))
+	ctx := usecase.WithActor(context.Background(), entity.Actor{Name: "alice"})
+	for _, phrase := range []string{"One", "Two"} {
+		if _, err := uc.Set(context.Background(), entity.Quote{Author: "Seneca", Phrase: phrase, Tags: []string{"life"}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, err := uc.RenameTag(ctx, "life", "vita"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.SetAuthorAlias(ctx, "Seneca", "Lucius Annaeus Seneca"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := uc.Audit(entity.AuditFilter{Actor: "alice", Operation: entity.AuditUpdate})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected an entry per rewritten quote, got %d", len(entries))
+	}
+	if entries[0].Before.Tags[0] != "life" || entries[0].After.Tags[0] != "vita" || entries[0].After.UpdatedBy != "alice" {
+		t.Errorf("Unexpected tag entry: %+v", entries[0])
+	}
+	if entries[3].Before.Author != "Seneca" || entries[3].After.Author != "Lucius Annaeus Seneca" {
+		t.Errorf("Unexpected author entry: %+v", entries[3])
+	}
+}
+
+func TestAuditSideTables(t *testing.T) {
+	t.Parallel()
+
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	uc := usecase.New(repo, repo, repo, repo, repo, usecase.WithAudit(log))
+	ctx := usecase.WithActor(context.Background(), entity.Actor{Name: "alice"})
+
+	author, err := uc.CreateAuthor(ctx, entity.Author{Name: "Seneca"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.UpdateAuthor(ctx, author.Id, entity.Author{Name: "Seneca", Bio: "Stoic"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.DeleteAuthor(ctx, author.Id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.SetAuthorAlias(ctx, "Сенека", "Seneca"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.DeleteAuthorAlias(ctx, "Сенека"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	quote, err := uc.Set(ctx, entity.Quote{Author: "Seneca", Phrase: "Pinned"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	date := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := uc.PinDaily(ctx, date, quote.Id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.UnpinDaily(ctx, date); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := uc.Audit(entity.AuditFilter{Actor: "alice"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var operations []string
+	for _, entry := range entries {
+		operations = append(operations, entry.Operation)
+	}
+	want := []string{
+		entity.AuditAuthorCreate, entity.AuditAuthorUpdate, entity.AuditAuthorDelete,
+		entity.AuditAliasSet, entity.AuditAliasDelete,
+		entity.AuditAuthorCreate, entity.AuditCreate,
+		entity.AuditPin, entity.AuditUnpin,
+	}
+	if !slices.Equal(operations, want) {
+		t.Fatalf("Expected operations %v, got %v", want, operations)
+	}
+	if updated := entries[1]; updated.Subject != author.Id || updated.AuthorBefore.Bio != "" || updated.AuthorAfter.Bio != "Stoic" {
+		t.Errorf("Unexpected author update entry: %+v", updated)
+	}
+	if alias := entries[4]; alias.Subject != "сенека" || alias.ValueBefore != "Seneca" || alias.ValueAfter != "" {
+		t.Errorf("Unexpected alias delete entry: %+v", alias)
+	}
+	if pin := entries[7]; pin.Subject != "2026-01-01" || pin.ValueBefore != "" || pin.ValueAfter != quote.Id {
+		t.Errorf("Unexpected pin entry: %+v", pin)
+	}
+}
@@ -0,0 +1,480 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (uc *usecase) SetAuthorAlias(ctx context.Context, alias, canonical string) error {
+	aliasKey, canonicalKey := entity.NormalizeAuthor(alias), entity.NormalizeAuthor(canonical)
+	if aliasKey == "" || canonicalKey == "" {
+		return fmt.Errorf("%w: alias and canonical author are required", ErrValidation)
+	}
+	if aliasKey == canonicalKey {
+		return fmt.Errorf("%w: %q is already the canonical spelling", ErrValidation, alias)
+	}
+	resolved, err := uc.aliases.GetAlias(canonicalKey)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return err
+	}
+	if err == nil && entity.NormalizeAuthor(resolved) != canonicalKey {
+		return fmt.Errorf("%w: %q is itself an alias of %q", ErrValidation, canonical, resolved)
+	}
+	previous, err := uc.aliases.GetAlias(aliasKey)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return err
+	}
+	if err := uc.aliases.SetAlias(aliasKey, canonical); err != nil {
+		return storageError(err)
+	}
+	uc.recordValue(ctx, entity.AuditAliasSet, aliasKey, previous, canonical)
+
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	target, hasTarget, err := uc.authorByName(canonical)
+	if err != nil {
+		return err
+	}
+	if hasTarget && target.Name != canonical {
+		before := target
+		target.Name = canonical
+		target.UpdatedAt = time.Now().UTC()
+		if _, err := uc.authors.SetAuthor(target.Id, target); err != nil {
+			return storageError(err)
+		}
+		uc.recordAuthor(ctx, entity.AuditAuthorUpdate, target.Id, &before, &target)
+		if _, err := uc.moveQuotes(ctx, target.Id, target); err != nil {
+			return err
+		}
+	}
+	source, ok, err := uc.authorByName(alias)
+	if err != nil {
+		return err
+	}
+	if ok && (!hasTarget || source.Id != target.Id) {
+		if !hasTarget {
+			if target, _, err = uc.createAuthor(ctx, entity.Author{Name: canonical}); err != nil {
+				return err
+			}
+		}
+		if _, err := uc.moveQuotes(ctx, source.Id, target); err != nil {
+			return err
+		}
+		if err := uc.authors.DelAuthor(source.Id); err != nil {
+			return storageError(err)
+		}
+		uc.recordAuthor(ctx, entity.AuditAuthorDelete, source.Id, &source, nil)
+	}
+	return nil
+}
+
+func (uc *usecase) DeleteAuthorAlias(ctx context.Context, alias string) error {
+	aliasKey := entity.NormalizeAuthor(alias)
+	previous, err := uc.aliases.GetAlias(aliasKey)
+	if err == nil {
+		err = uc.aliases.DelAlias(aliasKey)
+	}
+	if errors.Is(err, repo.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return storageError(err)
+	}
+	uc.recordValue(ctx, entity.AuditAliasDelete, aliasKey, previous, "")
+	return nil
+}
+
+func (uc *usecase) AuthorAliases() ([]entity.AuthorAlias, error) {
+	aliases, err := uc.aliases.Aliases()
+	if err != nil {
+		return nil, err
+	}
+	var res []entity.AuthorAlias
+	for alias, canonical := range aliases {
+		if alias == entity.NormalizeAuthor(canonical) {
+			// Self-mappings left by earlier versions resolve nothing.
+			continue
+		}
+		res = append(res, entity.AuthorAlias{Alias: alias, Canonical: canonical})
+	}
+	slices.SortFunc(res, func(a, b entity.AuthorAlias) int {
+		if c := cmp.Compare(a.Canonical, b.Canonical); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Alias, b.Alias)
+	})
+	return res, nil
+}
+
+func (uc *usecase) canonicalAuthor(author string) (string, error) {
+	canonical, err := uc.aliases.GetAlias(entity.NormalizeAuthor(author))
+	if errors.Is(err, repo.ErrNotFound) {
+		return author, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+func (uc *usecase) authorVariants(author string) ([]string, error) {
+	canonical, err := uc.canonicalAuthor(author)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := uc.aliases.Aliases()
+	if err != nil {
+		return nil, err
+	}
+	canonical = entity.NormalizeAuthor(canonical)
+	variants := []string{canonical}
+	for alias, target := range aliases {
+		if entity.NormalizeAuthor(target) == canonical && alias != canonical {
+			variants = append(variants, alias)
+		}
+	}
+	return variants, nil
+}
+
+// authorById and authorByName look an author up; ok is false when there is
+// none, and err is set only when the store could not be read.
+func (uc *usecase) authorById(key string) (entity.Author, bool, error) {
+	return found(uc.authors.GetAuthor(key))
+}
+
+func (uc *usecase) authorByName(name string) (entity.Author, bool, error) {
+	return found(uc.authors.GetAuthorByName(name))
+}
+
+func (uc *usecase) CreateAuthor(ctx context.Context, value entity.Author) (entity.Author, error) {
+	value, err := normalizeAuthor(value)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	existing, ok, err := uc.authorByName(value.Name)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if !ok {
+		if existing, ok, err = uc.createAuthor(ctx, value); err != nil || ok {
+			return existing, err
+		}
+	}
+	return existing, fmt.Errorf("%w: author %q already exists", ErrConflict, existing.Name)
+}
+
+func (uc *usecase) GetAuthor(key string) (entity.Author, error) {
+	author, ok, err := uc.authorById(key)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if !ok {
+		return entity.Author{}, ErrAuthorNotFound
+	}
+	if author.QuoteCount, err = uc.quoteCount(key); err != nil {
+		return entity.Author{}, err
+	}
+	return author, nil
+}
+
+func (uc *usecase) quoteCount(authorId string) (int, error) {
+	quotes, err := uc.repo.Find(entity.Filter{AuthorId: authorId})
+	return len(quotes), err
+}
+
+func (uc *usecase) Authors() ([]entity.Author, error) {
+	quotes, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, quote := range quotes {
+		counts[quote.AuthorId]++
+	}
+	authors, err := uc.authors.GetAllAuthors()
+	if err != nil {
+		return nil, err
+	}
+	for i := range authors {
+		authors[i].QuoteCount = counts[authors[i].Id]
+	}
+	slices.SortFunc(authors, func(a, b entity.Author) int { return compareIds(a.Id, b.Id) })
+	return authors, nil
+}
+
+func (uc *usecase) UpdateAuthor(ctx context.Context, key string, value entity.Author) (entity.Author, error) {
+	value, err := normalizeAuthor(value)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	current, ok, err := uc.authorById(key)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if !ok {
+		return entity.Author{}, ErrAuthorNotFound
+	}
+	existing, ok, err := uc.authorByName(value.Name)
+	if err != nil {
+		return entity.Author{}, err
+	}
+	if ok && existing.Id != key {
+		return entity.Author{}, fmt.Errorf("%w: author %q already exists", ErrConflict, existing.Name)
+	}
+	value.Id = key
+	value.CreatedAt = current.CreatedAt
+	value.UpdatedAt = time.Now().UTC()
+	if _, err := uc.authors.SetAuthor(key, value); err != nil {
+		return entity.Author{}, storageError(err)
+	}
+	uc.recordAuthor(ctx, entity.AuditAuthorUpdate, key, &current, &value)
+
+	if current.Name != value.Name {
+		value.QuoteCount, err = uc.moveQuotes(ctx, key, value)
+	} else {
+		value.QuoteCount, err = uc.quoteCount(key)
+	}
+	if err != nil {
+		return entity.Author{}, err
+	}
+	return value, nil
+}
+
+func (uc *usecase) DeleteAuthor(ctx context.Context, key string) error {
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	current, ok, err := uc.authorById(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAuthorNotFound
+	}
+	count, err := uc.quoteCount(key)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: author has %d quotes", ErrConflict, count)
+	}
+	if err := uc.authors.DelAuthor(key); err != nil {
+		return storageError(err)
+	}
+	uc.recordAuthor(ctx, entity.AuditAuthorDelete, key, &current, nil)
+	return nil
+}
+
+func (uc *usecase) AuthorQuotes(key string) ([]entity.Quote, error) {
+	_, ok, err := uc.authorById(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAuthorNotFound
+	}
+	return uc.repo.Find(entity.Filter{AuthorId: key, VisibleAt: uc.now()})
+}
+
+// MigrateAuthors links quotes without a known author to an author found or
+// created by name, and returns how many authors it created.
+func (uc *usecase) MigrateAuthors(ctx context.Context) (int, error) {
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	if err := uc.syncAuthorCounter(); err != nil {
+		return 0, err
+	}
+	quotes, err := uc.repo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, quote := range quotes {
+		_, known, err := uc.authorById(quote.AuthorId)
+		if err != nil {
+			return created, err
+		}
+		if known {
+			continue
+		}
+		before, after, ok, err := uc.rewrite(quote.Id, func(quote entity.Quote) (entity.Quote, bool, error) {
+			if _, ok, err := uc.authorById(quote.AuthorId); err != nil || ok {
+				return quote, false, err
+			}
+			author, ok, err := uc.authorByName(quote.Author)
+			if err != nil {
+				return quote, false, err
+			}
+			if !ok {
+				if author, ok, err = uc.createAuthor(ctx, entity.Author{Name: quote.Author}); err != nil {
+					return quote, false, err
+				}
+				if ok {
+					created++
+				}
+			}
+			quote.AuthorId = author.Id
+			quote.UpdatedBy = ActorFrom(ctx).Name
+			return quote, true, nil
+		})
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			uc.record(ctx, entity.AuditUpdate, after.Id, &before, &after)
+		}
+	}
+	return created, nil
+}
+
+func (uc *usecase) resolveAuthor(ctx context.Context, value entity.Quote) (entity.Quote, error) {
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	value, err := uc.matchAuthor(value)
+	if err != nil || value.AuthorId != "" {
+		return value, err
+	}
+	author, _, err := uc.createAuthor(ctx, entity.Author{Name: value.Author})
+	if err != nil {
+		return value, err
+	}
+	value.AuthorId = author.Id
+	value.Author = author.Name
+	return value, nil
+}
+
+// matchAuthor checks the author id of value, or spells its author the
+// canonical way and links the author of that name. The id stays empty
+// when there is no such author yet.
+func (uc *usecase) matchAuthor(value entity.Quote) (entity.Quote, error) {
+	if value.AuthorId != "" {
+		author, ok, err := uc.authorById(value.AuthorId)
+		if err != nil {
+			return value, err
+		}
+		if !ok {
+			return value, fmt.Errorf("%w: unknown author id %q", ErrValidation, value.AuthorId)
+		}
+		if value.Author != "" {
+			canonical, err := uc.canonicalAuthor(value.Author)
+			if err != nil {
+				return value, err
+			}
+			if entity.NormalizeAuthor(canonical) != entity.NormalizeAuthor(author.Name) {
+				return value, fmt.Errorf("%w: author %q does not match author id %q", ErrValidation, value.Author, value.AuthorId)
+			}
+		}
+		value.Author = author.Name
+		return value, nil
+	}
+	canonical, err := uc.canonicalAuthor(value.Author)
+	if err != nil {
+		return value, err
+	}
+	value.Author = canonical
+	author, ok, err := uc.authorByName(value.Author)
+	if err != nil || !ok {
+		return value, err
+	}
+	value.AuthorId = author.Id
+	value.Author = author.Name
+	return value, nil
+}
+
+// txAuthor returns the author named name, adding one to tx if there is
+// none, so that it lands in the same commit as the quotes of tx.
+func (uc *usecase) txAuthor(tx repo.Tx, name string, now time.Time) (entity.Author, bool) {
+	if author, ok := tx.GetAuthorByName(name); ok {
+		return author, false
+	}
+	value := entity.Author{Name: name, CreatedAt: now, UpdatedAt: now}
+	for {
+		value.Id = strconv.FormatInt(uc.authorCounter.Add(1), 10)
+		existing, ok := tx.SetAuthorIfAbsent(value.Id, value)
+		if ok || existing.Id == "" {
+			// A failed read fails the transaction when it commits.
+			return existing, ok
+		}
+	}
+}
+
+func (uc *usecase) moveQuotes(ctx context.Context, from string, to entity.Author) (int, error) {
+	quotes, err := uc.repo.Find(entity.Filter{AuthorId: from})
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	moved := 0
+	for _, quote := range quotes {
+		before, after, ok, err := uc.rewrite(quote.Id, func(quote entity.Quote) (entity.Quote, bool, error) {
+			if quote.AuthorId != from {
+				return quote, false, nil
+			}
+			quote.AuthorId = to.Id
+			quote.Author = to.Name
+			quote.UpdatedAt = now
+			quote.UpdatedBy = ActorFrom(ctx).Name
+			return quote, true, nil
+		})
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			uc.record(ctx, entity.AuditUpdate, after.Id, &before, &after)
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// createAuthor adds value under the next free id. The store checks that
+// neither the id nor the name is taken when the write lands, so when
+// another writer created an author of that name first, that author is
+// returned with created == false. A taken id means authors were created
+// behind the counter, by another node of a cluster, so it is synced again
+// instead of trying the ids one by one.
+func (uc *usecase) createAuthor(ctx context.Context, value entity.Author) (entity.Author, bool, error) {
+	value.CreatedAt = time.Now().UTC()
+	value.UpdatedAt = value.CreatedAt
+	for {
+		value.Id = strconv.FormatInt(uc.authorCounter.Add(1), 10)
+		existing, err := uc.authors.SetAuthorIfAbsent(value.Id, value)
+		switch {
+		case err == nil:
+			uc.recordAuthor(ctx, entity.AuditAuthorCreate, existing.Id, nil, &existing)
+			return existing, true, nil
+		case !errors.Is(err, repo.ErrExists):
+			return entity.Author{}, false, storageError(err)
+		case entity.NormalizeAuthor(existing.Name) == entity.NormalizeAuthor(value.Name):
+			return existing, false, nil
+		}
+		if err := uc.syncAuthorCounter(); err != nil {
+			return entity.Author{}, false, err
+		}
+	}
+}
+
+// syncAuthorCounter moves the author counter past every stored author id.
+func (uc *usecase) syncAuthorCounter() error {
+	authors, err := uc.authors.GetAllAuthors()
+	if err != nil {
+		return err
+	}
+	for _, author := range authors {
+		if id, err := strconv.ParseInt(author.Id, 10, 64); err == nil && id > uc.authorCounter.Load() {
+			uc.authorCounter.Store(id)
+		}
+	}
+	return nil
+}
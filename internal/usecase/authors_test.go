@@ -0,0 +1,207 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestNormalizeAuthor(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"Толстой":                "толстой",
+		"  Лев   ТОЛСТОЙ ":       "лев толстой",
+		"Л. Толстой":             "л толстой",
+		"Фёдор Достоевский":      "федор достоевский",
+		"Gabriel García Márquez": "gabriel garcia marquez",
+		"Émile Zola":             "emile zola",
+		"Салтыков-Щедрин":        "салтыков щедрин",
+		"Mihai Eminescu, Ștefan": "mihai eminescu stefan",
+		"Nguyễn Du":              "nguyen du",
+		"ǍBEL":                   "abel",
+		"ΟΔΥΣΣΕΥΣ":               "οδυσσευσ",
+		"Οδυσσεύς":               "οδυσσευσ",
+		"Straße":                 "strasse",
+		"Søren Kierkegaard":      "soren kierkegaard",
+		"Stanisław Lem":          "stanislaw lem",
+		"Андрей Платонов":        "андрей платонов",
+	}
+	for in, want := range cases {
+		if got := entity.NormalizeAuthor(in); got != want {
+			t.Errorf("NormalizeAuthor(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAuthorAliases(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t,
+		entity.Quote{Author: "Л. Толстой", Phrase: "Q1"},
+		entity.Quote{Author: "лев  толстой", Phrase: "Q2"},
+		entity.Quote{Author: "Пушкин", Phrase: "Q3"},
+	)
+
+	if quotes, _ := uc.GetAllByAuthor("ЛЕВ ТОЛСТОЙ"); len(quotes) != 1 {
+		t.Errorf("Expected normalized match, got %+v", quotes)
+	}
+
+	if err := uc.SetAuthorAlias(context.Background(), "Л. Толстой", "Лев Толстой"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if quotes, _ := uc.GetAllByAuthor("Лев Толстой"); len(quotes) != 2 {
+		t.Errorf("Expected alias match, got %+v", quotes)
+	}
+	if quotes, _ := uc.Find(entity.Filter{Author: "л толстой"}); len(quotes) != 2 {
+		t.Errorf("Expected alias match in filter, got %+v", quotes)
+	}
+
+	created, err := uc.Set(context.Background(), entity.Quote{Author: "л. толстой", Phrase: "Q4"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.Author != "Лев Толстой" {
+		t.Errorf("Expected canonical author on insert, got %q", created.Author)
+	}
+
+	if err := uc.SetAuthorAlias(context.Background(), "Лёва", "Л. Толстой"); err == nil {
+		t.Error("Expected error when canonical is an alias")
+	}
+	if err := uc.SetAuthorAlias(context.Background(), "ЛЕВ ТОЛСТОЙ", "Лев Толстой"); err == nil {
+		t.Error("Expected error aliasing a name to itself")
+	}
+	if aliases, _ := uc.AuthorAliases(); len(aliases) != 1 || aliases[0].Alias != "л толстой" {
+		t.Errorf("Expected only the alias to be stored, got %+v", aliases)
+	}
+	if err := uc.DeleteAuthorAlias(context.Background(), "Л. Толстой"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := uc.DeleteAuthorAlias(context.Background(), "Л. Толстой"); err == nil {
+		t.Error("Expected error deleting missing alias")
+	}
+	if aliases, _ := uc.AuthorAliases(); len(aliases) != 0 {
+		t.Errorf("Unexpected aliases: %+v", aliases)
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t,
+		entity.Quote{Author: "Чехов", Phrase: "Краткость — сестра таланта."},
+		entity.Quote{Author: "чехов", Phrase: "В человеке должно быть всё прекрасно."},
+	)
+
+	authors, _ := uc.Authors()
+	if len(authors) != 1 || authors[0].Name != "Чехов" || authors[0].QuoteCount != 2 {
+		t.Fatalf("Expected one author with two quotes, got %+v", authors)
+	}
+	id := authors[0].Id
+
+	if _, err := uc.CreateAuthor(context.Background(), entity.Author{Name: "ЧЕХОВ"}); !errors.Is(err, usecase.ErrConflict) {
+		t.Errorf("Expected conflict, got %v", err)
+	}
+	if _, err := uc.CreateAuthor(context.Background(), entity.Author{Name: "Гоголь", BirthDate: "1809-04-01", PhotoURL: "not a url"}); !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+
+	updated, err := uc.UpdateAuthor(context.Background(), id, entity.Author{Name: "Антон Чехов", BirthDate: "1860-01-29", DeathDate: "1904"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.QuoteCount != 2 {
+		t.Errorf("Unexpected quote count: %d", updated.QuoteCount)
+	}
+	quotes, err := uc.AuthorQuotes(id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, q := range quotes {
+		if q.Author != "Антон Чехов" {
+			t.Errorf("Author rename was not cascaded: %+v", q)
+		}
+	}
+
+	if _, err := uc.Set(context.Background(), entity.Quote{AuthorId: id, Phrase: "Third"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.Set(context.Background(), entity.Quote{AuthorId: "missing", Phrase: "Fourth"}); !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error for unknown author id, got %v", err)
+	}
+	if err := uc.DeleteAuthor(context.Background(), id); !errors.Is(err, usecase.ErrConflict) {
+		t.Errorf("Expected conflict deleting author with quotes, got %v", err)
+	}
+}
+
+func TestMigrateAuthors(t *testing.T) {
+	t.Parallel()
+
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	repo.Set("1", entity.Quote{Id: "1", Author: "Пушкин", Phrase: "Q1"})
+	repo.Set("2", entity.Quote{Id: "2", Author: "пушкин", Phrase: "Q2"})
+	repo.Set("3", entity.Quote{Id: "3", Author: "Лермонтов", Phrase: "Q3"})
+	uc := usecase.New(repo, repo, repo, repo, repo)
+
+	if created, err := uc.MigrateAuthors(context.Background()); err != nil || created != 2 {
+		t.Errorf("Expected 2 authors, got %d %v", created, err)
+	}
+	if created, _ := uc.MigrateAuthors(context.Background()); created != 0 {
+		t.Errorf("Migration must be idempotent, created %d", created)
+	}
+	all, _ := uc.GetAll()
+	for _, q := range all {
+		if q.AuthorId == "" {
+			t.Errorf("Quote without author id: %+v", q)
+		}
+	}
+}
+
+// countingAuthors counts the conditional author writes a usecase makes.
+type countingAuthors struct {
+	*storage.Engine
+	attempts int
+}
+
+func (c *countingAuthors) SetAuthorIfAbsent(key string, value entity.Author) (entity.Author, error) {
+	c.attempts++
+	return c.Engine.SetAuthorIfAbsent(key, value)
+}
+
+func TestAuthorCounter(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	// Authors replicated from a leader: no MigrateAuthors runs here.
+	for i, name := range []string{"Чехов", "Гоголь", "Толстой"} {
+		id := fmt.Sprint(i + 1)
+		engine.SetAuthor(id, entity.Author{Id: id, Name: name})
+	}
+	authors := &countingAuthors{Engine: engine}
+	uc := usecase.New(engine, engine, authors, engine, engine)
+	created, err := uc.CreateAuthor(context.Background(), entity.Author{Name: "Пушкин"})
+	if err != nil || created.Id != "4" || authors.attempts != 1 {
+		t.Errorf("Expected id 4 at the first try, got %+v %v after %d tries", created, err, authors.attempts)
+	}
+
+	// Authors created through another node after the usecase started.
+	for id := 5; id <= 40; id++ {
+		engine.SetAuthor(fmt.Sprint(id), entity.Author{Id: fmt.Sprint(id), Name: fmt.Sprint("Author ", id)})
+	}
+	authors.attempts = 0
+	created, err = uc.CreateAuthor(context.Background(), entity.Author{Name: "Бунин"})
+	if err != nil || created.Id != "41" || authors.attempts != 2 {
+		t.Errorf("Expected id 41 after one resync, got %+v %v after %d tries", created, err, authors.attempts)
+	}
+}
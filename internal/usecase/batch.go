@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const maxBatchOps = 1000
+
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("op %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+func (uc *usecase) Batch(ctx context.Context, ops []entity.BatchOp) ([]entity.BatchResult, error) {
+	if len(ops) == 0 || len(ops) > maxBatchOps {
+		return nil, fmt.Errorf("%w: batch must contain between 1 and %d ops", ErrValidation, maxBatchOps)
+	}
+	for i := range ops {
+		if err := uc.prepareBatchOp(&ops[i]); err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	actor := ActorFrom(ctx).Name
+	before := make([]entity.Quote, len(ops))
+	results := make([]entity.BatchResult, len(ops))
+	var authors []entity.Author
+	uc.createMutex.Lock()
+	defer uc.createMutex.Unlock()
+	uc.authorsMutex.Lock()
+	defer uc.authorsMutex.Unlock()
+	err := uc.repo.Update(func(tx repo.Tx) error {
+		now := time.Now().UTC()
+		authors = authors[:0]
+		for i, op := range ops {
+			results[i] = entity.BatchResult{Kind: op.Kind, Id: op.Id}
+			if op.Kind != entity.BatchDelete && op.Quote.AuthorId == "" {
+				author, created := uc.txAuthor(tx, op.Quote.Author, now)
+				if created {
+					authors = append(authors, author)
+				}
+				op.Quote.AuthorId = author.Id
+				op.Quote.Author = author.Name
+			}
+			if op.Kind == entity.BatchCreate {
+				value := op.Quote
+				value.Id = strconv.FormatInt(uc.keyCounter.Add(1), 10)
+				for _, taken := tx.Get(value.Id); taken; _, taken = tx.Get(value.Id) {
+					value.Id = strconv.FormatInt(uc.keyCounter.Add(1), 10)
+				}
+				value.CreatedAt = now
+				value.UpdatedAt = now
+				value.UpdatedBy = actor
+				value = tx.Set(value.Id, value)
+				results[i].Id = value.Id
+				results[i].Quote = &value
+				continue
+			}
+			current, ok := tx.Get(op.Id)
+			if !ok {
+				return &BatchError{Index: i, Err: ErrNotFound}
+			}
+			if op.Version != 0 && current.Version != op.Version {
+				return &BatchError{Index: i, Err: ErrPreconditionFailed}
+			}
+			before[i] = current
+			if op.Kind == entity.BatchDelete {
+				tx.SoftDel(op.Id, now)
+				continue
+			}
+			value := op.Quote
+			value.Id = op.Id
+			value.CreatedAt = current.CreatedAt
+			value.UpdatedAt = now
+			value.UpdatedBy = actor
+			value = tx.Set(op.Id, value)
+			results[i].Quote = &value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	for i := range authors {
+		uc.recordAuthor(ctx, entity.AuditAuthorCreate, authors[i].Id, nil, &authors[i])
+	}
+	for i, res := range results {
+		switch res.Kind {
+		case entity.BatchCreate:
+			uc.revise(entity.Quote{}, *res.Quote)
+			uc.record(ctx, entity.AuditCreate, res.Id, nil, res.Quote)
+		case entity.BatchUpdate:
+			uc.revise(before[i], *res.Quote)
+			uc.record(ctx, entity.AuditUpdate, res.Id, &before[i], res.Quote)
+		case entity.BatchDelete:
+			uc.record(ctx, entity.AuditDelete, res.Id, &before[i], nil)
+		}
+	}
+	return results, nil
+}
+
+// prepareBatchOp validates op and resolves its author without creating
+// one: a missing author is created by the batch's own transaction, so a
+// batch that fails leaves no authors behind.
+func (uc *usecase) prepareBatchOp(op *entity.BatchOp) error {
+	switch op.Kind {
+	case entity.BatchCreate:
+	case entity.BatchUpdate, entity.BatchDelete:
+		if op.Id == "" {
+			return fmt.Errorf("%w: id is required for %s", ErrValidation, op.Kind)
+		}
+		if op.Kind == entity.BatchDelete {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrValidation, op.Kind)
+	}
+	value, err := normalizeQuote(op.Quote)
+	if err != nil {
+		return err
+	}
+	op.Quote, err = uc.matchAuthor(value)
+	return err
+}
@@ -0,0 +1,66 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	uc := newUsecase(t,
+		entity.Quote{Author: "A", Phrase: "Q1"},
+		entity.Quote{Author: "B", Phrase: "Q2"},
+	)
+
+	results, err := uc.Batch(ctx, []entity.BatchOp{
+		{Kind: entity.BatchCreate, Quote: entity.Quote{Author: "C", Phrase: "Q3"}},
+		{Kind: entity.BatchUpdate, Id: "1", Quote: entity.Quote{Author: "A", Phrase: "Q1 edited"}},
+		{Kind: entity.BatchDelete, Id: "2"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 3 || results[0].Id != "3" || results[1].Quote.Phrase != "Q1 edited" || results[2].Quote != nil {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	if _, err := uc.Get("2"); err == nil {
+		t.Error("Deleted quote is still readable")
+	}
+	if author, err := uc.GetAuthor(results[0].Quote.AuthorId); err != nil || author.Name != "C" {
+		t.Errorf("Batch did not create the author of its quote: %+v, %v", author, err)
+	}
+
+	first, _ := uc.Get("1")
+	_, err = uc.Batch(ctx, []entity.BatchOp{
+		{Kind: entity.BatchCreate, Quote: entity.Quote{Author: "D", Phrase: "Q4"}},
+		{Kind: entity.BatchDelete, Id: "1"},
+		{Kind: entity.BatchUpdate, Id: "2", Quote: entity.Quote{Author: "B", Phrase: "Q2"}},
+	})
+	var batchErr *usecase.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 2 || !errors.Is(err, usecase.ErrNotFound) {
+		t.Fatalf("Expected not found at op 2, got %v", err)
+	}
+	if after, err := uc.Get("1"); err != nil || after.Version != first.Version {
+		t.Error("Failed batch changed a quote")
+	}
+	if all, _ := uc.GetAll(); len(all) != 2 {
+		t.Errorf("Failed batch created a quote: %+v", all)
+	}
+	authors, _ := uc.Authors()
+	for _, author := range authors {
+		if author.Name == "D" {
+			t.Errorf("Failed batch created an author: %+v", author)
+		}
+	}
+
+	_, err = uc.Batch(ctx, []entity.BatchOp{{Kind: "upsert"}})
+	if !errors.As(err, &batchErr) || batchErr.Index != 0 || !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error at op 0, got %v", err)
+	}
+}
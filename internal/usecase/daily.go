@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	defaultDailyWindow = 30
+)
+
+func (uc *usecase) Daily(date time.Time) (entity.Quote, error) {
+	day := date.Format(dateLayout)
+	now := uc.now()
+	key, pinned, err := found(uc.pins.GetPin(day))
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	if pinned {
+		quote, ok, err := found(uc.repo.Get(key))
+		if err != nil {
+			return entity.Quote{}, err
+		}
+		if ok && quote.Visible(now) {
+			return quote, nil
+		}
+	}
+	corpus, later, err := uc.dailyCorpus(date)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	var order []entity.Quote
+	if n := int64(len(corpus)); n > 0 {
+		cycle, pos := floorDiv(civilDays(date), n)
+		order = dailyOrder(corpus, cycle, uc.dailyWindow)
+		order = append(order[pos:], order[:pos]...)
+	}
+	// The day's pick only moves on if it was deleted or hidden since the
+	// day began; quotes that were not shown then are the last resort.
+	for _, quote := range append(order, later...) {
+		current, ok, err := found(uc.repo.Get(quote.Id))
+		if err != nil {
+			return entity.Quote{}, err
+		}
+		if ok && current.Visible(now) {
+			return current, nil
+		}
+	}
+	return entity.Quote{}, ErrNotFound
+}
+
+// dailyCorpus splits the quotes into those shown when the day began in
+// date's location and the rest, in id order. Only the first set decides
+// the day's order, so quotes added, deleted or rescheduled during the day
+// do not change it.
+func (uc *usecase) dailyCorpus(date time.Time) (corpus, later []entity.Quote, err error) {
+	y, m, d := date.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+	quotes, err := uc.allQuotes()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, quote := range quotes {
+		switch {
+		case !quote.DeletedAt.IsZero() && quote.DeletedAt.Before(start):
+		case quote.CreatedAt.Before(start) && quote.Visible(start):
+			corpus = append(corpus, quote)
+		default:
+			later = append(later, quote)
+		}
+	}
+	slices.SortFunc(later, func(a, b entity.Quote) int { return compareIds(a.Id, b.Id) })
+	return corpus, later, nil
+}
+
+func (uc *usecase) PinDaily(ctx context.Context, date time.Time, key string) error {
+	if _, err := uc.Get(key); err != nil {
+		return err
+	}
+	day := date.Format(dateLayout)
+	previous, err := uc.pins.GetPin(day)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return err
+	}
+	if err := uc.pins.SetPin(day, key); err != nil {
+		return storageError(err)
+	}
+	uc.recordValue(ctx, entity.AuditPin, day, previous, key)
+	return nil
+}
+
+func (uc *usecase) UnpinDaily(ctx context.Context, date time.Time) error {
+	day := date.Format(dateLayout)
+	previous, err := uc.pins.GetPin(day)
+	if err == nil {
+		err = uc.pins.DelPin(day)
+	}
+	if errors.Is(err, repo.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return storageError(err)
+	}
+	uc.recordValue(ctx, entity.AuditUnpin, day, previous, "")
+	return nil
+}
+
+func (uc *usecase) DailyPins() ([]entity.DailyPin, error) {
+	pins, err := uc.pins.Pins()
+	if err != nil {
+		return nil, err
+	}
+	var res []entity.DailyPin
+	for date, key := range pins {
+		res = append(res, entity.DailyPin{Date: date, QuoteId: key})
+	}
+	slices.SortFunc(res, func(a, b entity.DailyPin) int { return cmp.Compare(a.Date, b.Date) })
+	return res, nil
+}
+
+// dailyOrder returns the order in which quotes are shown during a cycle of
+// len(quotes) days. Each cycle is a seeded permutation, so nothing repeats
+// inside a cycle. Quotes shown during the last window days of the previous
+// cycle are moved out of the first window days of this one, which keeps the
+// guarantee across the boundary while the corpus has at least 3*window quotes:
+// only then does the tail of every cycle stay untouched by the move.
+func dailyOrder(quotes []entity.Quote, cycle int64, window int) []entity.Quote {
+	current := rawDailyOrder(quotes, cycle)
+	if window <= 0 || len(current) < 3*window {
+		return current
+	}
+	previous := rawDailyOrder(quotes, cycle-1)
+	recent := make(map[string]struct{}, window)
+	for _, quote := range previous[len(previous)-window:] {
+		recent[quote.Id] = struct{}{}
+	}
+	head := make([]entity.Quote, 0, window)
+	var moved []entity.Quote
+	for _, quote := range current[:window] {
+		if _, ok := recent[quote.Id]; ok {
+			moved = append(moved, quote)
+		} else {
+			head = append(head, quote)
+		}
+	}
+	res := make([]entity.Quote, 0, len(current))
+	res = append(res, head...)
+	var later []entity.Quote
+	for _, quote := range current[window:] {
+		if _, ok := recent[quote.Id]; !ok && len(res) < window {
+			res = append(res, quote)
+		} else {
+			later = append(later, quote)
+		}
+	}
+	res = append(res, moved...)
+	return append(res, later...)
+}
+
+func rawDailyOrder(quotes []entity.Quote, cycle int64) []entity.Quote {
+	type scored struct {
+		score uint64
+		quote entity.Quote
+	}
+	items := make([]scored, len(quotes))
+	for i, quote := range quotes {
+		items[i] = scored{score: dailyScore(cycle, quote.Id), quote: quote}
+	}
+	slices.SortFunc(items, func(a, b scored) int {
+		if c := cmp.Compare(a.score, b.score); c != 0 {
+			return c
+		}
+		return compareIds(a.quote.Id, b.quote.Id)
+	})
+	res := make([]entity.Quote, len(items))
+	for i, item := range items {
+		res[i] = item.quote
+	}
+	return res
+}
+
+func dailyScore(cycle int64, key string) uint64 {
+	hash := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(cycle))
+	hash.Write(buf[:])
+	hash.Write([]byte(key))
+	state := hash.Sum64()
+	return splitmix64(&state)
+}
+
+func civilDays(date time.Time) int64 {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+func floorDiv(a, b int64) (int64, int64) {
+	q, r := a/b, a%b
+	if r < 0 {
+		q, r = q-1, r+b
+	}
+	return q, r
+}
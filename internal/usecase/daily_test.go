@@ -0,0 +1,118 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+func TestDaily(t *testing.T) {
+	t.Parallel()
+
+	var quotes []entity.Quote
+	for i := range 100 {
+		quotes = append(quotes, entity.Quote{Author: "Author", Phrase: fmt.Sprintf("Quote %d", i)})
+	}
+	uc := newUsecase(t, quotes...)
+	other := newUsecase(t, quotes...)
+	// Days before the quotes were created have nothing to show.
+	start := time.Now().UTC().AddDate(0, 0, 1)
+
+	t.Run("deterministic", func(t *testing.T) {
+		t.Parallel()
+		for i := range 50 {
+			date := start.AddDate(0, 0, i)
+			a, _ := uc.Daily(date)
+			b, _ := other.Daily(date)
+			if a.Id != b.Id {
+				t.Fatalf("Instances disagree on %s: %s != %s", date.Format(time.DateOnly), a.Id, b.Id)
+			}
+		}
+	})
+
+	t.Run("no repeats within window", func(t *testing.T) {
+		t.Parallel()
+		lastSeen := make(map[string]int)
+		for i := range 500 {
+			q, err := uc.Daily(start.AddDate(0, 0, i))
+			if err != nil {
+				t.Fatal("Expected a quote")
+			}
+			if prev, ok := lastSeen[q.Id]; ok && i-prev <= 30 {
+				t.Fatalf("Quote %s repeated after %d days", q.Id, i-prev)
+			}
+			lastSeen[q.Id] = i
+		}
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		t.Parallel()
+		uc := newUsecase(t, quotes...)
+		date := time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)
+		if err := uc.PinDaily(context.Background(), date, "42"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if q, _ := uc.Daily(date); q.Id != "42" {
+			t.Errorf("Expected pinned quote, got %s", q.Id)
+		}
+		if err := uc.PinDaily(context.Background(), date, "missing"); err == nil {
+			t.Error("Expected error pinning missing quote")
+		}
+		if pins, _ := uc.DailyPins(); len(pins) != 1 || pins[0].Date != "2026-03-08" {
+			t.Errorf("Unexpected pins: %+v", pins)
+		}
+		if err := uc.UnpinDaily(context.Background(), date); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := uc.UnpinDaily(context.Background(), date); err == nil {
+			t.Error("Expected error removing missing pin")
+		}
+	})
+
+	t.Run("stable during the day", func(t *testing.T) {
+		t.Parallel()
+		repo, uc := newEngineUsecase(t)
+		yesterday := time.Now().UTC().AddDate(0, 0, -1)
+		for i := range 50 {
+			key := fmt.Sprint(i + 1)
+			if _, err := repo.SetIfAbsent(key, entity.Quote{Id: key, Author: "Author", Phrase: "Quote " + key, CreatedAt: yesterday}); err != nil {
+				t.Fatalf("Failed to seed %s: %v", key, err)
+			}
+		}
+		today := time.Now().UTC()
+		pick, err := uc.Daily(today)
+		if err != nil {
+			t.Fatal("Expected a quote")
+		}
+		for i := range 10 {
+			if _, err := uc.Set(context.Background(), entity.Quote{Author: "Author", Phrase: fmt.Sprintf("New %d", i)}); err != nil {
+				t.Fatalf("Failed to add: %v", err)
+			}
+		}
+		other := "1"
+		if pick.Id == other {
+			other = "2"
+		}
+		if err := uc.Delete(context.Background(), other, 0); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		if got, _ := uc.Daily(today); got.Id != pick.Id {
+			t.Errorf("Daily quote moved from %s to %s after the corpus changed", pick.Id, got.Id)
+		}
+
+		if err := uc.Delete(context.Background(), pick.Id, 0); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		next, err := uc.Daily(today)
+		if err != nil || next.Id == pick.Id {
+			t.Fatalf("Expected another quote once the pick is deleted, got %+v", next)
+		}
+		if id, _ := strconv.Atoi(next.Id); id > 50 {
+			t.Errorf("Expected a quote from the start of the day, got %s", next.Id)
+		}
+	})
+}
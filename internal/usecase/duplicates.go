@@ -0,0 +1,391 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	shingleSize            = 3
+	minHashBands           = 16
+	minHashRows            = 4
+	minHashSize            = minHashBands * minHashRows
+	nearDuplicateThreshold = 0.8
+)
+
+var minHashSeeds = func() [minHashSize][2]uint64 {
+	var seeds [minHashSize][2]uint64
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		seeds[i][0] = splitmix64(&state) | 1
+		seeds[i][1] = splitmix64(&state)
+	}
+	return seeds
+}()
+
+type signature [minHashSize]uint64
+
+type fingerprint struct {
+	exact     [sha256.Size]byte
+	signature signature
+}
+
+// DuplicateError is returned by SetUnique with the quote the new one
+// duplicates.
+type DuplicateError struct {
+	Existing entity.Quote
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate of quote %s", e.Existing.Id)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// FindDuplicate looks up a quote by the same author with the same or a
+// near-identical text. Only quotes sharing an index bucket with value are
+// compared.
+func (uc *usecase) FindDuplicate(value entity.Quote) (entity.Quote, bool, error) {
+	candidate := newFingerprint(value)
+	author, err := uc.authorKey(value)
+	if err != nil {
+		return entity.Quote{}, false, err
+	}
+	best, bestScore := entity.Quote{}, 0.0
+	for _, key := range uc.duplicates.candidates(candidate) {
+		quote, err := uc.repo.Get(key)
+		if errors.Is(err, repo.ErrNotFound) {
+			uc.duplicates.remove(key)
+			continue
+		}
+		if err != nil {
+			return entity.Quote{}, false, err
+		}
+		existing := uc.duplicates.add(quote)
+		name, err := uc.authorKey(quote)
+		if err != nil {
+			return entity.Quote{}, false, err
+		}
+		if name != author {
+			continue
+		}
+		if existing.exact == candidate.exact {
+			return quote, true, nil
+		}
+		if score := similarity(existing.signature, candidate.signature); score > bestScore {
+			best, bestScore = quote, score
+		}
+	}
+	return best, bestScore >= nearDuplicateThreshold, nil
+}
+
+// authorKey is the normalized canonical name of the quote's author.
+func (uc *usecase) authorKey(quote entity.Quote) (string, error) {
+	name := quote.Author
+	if quote.AuthorId != "" {
+		author, err := uc.authors.GetAuthor(quote.AuthorId)
+		if err == nil {
+			name = author.Name
+		} else if !errors.Is(err, repo.ErrNotFound) {
+			return "", err
+		}
+	}
+	canonical, err := uc.canonicalAuthor(name)
+	if err != nil {
+		return "", err
+	}
+	return entity.NormalizeAuthor(canonical), nil
+}
+
+// Duplicates groups quotes the way FindDuplicate matches them: by the same
+// author with the same or a near-identical text.
+func (uc *usecase) Duplicates() ([][]entity.Quote, error) {
+	quotes, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(quotes, func(a, b entity.Quote) int { return compareIds(a.Id, b.Id) })
+	prints := make([]fingerprint, len(quotes))
+	authors := make([]string, len(quotes))
+	for i, quote := range quotes {
+		prints[i] = newFingerprint(quote)
+		if authors[i], err = uc.authorKey(quote); err != nil {
+			return nil, err
+		}
+	}
+
+	parent := make([]int, len(prints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	buckets := make(map[string][]int)
+	for i, fp := range prints {
+		exact := fmt.Sprintf("exact:%x", fp.exact)
+		buckets[exact] = append(buckets[exact], i)
+		for band := range minHashBands {
+			key := fmt.Sprintf("%d:%v", band, fp.signature[band*minHashRows:(band+1)*minHashRows])
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+	for _, bucket := range buckets {
+		for i := range bucket {
+			for _, j := range bucket[i+1:] {
+				if authors[bucket[i]] != authors[j] {
+					continue
+				}
+				a, b := prints[bucket[i]], prints[j]
+				if a.exact == b.exact || similarity(a.signature, b.signature) >= nearDuplicateThreshold {
+					parent[find(j)] = find(bucket[i])
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]entity.Quote)
+	var roots []int
+	for i, quote := range quotes {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], quote)
+	}
+	var res [][]entity.Quote
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			res = append(res, groups[root])
+		}
+	}
+	return res, nil
+}
+
+func (uc *usecase) Merge(ctx context.Context, canonical string, duplicates []string) (entity.Quote, error) {
+	quote, err := uc.Get(canonical)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	for _, key := range duplicates {
+		if key == canonical {
+			return entity.Quote{}, fmt.Errorf("%w: cannot merge quote %s into itself", ErrInvalidMerge, key)
+		}
+		if _, err := uc.Get(key); err != nil {
+			return entity.Quote{}, err
+		}
+	}
+	now := time.Now().UTC()
+	for _, key := range duplicates {
+		before, err := uc.repo.Get(key)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return entity.Quote{}, err
+		}
+		err = uc.repo.SoftDel(key, now)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return entity.Quote{}, storageError(err)
+		}
+		uc.record(ctx, entity.AuditDelete, key, &before, nil)
+	}
+	return quote, nil
+}
+
+// duplicateIndex buckets quote fingerprints by their exact hash and by
+// MinHash band. Like the expirer it is not told about deletes: a candidate
+// is checked against the stored quote and dropped once it is gone.
+type duplicateIndex struct {
+	mutex   sync.Mutex
+	prints  map[string]fingerprint
+	buckets map[bucket]map[string]struct{}
+}
+
+// bucket is a band of a signature, or the exact hash for band -1.
+type bucket struct {
+	band int
+	hash uint64
+}
+
+func newDuplicateIndex() *duplicateIndex {
+	return &duplicateIndex{
+		prints:  make(map[string]fingerprint),
+		buckets: make(map[bucket]map[string]struct{}),
+	}
+}
+
+// add indexes quote, replacing what was indexed under its id, and returns
+// its fingerprint.
+func (d *duplicateIndex) add(quote entity.Quote) fingerprint {
+	fp := newFingerprint(quote)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if old, ok := d.prints[quote.Id]; ok {
+		if old == fp {
+			return fp
+		}
+		d.unlink(quote.Id, old)
+	}
+	d.prints[quote.Id] = fp
+	for _, b := range fp.buckets() {
+		if d.buckets[b] == nil {
+			d.buckets[b] = make(map[string]struct{})
+		}
+		d.buckets[b][quote.Id] = struct{}{}
+	}
+	return fp
+}
+
+func (d *duplicateIndex) remove(key string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if fp, ok := d.prints[key]; ok {
+		d.unlink(key, fp)
+		delete(d.prints, key)
+	}
+}
+
+func (d *duplicateIndex) unlink(key string, fp fingerprint) {
+	for _, b := range fp.buckets() {
+		delete(d.buckets[b], key)
+		if len(d.buckets[b]) == 0 {
+			delete(d.buckets, b)
+		}
+	}
+}
+
+// candidates lists the ids sharing a bucket with fp, in id order.
+func (d *duplicateIndex) candidates(fp fingerprint) []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	seen := make(map[string]struct{})
+	for _, b := range fp.buckets() {
+		for key := range d.buckets[b] {
+			seen[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareIds)
+	return keys
+}
+
+func (fp fingerprint) buckets() []bucket {
+	res := make([]bucket, 0, minHashBands+1)
+	res = append(res, bucket{band: -1, hash: binary.BigEndian.Uint64(fp.exact[:])})
+	for band := range minHashBands {
+		hash := fnv.New64a()
+		for _, v := range fp.signature[band*minHashRows : (band+1)*minHashRows] {
+			hash.Write(binary.BigEndian.AppendUint64(nil, v))
+		}
+		res = append(res, bucket{band: band, hash: hash.Sum64()})
+	}
+	return res
+}
+
+func newFingerprint(quote entity.Quote) fingerprint {
+	text := normalizeText(quote.Phrase)
+	return fingerprint{
+		exact:     sha256.Sum256([]byte(text)),
+		signature: minHash(text),
+	}
+}
+
+func normalizeText(text string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == 'ё':
+			r = 'е'
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+		default:
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func minHash(text string) signature {
+	var sig signature
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	runes := []rune(text)
+	if len(runes) < shingleSize {
+		updateSignature(&sig, text)
+		return sig
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		updateSignature(&sig, string(runes[i:i+shingleSize]))
+	}
+	return sig
+}
+
+func updateSignature(sig *signature, shingle string) {
+	hash := fnv.New64a()
+	hash.Write([]byte(shingle))
+	base := hash.Sum64()
+	for i, seed := range minHashSeeds {
+		if v := base*seed[0] + seed[1]; v < sig[i] {
+			sig[i] = v
+		}
+	}
+}
+
+func similarity(a, b signature) float64 {
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / minHashSize
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func compareIds(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,147 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func newUsecase(t *testing.T, quotes ...entity.Quote) usecase.Usecase {
+	t.Helper()
+	_, uc := newEngineUsecase(t)
+	for _, q := range quotes {
+		if _, err := uc.Set(context.Background(), q); err != nil {
+			t.Fatalf("Failed to set quote: %v", err)
+		}
+	}
+	return uc
+}
+
+func newEngineUsecase(t *testing.T) (*storage.Engine, interface {
+	usecase.Usecase
+	PurgeTrash(retention time.Duration) (int, error)
+}) {
+	t.Helper()
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	return repo, usecase.New(repo, repo, repo, repo, repo)
+}
+
+func TestFindDuplicate(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t, entity.Quote{Author: "Лев Толстой", Phrase: "Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива по-своему."})
+
+	t.Run("punctuation and case", func(t *testing.T) {
+		t.Parallel()
+		existing, ok, _ := uc.FindDuplicate(entity.Quote{Author: "лев толстой", Phrase: "все счастливые семьи похожи друг на друга — каждая несчастливая семья несчастлива по своему"})
+		if !ok || existing.Id != "1" {
+			t.Errorf("Expected duplicate of 1, got %+v %v", existing, ok)
+		}
+	})
+
+	t.Run("near duplicate", func(t *testing.T) {
+		t.Parallel()
+		_, ok, _ := uc.FindDuplicate(entity.Quote{Author: "Лев Толстой", Phrase: "Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива посвоему!!"})
+		if !ok {
+			t.Error("Expected near duplicate")
+		}
+	})
+
+	t.Run("different quote", func(t *testing.T) {
+		t.Parallel()
+		if existing, ok, _ := uc.FindDuplicate(entity.Quote{Author: "Лев Толстой", Phrase: "Счастье — это когда тебя понимают."}); ok {
+			t.Errorf("Unexpected duplicate: %+v", existing)
+		}
+	})
+
+	t.Run("different author", func(t *testing.T) {
+		t.Parallel()
+		if existing, ok, _ := uc.FindDuplicate(entity.Quote{Author: "Фёдор Достоевский", Phrase: "Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива по-своему."}); ok {
+			t.Errorf("Quote by another author reported as duplicate: %+v", existing)
+		}
+	})
+}
+
+func TestSetUnique(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t, entity.Quote{Author: "Pushkin", Phrase: "I loved you once, and still, perhaps, love's yearning"})
+	_, err := uc.SetUnique(context.Background(), entity.Quote{Author: "Pushkin", Phrase: "I loved you once; and still, perhaps, love’s yearning."})
+	var duplicate *usecase.DuplicateError
+	if !errors.As(err, &duplicate) || duplicate.Existing.Id != "1" {
+		t.Fatalf("Expected a duplicate of 1, got %v", err)
+	}
+	if !errors.Is(err, usecase.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+
+	// A quote whose text changed is matched by its new text only.
+	if _, err := uc.Update(context.Background(), "1", entity.Quote{Author: "Pushkin", Phrase: "The bronze horseman"}, 0); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if _, err := uc.SetUnique(context.Background(), entity.Quote{Author: "Pushkin", Phrase: "I loved you once"}); err != nil {
+		t.Errorf("Expected the old text to be free, got %v", err)
+	}
+	if _, err := uc.SetUnique(context.Background(), entity.Quote{Author: "Pushkin", Phrase: "The Bronze Horseman!"}); !errors.Is(err, usecase.ErrDuplicate) {
+		t.Errorf("Expected the new text to be a duplicate, got %v", err)
+	}
+
+	// Deleted quotes are not duplicates.
+	if err := uc.Delete(context.Background(), "1", 0); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := uc.SetUnique(context.Background(), entity.Quote{Author: "Pushkin", Phrase: "The bronze horseman"}); err != nil {
+		t.Errorf("Expected a deleted quote not to block, got %v", err)
+	}
+}
+
+func TestDuplicatesAndMerge(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t,
+		entity.Quote{Author: "Pushkin", Phrase: "I loved you once, and still, perhaps, love's yearning"},
+		entity.Quote{Author: "Other", Phrase: "Something completely unrelated"},
+		entity.Quote{Author: "Pushkin A.", Phrase: "I loved you once; and still, perhaps, love’s yearning."},
+		// The same text by another author is not a duplicate, as on write.
+		entity.Quote{Author: "Other", Phrase: "I loved you once, and still, perhaps, love's yearning"},
+	)
+	if groups, _ := uc.Duplicates(); len(groups) != 0 {
+		t.Fatalf("Expected no groups across authors, got %+v", groups)
+	}
+	if err := uc.SetAuthorAlias(context.Background(), "Pushkin A.", "Pushkin"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+
+	groups, _ := uc.Duplicates()
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("Expected one group of two, got %+v", groups)
+	}
+	if groups[0][0].Id != "1" || groups[0][1].Id != "3" {
+		t.Errorf("Unexpected group: %+v", groups[0])
+	}
+
+	if _, err := uc.Merge(context.Background(), "1", []string{"1"}); err == nil {
+		t.Error("Expected error when merging quote into itself")
+	}
+	merged, err := uc.Merge(context.Background(), "1", []string{"3"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if merged.Id != "1" {
+		t.Errorf("Unexpected canonical quote: %+v", merged)
+	}
+	if _, err := uc.Get("3"); err == nil {
+		t.Error("Duplicate must be removed")
+	}
+	if groups, _ := uc.Duplicates(); len(groups) != 0 {
+		t.Error("Expected no duplicates after merge")
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+// expiryRetry is how long a quote that could not be read or moved to the
+// trash waits before the expirer looks at it again.
+const expiryRetry = time.Minute
+
+func WithClock(now func() time.Time) Option {
+	return func(uc *usecase) {
+		uc.now = now
+	}
+}
+
+// expirer queues quotes by ExpireAt. Entries are not removed when a quote
+// changes: a due entry is checked against the stored quote, so a moved or
+// cleared ExpireAt only leaves a stale entry behind.
+type expirer struct {
+	mutex sync.Mutex
+	queue expiryQueue
+	wake  chan struct{}
+}
+
+type expiry struct {
+	at  time.Time
+	key string
+}
+
+func newExpirer() *expirer {
+	return &expirer{wake: make(chan struct{}, 1)}
+}
+
+func (e *expirer) schedule(key string, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	e.mutex.Lock()
+	heap.Push(&e.queue, expiry{at: at, key: key})
+	first := e.queue[0] == expiry{at: at, key: key}
+	e.mutex.Unlock()
+	if first {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *expirer) due(now time.Time) []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var keys []string
+	for len(e.queue) > 0 && !e.queue[0].at.After(now) {
+		keys = append(keys, heap.Pop(&e.queue).(expiry).key)
+	}
+	return keys
+}
+
+func (e *expirer) next() (time.Time, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if len(e.queue) == 0 {
+		return time.Time{}, false
+	}
+	return e.queue[0].at, true
+}
+
+type expiryQueue []expiry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x any) {
+	*q = append(*q, x.(expiry))
+}
+
+func (q *expiryQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// ScheduleExpiry queues a quote written behind the usecase's back, by
+// another node of a cluster, to expire at its ExpireAt.
+func (uc *usecase) ScheduleExpiry(quote entity.Quote) {
+	if quote.ExpireAt.After(uc.now()) {
+		uc.expirer.schedule(quote.Id, quote.ExpireAt)
+	}
+}
+
+// NextExpiry returns when the earliest scheduled quote expires.
+func (uc *usecase) NextExpiry() (time.Time, bool) {
+	return uc.expirer.next()
+}
+
+// ExpiryRescheduled fires when a quote is scheduled to expire before every
+// other one, so a caller waiting for NextExpiry can move its timer up.
+func (uc *usecase) ExpiryRescheduled() <-chan struct{} {
+	return uc.expirer.wake
+}
+
+// ExpireQuotes moves quotes past their ExpireAt to the trash, where they
+// are purged with the rest once the retention period is over.
+func (uc *usecase) ExpireQuotes() int {
+	now := uc.now()
+	expired := 0
+	for _, key := range uc.expirer.due(now) {
+		quote, ok, err := found(uc.repo.Get(key))
+		if err != nil {
+			log.Printf("failed to read expiring quote %s: %v", key, err)
+			uc.expirer.schedule(key, now.Add(expiryRetry))
+			continue
+		}
+		if !ok || quote.ExpireAt.IsZero() || quote.ExpireAt.After(now) {
+			continue
+		}
+		err = uc.repo.SoftDelIfVersion(key, quote.Version, now.UTC())
+		switch {
+		case errors.Is(err, repo.ErrVersionMismatch), errors.Is(err, repo.ErrNotFound):
+			// A concurrent write has already rescheduled or removed the quote.
+			continue
+		case err != nil:
+			log.Printf("failed to expire quote %s: %v", key, err)
+			uc.expirer.schedule(key, now.Add(expiryRetry))
+			continue
+		}
+		uc.record(context.Background(), entity.AuditExpire, key, &quote, nil)
+		expired++
+	}
+	if expired > 0 {
+		log.Printf("expired %d quotes", expired)
+	}
+	return expired
+}
@@ -0,0 +1,233 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type expiringUsecase interface {
+	usecase.Usecase
+	ExpireQuotes() int
+	NextExpiry() (time.Time, bool)
+	ExpiryRescheduled() <-chan struct{}
+}
+
+func newClockUsecase(t *testing.T, clock *fakeClock) (*storage.Engine, expiringUsecase) {
+	t.Helper()
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	return repo, usecase.New(repo, repo, repo, repo, repo, usecase.WithClock(clock.Now))
+}
+
+func ids(quotes []entity.Quote, err error) map[string]bool {
+	if err != nil {
+		return nil
+	}
+	res := make(map[string]bool, len(quotes))
+	for _, quote := range quotes {
+		res[quote.Id] = true
+	}
+	return res
+}
+
+func TestPublishingWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	_, uc := newClockUsecase(t, clock)
+	ctx := context.Background()
+
+	always, err := uc.Set(ctx, entity.Quote{Author: "A", Phrase: "Always"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	campaign, err := uc.Set(ctx, entity.Quote{
+		Author:    "A",
+		Phrase:    "Campaign",
+		PublishAt: start.Add(time.Hour),
+		ExpireAt:  start.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	check := func(want bool) {
+		t.Helper()
+		if got := ids(uc.GetAll())[campaign.Id]; got != want {
+			t.Errorf("GetAll: campaign visible = %v, want %v", got, want)
+		}
+		if got := ids(uc.GetAllByAuthor("A"))[campaign.Id]; got != want {
+			t.Errorf("GetAllByAuthor: campaign visible = %v, want %v", got, want)
+		}
+		if got := ids(uc.AuthorQuotes(always.AuthorId))[campaign.Id]; got != want {
+			t.Errorf("AuthorQuotes: campaign visible = %v, want %v", got, want)
+		}
+		found, _ := uc.Find(entity.Filter{Phrase: "campaign"})
+		if got := len(found) == 1; got != want {
+			t.Errorf("Find: campaign visible = %v, want %v", got, want)
+		}
+		picked := make(map[string]bool)
+		for range 50 {
+			if quote, err := uc.Random(entity.RandomOptions{}); err == nil {
+				picked[quote.Id] = true
+			}
+		}
+		if picked[campaign.Id] && !want {
+			t.Error("Random picked a hidden quote")
+		}
+	}
+
+	check(false)
+	if _, err := uc.Get(campaign.Id); err != nil {
+		t.Error("Scheduled quote is not reachable by id")
+	}
+	clock.Advance(time.Hour)
+	check(true)
+	clock.Advance(time.Hour)
+	check(false)
+	if !ids(uc.GetAll())[always.Id] {
+		t.Error("Quote without a window was hidden")
+	}
+}
+
+func TestExpireQuotes(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	repo, uc := newClockUsecase(t, clock)
+	ctx := context.Background()
+
+	first, _ := uc.Set(ctx, entity.Quote{Author: "A", Phrase: "First", ExpireAt: start.Add(2 * time.Hour)})
+	second, _ := uc.Set(ctx, entity.Quote{Author: "A", Phrase: "Second", ExpireAt: start.Add(3 * time.Hour)})
+	uc.Set(ctx, entity.Quote{Author: "A", Phrase: "Forever"})
+
+	select {
+	case <-uc.ExpiryRescheduled():
+	default:
+		t.Error("Scheduling the earliest expiry did not wake the expirer")
+	}
+	if at, ok := uc.NextExpiry(); !ok || !at.Equal(first.ExpireAt) {
+		t.Errorf("Unexpected next expiry: %v %v", at, ok)
+	}
+
+	// Moving the expiry leaves a stale entry that must not archive the quote.
+	first, err := uc.Update(ctx, first.Id, entity.Quote{Author: "A", Phrase: "First", ExpireAt: start.Add(4 * time.Hour)}, first.Version)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	clock.Advance(2 * time.Hour)
+	if n := uc.ExpireQuotes(); n != 0 {
+		t.Errorf("Expired %d quotes before their time", n)
+	}
+
+	clock.Advance(time.Hour)
+	if n := uc.ExpireQuotes(); n != 1 {
+		t.Errorf("Expected 1 expired quote, got %d", n)
+	}
+	trash, _ := uc.Trash()
+	if len(trash) != 1 || trash[0].Id != second.Id {
+		t.Errorf("Expired quote was not archived: %+v", trash)
+	}
+
+	clock.Advance(time.Hour)
+	if n := uc.ExpireQuotes(); n != 1 {
+		t.Errorf("Expected 1 expired quote, got %d", n)
+	}
+	if all, _ := uc.GetAll(); len(all) != 1 {
+		t.Errorf("Expected only the quote without expiry, got %+v", all)
+	}
+	if _, ok := uc.NextExpiry(); ok {
+		t.Error("Expiry queue is not empty")
+	}
+
+	// A restarted usecase picks up pending expiries from the repository.
+	late, _ := uc.Set(ctx, entity.Quote{Author: "A", Phrase: "Late", ExpireAt: clock.Now().Add(time.Hour)})
+	restarted := usecase.New(repo, repo, repo, repo, repo, usecase.WithClock(clock.Now))
+	if at, ok := restarted.NextExpiry(); !ok || !at.Equal(late.ExpireAt) {
+		t.Errorf("Pending expiry was not restored: %v %v", at, ok)
+	}
+}
+
+// flakyEngine fails the first failures conditional soft deletes.
+type flakyEngine struct {
+	*storage.Engine
+	failures int
+}
+
+func (e *flakyEngine) SoftDelIfVersion(key string, expected uint64, at time.Time) error {
+	if e.failures > 0 {
+		e.failures--
+		return repo.ErrUnavailable
+	}
+	return e.Engine.SoftDelIfVersion(key, expected, at)
+}
+
+func TestExpireRetriesFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	flaky := &flakyEngine{Engine: engine, failures: 1}
+	uc := usecase.New(flaky, engine, engine, engine, engine, usecase.WithClock(clock.Now))
+	quote, _ := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: "Q", ExpireAt: start.Add(time.Hour)})
+
+	clock.Advance(time.Hour)
+	if n := uc.ExpireQuotes(); n != 0 {
+		t.Fatalf("Expected the failed write to expire nothing, got %d", n)
+	}
+	if at, ok := uc.NextExpiry(); !ok || !at.After(clock.Now()) {
+		t.Fatalf("Failed expiry was not rescheduled: %v %v", at, ok)
+	}
+	clock.Advance(time.Hour)
+	if n := uc.ExpireQuotes(); n != 1 {
+		t.Errorf("Expected the retry to expire the quote, got %d", n)
+	}
+	if _, err := uc.Get(quote.Id); err == nil {
+		t.Error("Quote is still live after the retry")
+	}
+}
+
+func TestPublishingWindowValidation(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+	_, uc := newClockUsecase(t, clock)
+	at := clock.Now().Add(time.Hour)
+	_, err := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: "Q", PublishAt: at, ExpireAt: at})
+	if !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const (
+	defaultCursorTTL = 5 * time.Minute
+	maxPageSize      = 500
+)
+
+func WithCursorTTL(ttl time.Duration) Option {
+	return func(uc *usecase) {
+		uc.cursorTTL = ttl
+	}
+}
+
+func (uc *usecase) List(filter entity.Filter, cursor string, limit int) (entity.Page, error) {
+	if limit < 1 || limit > maxPageSize {
+		return entity.Page{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, maxPageSize)
+	}
+	filter, err := uc.prepareFilter(filter)
+	if err != nil {
+		return entity.Page{}, err
+	}
+	var (
+		seq   uint64
+		after string
+	)
+	// Later pages filter by the visibility of the first one, so a quote
+	// whose window opens or closes mid-listing is neither skipped nor shown
+	// on one page only.
+	if cursor == "" {
+		seq = uc.repo.Snapshot(uc.cursorTTL)
+	} else if seq, filter.VisibleAt, after, err = decodeCursor(cursor); err != nil {
+		return entity.Page{}, err
+	}
+	quotes, err := uc.repo.ListAt(seq, filter, after, limit+1)
+	if errors.Is(err, repo.ErrSnapshotExpired) {
+		return entity.Page{}, ErrCursorExpired
+	}
+	if err != nil {
+		return entity.Page{}, err
+	}
+	page := entity.Page{Quotes: quotes}
+	if len(quotes) > limit {
+		page.Quotes = quotes[:limit]
+		page.Next = encodeCursor(seq, filter.VisibleAt, quotes[limit-1].Id)
+	}
+	return page, nil
+}
+
+func encodeCursor(seq uint64, at time.Time, after string) string {
+	data := strconv.FormatUint(seq, 10) + ":" + strconv.FormatInt(at.UnixNano(), 10) + ":" + after
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+func decodeCursor(cursor string) (uint64, time.Time, string, error) {
+	malformed := fmt.Errorf("%w: malformed cursor", ErrValidation)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, time.Time{}, "", malformed
+	}
+	parts := strings.SplitN(string(data), ":", 3)
+	if len(parts) != 3 {
+		return 0, time.Time{}, "", malformed
+	}
+	seq, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, "", malformed
+	}
+	at, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, "", malformed
+	}
+	return seq, time.Unix(0, at).UTC(), parts[2], nil
+}
@@ -0,0 +1,97 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	uc := newUsecase(t)
+	for _, phrase := range []string{"Q1", "Q2", "Q3", "Q4", "Q5"} {
+		if _, err := uc.Set(ctx, entity.Quote{Author: "A", Phrase: phrase}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	page, err := uc.List(entity.Filter{}, "", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	seen := []string{page.Quotes[0].Id, page.Quotes[1].Id}
+
+	// Writes between pages must neither shift nor leak into the listing.
+	if err := uc.Delete(ctx, "1", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.Set(ctx, entity.Quote{Author: "A", Phrase: "Q6"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for page.Next != "" {
+		if page, err = uc.List(entity.Filter{}, page.Next, 2); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, quote := range page.Quotes {
+			seen = append(seen, quote.Id)
+		}
+	}
+	want := []string{"1", "2", "3", "4", "5"}
+	if len(seen) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, seen)
+		}
+	}
+
+	if _, err := uc.List(entity.Filter{}, "not a cursor", 2); !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+	if _, err := uc.List(entity.Filter{}, "", 0); !errors.Is(err, usecase.ErrValidation) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
+
+func TestListVisibility(t *testing.T) {
+	t.Parallel()
+
+	repo, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := usecase.New(repo, repo, repo, repo, repo, usecase.WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	windows := []entity.Quote{
+		{Author: "A", Phrase: "Q1"},
+		{Author: "A", Phrase: "Q2"},
+		{Author: "A", Phrase: "Q3", ExpireAt: now.Add(time.Minute)},
+		{Author: "A", Phrase: "Q4", PublishAt: now.Add(time.Minute)},
+	}
+	for _, quote := range windows {
+		if _, err := uc.Set(ctx, quote); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	page, err := uc.List(entity.Filter{}, "", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if page, err = uc.List(entity.Filter{}, page.Next, 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(page.Quotes) != 1 || page.Quotes[0].Id != "3" || page.Next != "" {
+		t.Errorf("Expected the second page as of the first one, got %+v", page)
+	}
+}
@@ -1,42 +1,221 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
 )
 
-func (uc *usecase) Delete(key string) error {
-	_, ok := uc.repo.Get(key)
-	if !ok {
-		return errors.New("no rows affected")
+func (uc *usecase) Delete(ctx context.Context, key string, version uint64) error {
+	current, err := uc.Get(key)
+	if err != nil {
+		return err
 	}
-	uc.repo.Del(key)
+	if version != 0 && current.Version != version {
+		return ErrPreconditionFailed
+	}
+	if err := uc.repo.SoftDelIfVersion(key, current.Version, time.Now().UTC()); err != nil {
+		return conditionalError(err, version)
+	}
+	uc.record(ctx, entity.AuditDelete, key, &current, nil)
 	return nil
 }
 
-func (uc *usecase) Random() (entity.Quote, bool) {
-	val, ok := uc.repo.GetRandom()
-	if !ok {
+func (uc *usecase) Get(key string) (entity.Quote, error) {
+	quote, err := uc.repo.Get(key)
+	if errors.Is(err, repo.ErrNotFound) {
+		return entity.Quote{}, ErrNotFound
+	}
+	return quote, err
+}
+
+func (uc *usecase) Random(opts entity.RandomOptions) (entity.Quote, error) {
+	filter, err := uc.prepareFilter(opts.Filter)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	opts.Filter = filter
+	val, err := uc.repo.GetRandom(opts)
+	if errors.Is(err, repo.ErrNotFound) {
 		log.Println("database is empty")
+		return entity.Quote{}, ErrNotFound
 	}
-	return val, ok
+	return val, err
 }
 
-func (uc *usecase) GetAllByAuthor(author string) ([]entity.Quote, bool) {
-	return uc.repo.GetAllByAuthor(author)
+func (uc *usecase) GetAllByAuthor(author string) ([]entity.Quote, error) {
+	variants, err := uc.authorVariants(author)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.Find(entity.Filter{Author: author, AuthorVariants: variants, VisibleAt: uc.now()})
+}
+
+func (uc *usecase) Find(filter entity.Filter) ([]entity.Quote, error) {
+	filter, err := uc.prepareFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.Find(filter)
 }
 
-func (uc *usecase) GetAll() []entity.Quote {
-	return uc.repo.GetAll()
+func (uc *usecase) prepareFilter(filter entity.Filter) (entity.Filter, error) {
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return filter, err
+	}
+	filter.Tags = tags
+	filter.VisibleAt = uc.now()
+	if filter.Author != "" && filter.AuthorMatch != entity.AuthorPrefix {
+		if filter.AuthorVariants, err = uc.authorVariants(filter.Author); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
 }
 
-func (uc *usecase) Set(value entity.Quote) {
-	key := uc.keyCounter.Add(1)
-	keyStr := strconv.Itoa(int(key))
-	value.Id = keyStr
-	uc.repo.Set(keyStr, value)
+func (uc *usecase) GetAll() ([]entity.Quote, error) {
+	quotes, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return visible(quotes, uc.now()), nil
+}
+
+// visible drops quotes outside their publishing window at the given time.
+func visible(quotes []entity.Quote, at time.Time) []entity.Quote {
+	return slices.DeleteFunc(quotes, func(quote entity.Quote) bool { return !quote.Visible(at) })
+}
+
+func (uc *usecase) Set(ctx context.Context, value entity.Quote) (entity.Quote, error) {
+	return uc.create(ctx, value, false)
+}
+
+// SetUnique creates value unless the same author already has a quote
+// with the same or a near-identical text, which it returns in a
+// DuplicateError instead.
+func (uc *usecase) SetUnique(ctx context.Context, value entity.Quote) (entity.Quote, error) {
+	return uc.create(ctx, value, true)
+}
+
+func (uc *usecase) create(ctx context.Context, value entity.Quote, unique bool) (entity.Quote, error) {
+	value, err := normalizeQuote(value)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	value, err = uc.resolveAuthor(ctx, value)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	value.CreatedAt = time.Now().UTC()
+	value.UpdatedAt = value.CreatedAt
+	value.UpdatedBy = ActorFrom(ctx).Name
+	// Creates are serialized so no quote lands between the duplicate check
+	// and the insert.
+	uc.createMutex.Lock()
+	defer uc.createMutex.Unlock()
+	if unique {
+		existing, found, err := uc.FindDuplicate(value)
+		if err != nil {
+			return entity.Quote{}, err
+		}
+		if found {
+			return entity.Quote{}, &DuplicateError{Existing: existing}
+		}
+	}
+	for {
+		value.Id = strconv.FormatInt(uc.keyCounter.Add(1), 10)
+		created, err := uc.repo.SetIfAbsent(value.Id, value)
+		if err == nil {
+			value = created
+			break
+		}
+		if !errors.Is(err, repo.ErrExists) {
+			return entity.Quote{}, storageError(err)
+		}
+		log.Printf("key %s is already taken, retrying", value.Id)
+		uc.syncKeyCounter()
+	}
+	uc.revise(entity.Quote{}, value)
+	uc.record(ctx, entity.AuditCreate, value.Id, nil, &value)
 	log.Println("successeful set value")
+	return value, nil
+}
+
+func (uc *usecase) Update(ctx context.Context, key string, value entity.Quote, version uint64) (entity.Quote, error) {
+	return uc.update(ctx, entity.AuditUpdate, key, value, version)
+}
+
+func (uc *usecase) update(ctx context.Context, operation, key string, value entity.Quote, version uint64) (entity.Quote, error) {
+	value, err := normalizeQuote(value)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	current, err := uc.Get(key)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	if version != 0 && current.Version != version {
+		return entity.Quote{}, ErrPreconditionFailed
+	}
+	value, err = uc.resolveAuthor(ctx, value)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	value.Id = key
+	value.CreatedAt = current.CreatedAt
+	value.UpdatedAt = time.Now().UTC()
+	value.UpdatedBy = ActorFrom(ctx).Name
+	value, err = uc.repo.CompareAndSwap(key, current.Version, value)
+	if err != nil {
+		return entity.Quote{}, conditionalError(err, version)
+	}
+	uc.revise(current, value)
+	uc.record(ctx, operation, key, &current, &value)
+	return value, nil
+}
+
+// conditionalError maps a failed conditional write. A version the client
+// asked for is a failed precondition, one we read ourselves lost a race.
+func conditionalError(err error, version uint64) error {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, repo.ErrVersionMismatch) && version != 0:
+		return ErrPreconditionFailed
+	case errors.Is(err, repo.ErrVersionMismatch):
+		return fmt.Errorf("%w: quote was modified concurrently", ErrConflict)
+	}
+	return storageError(err)
+}
+
+// storageError maps a write the storage refused because it is full or
+// could not reach the rest of the cluster.
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, repo.ErrInsufficientStorage):
+		return fmt.Errorf("%w: %v", ErrInsufficientStorage, err)
+	case errors.Is(err, repo.ErrUnavailable):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// found reports a read that failed with ErrNotFound as ok == false.
+func found[T any](value T, err error) (T, bool, error) {
+	if errors.Is(err, repo.ErrNotFound) {
+		return value, false, nil
+	}
+	return value, err == nil, err
+}
+
+func (uc *usecase) Version() (uint64, time.Time) {
+	return uc.repo.Version()
 }
@@ -0,0 +1,124 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rich quote", func(t *testing.T) {
+		t.Parallel()
+		uc := newUsecase(t)
+		quote, err := uc.Set(context.Background(), entity.Quote{
+			Author:   " Seneca ",
+			Phrase:   "Luck is what happens when preparation meets opportunity.",
+			Source:   &entity.Source{Kind: "Book", Title: "Letters", Year: 65},
+			Tags:     []string{"Luck", "luck", " life "},
+			Language: "en",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if quote.Author != "Seneca" || quote.Source.Kind != entity.SourceBook {
+			t.Errorf("Quote was not normalized: %+v", quote)
+		}
+		if len(quote.Tags) != 2 || quote.Tags[0] != "luck" || quote.Tags[1] != "life" {
+			t.Errorf("Unexpected tags: %v", quote.Tags)
+		}
+		if quote.CreatedAt.IsZero() || !quote.CreatedAt.Equal(quote.UpdatedAt) {
+			t.Errorf("Unexpected timestamps: %v %v", quote.CreatedAt, quote.UpdatedAt)
+		}
+
+		updated, err := uc.Update(context.Background(), quote.Id, entity.Quote{Author: "Seneca", Phrase: "Updated"}, quote.Version)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !updated.CreatedAt.Equal(quote.CreatedAt) || updated.UpdatedAt.Before(quote.UpdatedAt) {
+			t.Errorf("Unexpected timestamps after update: %+v", updated)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		t.Parallel()
+		uc := newUsecase(t)
+		invalid := []entity.Quote{
+			{Author: "", Phrase: "Text"},
+			{Author: "Author", Phrase: "  "},
+			{Author: "Author", Phrase: "Text", Language: "English"},
+			{Author: "Author", Phrase: "Text", Tags: []string{""}},
+			{Author: "Author", Phrase: "Text", Source: &entity.Source{Kind: "tweet"}},
+			{Author: "Author", Phrase: "Text", Source: &entity.Source{URL: "ftp://example.com"}},
+			{Author: "Author", Phrase: "Text", Source: &entity.Source{Year: 99999}},
+		}
+		for _, quote := range invalid {
+			if _, err := uc.Set(context.Background(), quote); !errors.Is(err, usecase.ErrValidation) {
+				t.Errorf("Expected validation error for %+v, got %v", quote, err)
+			}
+		}
+		if all, _ := uc.GetAll(); len(all) != 0 {
+			t.Error("Invalid quotes must not be stored")
+		}
+	})
+
+	t.Run("storage full", func(t *testing.T) {
+		t.Parallel()
+		repo, err := storage.NewEngine(storage.WithLimits(storage.Limits{MaxEntries: 1}))
+		if err != nil {
+			t.Fatalf("Failed to init engine: %v", err)
+		}
+		uc := usecase.New(repo, repo, repo, repo, repo)
+		if _, err := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: "First"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: "Second"}); !errors.Is(err, usecase.ErrInsufficientStorage) {
+			t.Errorf("Expected insufficient storage, got %v", err)
+		}
+	})
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t, entity.Quote{Author: "A", Phrase: "Original"})
+	const writers = 16
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.Update(context.Background(), "1", entity.Quote{Author: "A", Phrase: strconv.Itoa(i)}, 0)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case !errors.Is(err, usecase.ErrConflict):
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded.Load() == 0 {
+		t.Fatal("No update succeeded")
+	}
+	revs, _ := uc.Revisions("1")
+	if len(revs) != int(succeeded.Load())+1 {
+		t.Errorf("Expected %d revisions, got %d", succeeded.Load()+1, len(revs))
+	}
+
+	quote, _ := uc.Get("1")
+	if err := uc.Delete(context.Background(), "1", quote.Version+1); !errors.Is(err, usecase.ErrPreconditionFailed) {
+		t.Errorf("Expected precondition failure, got %v", err)
+	}
+}
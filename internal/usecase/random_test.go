@@ -0,0 +1,93 @@
+package usecase_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+
+	var quotes []entity.Quote
+	for i := range 20 {
+		author := "Odd"
+		if i%2 == 0 {
+			author = "Even"
+		}
+		quotes = append(quotes, entity.Quote{Author: author, Phrase: fmt.Sprintf("Quote %d", i), Tags: []string{author}})
+	}
+	quotes = append(quotes, entity.Quote{Author: "Rated", Phrase: "Best quote", Rating: 5})
+	uc := newUsecase(t, quotes...)
+
+	t.Run("seed is reproducible", func(t *testing.T) {
+		t.Parallel()
+		first, err := uc.Random(entity.RandomOptions{Seed: 7, Seeded: true})
+		if err != nil {
+			t.Fatalf("Expected a quote, got %v", err)
+		}
+		for range 10 {
+			if q, _ := uc.Random(entity.RandomOptions{Seed: 7, Seeded: true}); q.Id != first.Id {
+				t.Fatalf("Seeded pick changed: %s != %s", q.Id, first.Id)
+			}
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+		for seed := range uint64(20) {
+			q, err := uc.Random(entity.RandomOptions{Filter: entity.Filter{Author: "even"}, Seed: seed, Seeded: true})
+			if err != nil || q.Author != "Even" {
+				t.Fatalf("Unexpected pick: %+v", q)
+			}
+			q, err = uc.Random(entity.RandomOptions{Filter: entity.Filter{Tags: []string{"odd"}}, Seed: seed, Seeded: true})
+			if err != nil || q.Author != "Odd" {
+				t.Fatalf("Unexpected tag pick: %+v", q)
+			}
+		}
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		t.Parallel()
+		var exclude []string
+		for range 10 {
+			q, err := uc.Random(entity.RandomOptions{Filter: entity.Filter{Author: "Even"}, Exclude: exclude})
+			if err != nil {
+				t.Fatalf("Expected a quote, got %v", err)
+			}
+			for _, id := range exclude {
+				if id == q.Id {
+					t.Fatalf("Excluded quote %s returned", id)
+				}
+			}
+			exclude = append(exclude, q.Id)
+		}
+		if _, err := uc.Random(entity.RandomOptions{Filter: entity.Filter{Author: "Even"}, Exclude: exclude}); !errors.Is(err, usecase.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when all are excluded, got %v", err)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		t.Parallel()
+		if _, err := uc.Random(entity.RandomOptions{Filter: entity.Filter{Tags: []string{" "}}}); !errors.Is(err, usecase.ErrValidation) {
+			t.Errorf("Expected ErrValidation, got %v", err)
+		}
+	})
+
+	t.Run("weight", func(t *testing.T) {
+		t.Parallel()
+		hits := 0
+		for seed := range uint64(2000) {
+			if q, _ := uc.Random(entity.RandomOptions{Weight: entity.WeightRating, Seed: seed, Seeded: true}); q.Author == "Rated" {
+				hits++
+			}
+		}
+		// The rated quote has weight 6 out of a total of 26.
+		if hits < 350 || hits > 600 {
+			t.Errorf("Unexpected number of weighted hits: %d", hits)
+		}
+	})
+}
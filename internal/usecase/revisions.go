@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+const systemActor = "system"
+
+// maxRewriteAttempts bounds how often a rewrite rereads a quote that was
+// changed between reading and writing it.
+const maxRewriteAttempts = 5
+
+func (uc *usecase) Revisions(key string) ([]entity.Revision, error) {
+	revs, err := uc.revisions.Revisions(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		return nil, ErrNotFound
+	}
+	return revs, nil
+}
+
+func (uc *usecase) Revision(key string, n int) (entity.Revision, error) {
+	rev, err := uc.revisions.GetRevision(key, n)
+	if errors.Is(err, repo.ErrNotFound) {
+		return entity.Revision{}, ErrNotFound
+	}
+	return rev, err
+}
+
+func (uc *usecase) Revert(ctx context.Context, key string, n int, version uint64) (entity.Quote, error) {
+	rev, err := uc.Revision(key, n)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	value := rev.Quote
+	_, known, err := uc.authorById(value.AuthorId)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	if known {
+		value.Author = ""
+	} else {
+		value.AuthorId = ""
+	}
+	return uc.update(ctx, entity.AuditRevert, key, value, version)
+}
+
+// rewrite applies fn to the stored quote and writes the result only if the
+// quote is still at the version fn saw; otherwise it reads the quote again,
+// so a concurrent edit is never overwritten with stale fields. fn returns
+// false to leave the quote alone. ok is false when nothing was written,
+// also when the quote is gone.
+func (uc *usecase) rewrite(key string, fn func(quote entity.Quote) (entity.Quote, bool, error)) (before, after entity.Quote, ok bool, err error) {
+	for range maxRewriteAttempts {
+		before, ok, err := found(uc.repo.Get(key))
+		if err != nil || !ok {
+			return before, entity.Quote{}, false, err
+		}
+		after, change, err := fn(before)
+		if err != nil || !change {
+			return before, entity.Quote{}, false, err
+		}
+		after, err = uc.repo.CompareAndSwap(key, before.Version, after)
+		if errors.Is(err, repo.ErrVersionMismatch) || errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return before, entity.Quote{}, false, storageError(err)
+		}
+		uc.revise(before, after)
+		return before, after, true, nil
+	}
+	return entity.Quote{}, entity.Quote{}, false, fmt.Errorf("%w: quote %s kept changing while being rewritten", ErrConflict, key)
+}
+
+func (uc *usecase) revise(before, after entity.Quote) {
+	if !after.ExpireAt.Equal(before.ExpireAt) {
+		uc.expirer.schedule(after.Id, after.ExpireAt)
+	}
+	uc.duplicates.add(after)
+	changes := diffQuotes(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	_, err := uc.revisions.AppendRevision(after.Id, entity.Revision{
+		Actor:   after.UpdatedBy,
+		At:      after.UpdatedAt,
+		Changes: changes,
+		Quote:   after,
+	})
+	if err != nil {
+		// The quote itself is committed; failing the request would make
+		// the client retry a write that already happened.
+		log.Printf("failed to record revision of quote %s: %v", after.Id, err)
+	}
+}
+
+func diffQuotes(before, after entity.Quote) []entity.FieldChange {
+	var changes []entity.FieldChange
+	add := func(field string, old, new any) {
+		changes = append(changes, entity.FieldChange{Field: field, Old: old, New: new})
+	}
+	if before.AuthorId != after.AuthorId {
+		add("author_id", before.AuthorId, after.AuthorId)
+	}
+	if before.Author != after.Author {
+		add("author", before.Author, after.Author)
+	}
+	if before.Phrase != after.Phrase {
+		add("quote", before.Phrase, after.Phrase)
+	}
+	if !sameSource(before.Source, after.Source) {
+		add("source", before.Source, after.Source)
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		add("tags", before.Tags, after.Tags)
+	}
+	if before.Language != after.Language {
+		add("language", before.Language, after.Language)
+	}
+	if before.Rating != after.Rating {
+		add("rating", before.Rating, after.Rating)
+	}
+	if !before.PublishAt.Equal(after.PublishAt) {
+		add("publish_at", before.PublishAt, after.PublishAt)
+	}
+	if !before.ExpireAt.Equal(after.ExpireAt) {
+		add("expire_at", before.ExpireAt, after.ExpireAt)
+	}
+	return changes
+}
+
+func sameSource(a, b *entity.Source) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
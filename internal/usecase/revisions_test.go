@@ -0,0 +1,190 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+	storage "github.com/paxaf/BrandScoutTest/internal/repo/engine"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestRevisions(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t)
+	as := func(name string) context.Context {
+		return usecase.WithActor(context.Background(), entity.Actor{Name: name})
+	}
+	quote, err := uc.Set(as("alice"), entity.Quote{Author: "Seneca", Phrase: "Original"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	edited, err := uc.Update(as("bob"), quote.Id, entity.Quote{Author: "Seneca", Phrase: "Edited", Tags: []string{"life"}}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	revs, err := uc.Revisions(quote.Id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(revs) != 2 || revs[0].Number != 1 || revs[1].Number != 2 {
+		t.Fatalf("Unexpected revisions: %+v", revs)
+	}
+	if revs[0].Actor != "alice" || revs[1].Actor != "bob" {
+		t.Errorf("Unexpected actors: %q %q", revs[0].Actor, revs[1].Actor)
+	}
+	if len(revs[1].Changes) != 2 || revs[1].Changes[0].Field != "quote" || revs[1].Changes[0].Old != "Original" {
+		t.Errorf("Unexpected changes: %+v", revs[1].Changes)
+	}
+
+	reverted, err := uc.Revert(as("carol"), quote.Id, 1, edited.Version)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reverted.Phrase != "Original" || len(reverted.Tags) != 0 || reverted.UpdatedBy != "carol" {
+		t.Errorf("Unexpected reverted quote: %+v", reverted)
+	}
+	rev, err := uc.Revision(quote.Id, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rev.Actor != "carol" || rev.Quote.Phrase != "Original" {
+		t.Errorf("Revert did not create a revision: %+v", rev)
+	}
+
+	if _, err := uc.Revert(as("carol"), quote.Id, 1, edited.Version); !errors.Is(err, usecase.ErrPreconditionFailed) {
+		t.Errorf("Expected precondition failure, got %v", err)
+	}
+	if _, err := uc.Revision(quote.Id, 9); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+	if _, err := uc.Revisions("missing"); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func TestRevisionsHistoryCap(t *testing.T) {
+	t.Parallel()
+
+	repo, err := storage.NewEngine(storage.WithHistoryCap(2))
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	uc := usecase.New(repo, repo, repo, repo, repo)
+	quote, err := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: "v1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, phrase := range []string{"v2", "v3", "v4"} {
+		if _, err := uc.Update(context.Background(), quote.Id, entity.Quote{Author: "A", Phrase: phrase}, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	revs, _ := uc.Revisions(quote.Id)
+	if len(revs) != 2 || revs[0].Number != 3 || revs[1].Number != 4 {
+		t.Errorf("Unexpected capped revisions: %+v", revs)
+	}
+	if _, err := uc.Revision(quote.Id, 1); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("Expected evicted revision, got %v", err)
+	}
+}
+
+// failingSwaps is an engine whose conditional writes fail, as a full disk
+// would.
+type failingSwaps struct {
+	*storage.Engine
+}
+
+func (failingSwaps) CompareAndSwap(string, uint64, entity.Quote) (entity.Quote, error) {
+	return entity.Quote{}, repo.ErrUnavailable
+}
+
+// racingSwaps is an engine where race runs right before every conditional
+// write, as a concurrent request would.
+type racingSwaps struct {
+	*storage.Engine
+	race func(key string)
+}
+
+func (s racingSwaps) CompareAndSwap(key string, expected uint64, value entity.Quote) (entity.Quote, error) {
+	s.race(key)
+	return s.Engine.CompareAndSwap(key, expected, value)
+}
+
+func TestFailedSave(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	store := failingSwaps{engine}
+	uc := usecase.New(store, store, store, store, store)
+	quote, err := uc.Set(context.Background(), entity.Quote{Author: "Seneca", Phrase: "Q", Tags: []string{"life"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := uc.RenameTag(context.Background(), "life", "vita"); !errors.Is(err, usecase.ErrUnavailable) {
+		t.Errorf("Expected unavailable, got %v", err)
+	}
+	if revs, _ := uc.Revisions(quote.Id); len(revs) != 1 {
+		t.Errorf("Failed write was recorded as a revision: %+v", revs)
+	}
+
+	if err := uc.Delete(context.Background(), quote.Id, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	engine.DelAuthor(quote.AuthorId)
+	if restored, err := uc.Restore(context.Background(), quote.Id); !errors.Is(err, usecase.ErrUnavailable) {
+		t.Errorf("Expected unavailable, got %+v %v", restored, err)
+	}
+}
+
+func TestRewriteRetries(t *testing.T) {
+	t.Parallel()
+
+	engine, err := storage.NewEngine()
+	if err != nil {
+		t.Fatalf("Failed to init engine: %v", err)
+	}
+	races := 1
+	store := racingSwaps{Engine: engine, race: func(key string) {
+		if races == 0 {
+			return
+		}
+		races--
+		current, _ := engine.Get(key)
+		current.Phrase = "Edited"
+		current.Tags = append(current.Tags, "stoic")
+		if _, err := engine.Set(key, current); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}}
+	uc := usecase.New(store, store, store, store, store)
+	quote, err := uc.Set(context.Background(), entity.Quote{Author: "Seneca", Phrase: "Q", Tags: []string{"life"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if updated, err := uc.RenameTag(context.Background(), "life", "vita"); err != nil || updated != 1 {
+		t.Fatalf("Unexpected rename: %d %v", updated, err)
+	}
+	current, _ := engine.Get(quote.Id)
+	if current.Phrase != "Edited" || !slices.Equal(current.Tags, []string{"vita", "stoic"}) {
+		t.Errorf("Rename overwrote a concurrent edit: %+v", current)
+	}
+
+	races = -1
+	if _, err := uc.RenameTag(context.Background(), "vita", "life"); !errors.Is(err, usecase.ErrConflict) {
+		t.Errorf("Expected conflict, got %v", err)
+	}
+	if current, _ := engine.Get(quote.Id); !slices.Contains(current.Tags, "vita") {
+		t.Errorf("Rename was applied despite the conflict: %+v", current)
+	}
+}
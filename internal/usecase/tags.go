@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+func (uc *usecase) Tags() ([]entity.TagCount, error) {
+	counts, err := uc.repo.TagCounts()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(counts, func(a, b entity.TagCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	return counts, nil
+}
+
+func (uc *usecase) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return uc.MergeTags(ctx, []string{from}, to)
+}
+
+func (uc *usecase) MergeTags(ctx context.Context, from []string, to string) (int, error) {
+	sources, err := normalizeTags(from)
+	if err != nil {
+		return 0, err
+	}
+	target, err := normalizeTags([]string{to})
+	if err != nil {
+		return 0, err
+	}
+	if len(sources) == 0 || len(target) == 0 {
+		return 0, fmt.Errorf("%w: source and target tags are required", ErrValidation)
+	}
+	quotes, err := uc.repo.GetAllByTags(sources, false)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, quote := range quotes {
+		before, after, ok, err := uc.rewrite(quote.Id, func(quote entity.Quote) (entity.Quote, bool, error) {
+			if !slices.ContainsFunc(quote.Tags, func(tag string) bool { return slices.Contains(sources, tag) }) {
+				return quote, false, nil
+			}
+			tags := make([]string, 0, len(quote.Tags))
+			for _, tag := range quote.Tags {
+				if slices.Contains(sources, tag) {
+					tag = target[0]
+				}
+				if !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			quote.Tags = tags
+			quote.UpdatedAt = time.Now().UTC()
+			quote.UpdatedBy = ActorFrom(ctx).Name
+			return quote, true, nil
+		})
+		if err != nil {
+			return updated, err
+		}
+		if ok {
+			uc.record(ctx, entity.AuditUpdate, after.Id, &before, &after)
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,63 @@
+package usecase_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t,
+		entity.Quote{Author: "A", Phrase: "Q1", Tags: []string{"Love", "life"}},
+		entity.Quote{Author: "B", Phrase: "Q2", Tags: []string{"love"}},
+		entity.Quote{Author: "C", Phrase: "Q3", Tags: []string{"war", "Life"}},
+		entity.Quote{Author: "D", Phrase: "Q4"},
+	)
+
+	if quotes, _ := uc.Find(entity.Filter{Tags: []string{"LOVE", "life"}}); len(quotes) != 1 || quotes[0].Id != "1" {
+		t.Errorf("Unexpected AND result: %+v", quotes)
+	}
+	if quotes, _ := uc.Find(entity.Filter{Tags: []string{"love", "war"}, AnyTag: true}); len(quotes) != 3 {
+		t.Errorf("Unexpected OR result: %+v", quotes)
+	}
+
+	counts, _ := uc.Tags()
+	want := []entity.TagCount{{Tag: "life", Count: 2}, {Tag: "love", Count: 2}, {Tag: "war", Count: 1}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("Unexpected counts: %+v", counts)
+	}
+
+	updated, err := uc.MergeTags(context.Background(), []string{"life", "war"}, "existence")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected 2 updated quotes, got %d", updated)
+	}
+	if quotes, _ := uc.Find(entity.Filter{Tags: []string{"war"}}); len(quotes) != 0 {
+		t.Errorf("Merged tag still indexed: %+v", quotes)
+	}
+	if q, _ := uc.Get("3"); !slices.Equal(q.Tags, []string{"existence"}) {
+		t.Errorf("Unexpected tags after merge: %v", q.Tags)
+	}
+
+	if _, err := uc.RenameTag(context.Background(), "love", "amor"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = []entity.TagCount{{Tag: "amor", Count: 2}, {Tag: "existence", Count: 2}}
+	if counts, _ := uc.Tags(); !slices.Equal(counts, want) {
+		t.Errorf("Unexpected counts after rename: %+v", counts)
+	}
+
+	if err := uc.Delete(context.Background(), "1", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = []entity.TagCount{{Tag: "amor", Count: 1}, {Tag: "existence", Count: 1}}
+	if counts, _ := uc.Tags(); !slices.Equal(counts, want) {
+		t.Errorf("Unexpected counts after delete: %+v", counts)
+	}
+}
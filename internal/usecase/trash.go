@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/repo"
+)
+
+func (uc *usecase) Trash() ([]entity.Quote, error) {
+	quotes, err := uc.repo.GetTrash()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(quotes, func(a, b entity.Quote) int { return b.DeletedAt.Compare(a.DeletedAt) })
+	return quotes, nil
+}
+
+func (uc *usecase) Restore(ctx context.Context, key string) (entity.Quote, error) {
+	quote, err := uc.repo.Restore(key)
+	if errors.Is(err, repo.ErrNotFound) {
+		return entity.Quote{}, ErrNotFound
+	}
+	if err != nil {
+		return entity.Quote{}, storageError(err)
+	}
+	_, known, err := uc.authorById(quote.AuthorId)
+	if err != nil {
+		return entity.Quote{}, err
+	}
+	if !known {
+		_, resolved, ok, err := uc.rewrite(key, func(quote entity.Quote) (entity.Quote, bool, error) {
+			if _, ok, err := uc.authorById(quote.AuthorId); err != nil || ok {
+				return quote, false, err
+			}
+			quote.AuthorId = ""
+			resolved, err := uc.resolveAuthor(ctx, quote)
+			resolved.UpdatedBy = systemActor
+			return resolved, err == nil, err
+		})
+		if err != nil {
+			return entity.Quote{}, err
+		}
+		if ok {
+			quote = resolved
+		}
+	}
+	if quote.ExpireAt.After(uc.now()) {
+		uc.expirer.schedule(key, quote.ExpireAt)
+	}
+	uc.record(ctx, entity.AuditRestore, key, nil, &quote)
+	return quote, nil
+}
+
+func (uc *usecase) Purge(ctx context.Context, key string, version uint64) error {
+	current, ok, err := found(uc.repo.Get(key))
+	if err != nil {
+		return err
+	}
+	if ok {
+		if version != 0 && current.Version != version {
+			return ErrPreconditionFailed
+		}
+		if err := uc.repo.DeleteIfVersion(key, current.Version); err != nil {
+			return conditionalError(err, version)
+		}
+		uc.record(ctx, entity.AuditPurge, key, &current, nil)
+		return nil
+	}
+	trash, err := uc.repo.GetTrash()
+	if err != nil {
+		return err
+	}
+	for _, quote := range trash {
+		if quote.Id == key {
+			if err := uc.repo.Del(key); err != nil {
+				return storageError(err)
+			}
+			uc.record(ctx, entity.AuditPurge, key, &quote, nil)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (uc *usecase) PurgeTrash(retention time.Duration) (int, error) {
+	purged, err := uc.repo.PurgeBefore(time.Now().Add(-retention))
+	if err != nil {
+		return 0, storageError(err)
+	}
+	for _, quote := range purged {
+		uc.record(context.Background(), entity.AuditPurge, quote.Id, &quote, nil)
+	}
+	if len(purged) > 0 {
+		log.Printf("purged %d quotes from trash", len(purged))
+	}
+	return len(purged), nil
+}
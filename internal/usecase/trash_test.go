@@ -0,0 +1,78 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+	"github.com/paxaf/BrandScoutTest/internal/usecase"
+)
+
+func TestSoftDelete(t *testing.T) {
+	t.Parallel()
+
+	uc := newUsecase(t,
+		entity.Quote{Author: "A", Phrase: "Q1", Tags: []string{"t"}},
+		entity.Quote{Author: "B", Phrase: "Q2"},
+	)
+
+	if err := uc.Delete(context.Background(), "1", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.Get("1"); err == nil {
+		t.Error("Deleted quote is still readable")
+	}
+	if quotes, _ := uc.Find(entity.Filter{Tags: []string{"t"}}); len(quotes) != 0 {
+		t.Error("Deleted quote is still indexed")
+	}
+	if all, _ := uc.GetAll(); len(all) != 1 {
+		t.Error("Deleted quote is still listed")
+	}
+	trash, _ := uc.Trash()
+	if len(trash) != 1 || trash[0].Id != "1" || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("Unexpected trash: %+v", trash)
+	}
+
+	restored, err := uc.Restore(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !restored.DeletedAt.IsZero() {
+		t.Error("Restored quote still marked deleted")
+	}
+	if quotes, _ := uc.Find(entity.Filter{Tags: []string{"t"}}); len(quotes) != 1 {
+		t.Error("Restored quote is not indexed")
+	}
+	if _, err := uc.Restore(context.Background(), "1"); !errors.Is(err, usecase.ErrNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	if err := uc.Purge(context.Background(), "2", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if trash, _ := uc.Trash(); len(trash) != 0 {
+		t.Error("Hard delete must bypass trash")
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	t.Parallel()
+
+	repo, uc := newEngineUsecase(t)
+	for _, phrase := range []string{"Q1", "Q2"} {
+		if _, err := uc.Set(context.Background(), entity.Quote{Author: "A", Phrase: phrase}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	repo.SoftDel("1", time.Now().Add(-48*time.Hour))
+	repo.SoftDel("2", time.Now())
+
+	if purged, err := uc.PurgeTrash(24 * time.Hour); err != nil || purged != 1 {
+		t.Errorf("Expected 1 purged quote, got %d %v", purged, err)
+	}
+	if trash, _ := uc.Trash(); len(trash) != 1 || trash[0].Id != "2" {
+		t.Errorf("Unexpected trash: %+v", trash)
+	}
+}
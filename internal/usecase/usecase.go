@@ -1,28 +1,155 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/paxaf/BrandScoutTest/internal/entity"
 	"github.com/paxaf/BrandScoutTest/internal/repo"
 )
 
+var (
+	ErrNotFound            = errors.New("quote not found")
+	ErrPreconditionFailed  = errors.New("quote version mismatch")
+	ErrInvalidMerge        = errors.New("invalid merge")
+	ErrValidation          = errors.New("invalid quote")
+	ErrAuthorNotFound      = errors.New("author not found")
+	ErrConflict            = errors.New("conflict")
+	ErrCursorExpired       = errors.New("cursor expired")
+	ErrInsufficientStorage = errors.New("insufficient storage")
+	ErrUnavailable         = errors.New("service unavailable")
+	ErrDuplicate           = errors.New("duplicate quote")
+)
+
 type Usecase interface {
-	Delete(key string) error
-	Random() (entity.Quote, bool)
-	GetAllByAuthor(author string) ([]entity.Quote, bool)
-	GetAll() []entity.Quote
-	Set(value entity.Quote)
+	Delete(ctx context.Context, key string, version uint64) error
+	Get(key string) (entity.Quote, error)
+	Random(opts entity.RandomOptions) (entity.Quote, error)
+	GetAllByAuthor(author string) ([]entity.Quote, error)
+	GetAll() ([]entity.Quote, error)
+	List(filter entity.Filter, cursor string, limit int) (entity.Page, error)
+	Set(ctx context.Context, value entity.Quote) (entity.Quote, error)
+	SetUnique(ctx context.Context, value entity.Quote) (entity.Quote, error)
+	Update(ctx context.Context, key string, value entity.Quote, version uint64) (entity.Quote, error)
+	Version() (uint64, time.Time)
+	FindDuplicate(value entity.Quote) (entity.Quote, bool, error)
+	Duplicates() ([][]entity.Quote, error)
+	Merge(ctx context.Context, canonical string, duplicates []string) (entity.Quote, error)
+	Find(filter entity.Filter) ([]entity.Quote, error)
+	Tags() ([]entity.TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+	MergeTags(ctx context.Context, from []string, to string) (int, error)
+	SetAuthorAlias(ctx context.Context, alias, canonical string) error
+	DeleteAuthorAlias(ctx context.Context, alias string) error
+	AuthorAliases() ([]entity.AuthorAlias, error)
+	CreateAuthor(ctx context.Context, value entity.Author) (entity.Author, error)
+	GetAuthor(key string) (entity.Author, error)
+	Authors() ([]entity.Author, error)
+	UpdateAuthor(ctx context.Context, key string, value entity.Author) (entity.Author, error)
+	DeleteAuthor(ctx context.Context, key string) error
+	AuthorQuotes(key string) ([]entity.Quote, error)
+	Daily(date time.Time) (entity.Quote, error)
+	PinDaily(ctx context.Context, date time.Time, key string) error
+	UnpinDaily(ctx context.Context, date time.Time) error
+	DailyPins() ([]entity.DailyPin, error)
+	Trash() ([]entity.Quote, error)
+	Restore(ctx context.Context, key string) (entity.Quote, error)
+	Purge(ctx context.Context, key string, version uint64) error
+	Revisions(key string) ([]entity.Revision, error)
+	Revision(key string, n int) (entity.Revision, error)
+	Revert(ctx context.Context, key string, n int, version uint64) (entity.Quote, error)
+	Audit(filter entity.AuditFilter) ([]entity.AuditEntry, error)
+	Batch(ctx context.Context, ops []entity.BatchOp) ([]entity.BatchResult, error)
 }
 
 type usecase struct {
-	repo       repo.Repository
-	keyCounter atomic.Int64
+	repo          repo.Repository
+	aliases       repo.AliasRepository
+	authors       repo.AuthorRepository
+	pins          repo.PinRepository
+	revisions     repo.RevisionRepository
+	audit         repo.AuditRepository
+	dailyWindow   int
+	cursorTTL     time.Duration
+	now           func() time.Time
+	expirer       *expirer
+	duplicates    *duplicateIndex
+	keyCounter    atomic.Int64
+	authorCounter atomic.Int64
+	authorsMutex  sync.Mutex
+	createMutex   sync.Mutex
+}
+
+type Option func(*usecase)
+
+func WithDailyWindow(days int) Option {
+	return func(uc *usecase) {
+		uc.dailyWindow = days
+	}
+}
+
+func New(repo repo.Repository, aliases repo.AliasRepository, authors repo.AuthorRepository, pins repo.PinRepository, revisions repo.RevisionRepository, opts ...Option) *usecase {
+	uc := &usecase{
+		repo:        repo,
+		aliases:     aliases,
+		authors:     authors,
+		pins:        pins,
+		revisions:   revisions,
+		dailyWindow: defaultDailyWindow,
+		cursorTTL:   defaultCursorTTL,
+		now:         time.Now,
+		expirer:     newExpirer(),
+		duplicates:  newDuplicateIndex(),
+		keyCounter:  atomic.Int64{},
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	uc.syncKeyCounter()
+	// Followers and cluster nodes skip MigrateAuthors, which syncs it too.
+	if err := uc.syncAuthorCounter(); err != nil {
+		log.Printf("failed to sync author counter: %v", err)
+	}
+	quotes, err := repo.GetAll()
+	if err != nil {
+		log.Printf("failed to index quotes: %v", err)
+	}
+	for _, quote := range quotes {
+		uc.expirer.schedule(quote.Id, quote.ExpireAt)
+		uc.duplicates.add(quote)
+	}
+	return uc
 }
 
-func New(repo repo.Repository) *usecase {
-	return &usecase{
-		repo:       repo,
-		keyCounter: atomic.Int64{},
+// syncKeyCounter moves the key counter past every key in the store. Keys
+// written elsewhere, by another node of a cluster, do not bump it. A key
+// it misses costs a retry of the create, which inserts only if absent.
+func (uc *usecase) syncKeyCounter() {
+	quotes, err := uc.allQuotes()
+	if err != nil {
+		log.Printf("failed to sync key counter: %v", err)
+	}
+	for _, quote := range quotes {
+		if id, err := strconv.ParseInt(quote.Id, 10, 64); err == nil && id > uc.keyCounter.Load() {
+			uc.keyCounter.Store(id)
+		}
+	}
+}
+
+// allQuotes returns the live quotes followed by those in the trash.
+func (uc *usecase) allQuotes() ([]entity.Quote, error) {
+	quotes, err := uc.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	trash, err := uc.repo.GetTrash()
+	if err != nil {
+		return nil, err
 	}
+	return append(quotes, trash...), nil
 }
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/paxaf/BrandScoutTest/internal/entity"
+)
+
+const (
+	maxAuthorLen = 200
+	maxPhraseLen = 5000
+	maxTags      = 20
+	maxTagLen    = 32
+	minYear      = -3000
+	maxBioLen    = 10000
+	maxRating    = 5
+)
+
+var (
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+	lifeDatePattern = regexp.MustCompile(`^-?\d{1,4}(-\d{2}(-\d{2})?)?$`)
+	sourceKinds     = []string{
+		entity.SourceBook,
+		entity.SourceSpeech,
+		entity.SourceURL,
+		entity.SourceFilm,
+		entity.SourceInterview,
+		entity.SourceOther,
+	}
+)
+
+func normalizeQuote(value entity.Quote) (entity.Quote, error) {
+	value.Author = strings.TrimSpace(value.Author)
+	value.Phrase = strings.TrimSpace(value.Phrase)
+	value.Language = strings.TrimSpace(value.Language)
+	switch {
+	case value.Author == "" && value.AuthorId == "":
+		return value, fmt.Errorf("%w: author is required", ErrValidation)
+	case utf8.RuneCountInString(value.Author) > maxAuthorLen:
+		return value, fmt.Errorf("%w: author is longer than %d characters", ErrValidation, maxAuthorLen)
+	case value.Phrase == "":
+		return value, fmt.Errorf("%w: quote is required", ErrValidation)
+	case utf8.RuneCountInString(value.Phrase) > maxPhraseLen:
+		return value, fmt.Errorf("%w: quote is longer than %d characters", ErrValidation, maxPhraseLen)
+	case value.Language != "" && !languagePattern.MatchString(value.Language):
+		return value, fmt.Errorf("%w: invalid language code %q", ErrValidation, value.Language)
+	case value.Rating < 0 || value.Rating > maxRating:
+		return value, fmt.Errorf("%w: rating must be between 0 and %d", ErrValidation, maxRating)
+	case !value.PublishAt.IsZero() && !value.ExpireAt.IsZero() && !value.ExpireAt.After(value.PublishAt):
+		return value, fmt.Errorf("%w: expire_at must be after publish_at", ErrValidation)
+	}
+	value.PublishAt = value.PublishAt.UTC()
+	value.ExpireAt = value.ExpireAt.UTC()
+
+	tags, err := normalizeTags(value.Tags)
+	if err != nil {
+		return value, err
+	}
+	value.Tags = tags
+
+	if value.Source != nil {
+		source, err := normalizeSource(*value.Source)
+		if err != nil {
+			return value, err
+		}
+		value.Source = &source
+	}
+	return value, nil
+}
+
+func normalizeAuthor(value entity.Author) (entity.Author, error) {
+	value.Name = strings.TrimSpace(value.Name)
+	value.Bio = strings.TrimSpace(value.Bio)
+	value.BirthDate = strings.TrimSpace(value.BirthDate)
+	value.DeathDate = strings.TrimSpace(value.DeathDate)
+	value.PhotoURL = strings.TrimSpace(value.PhotoURL)
+	switch {
+	case entity.NormalizeAuthor(value.Name) == "":
+		return value, fmt.Errorf("%w: author name is required", ErrValidation)
+	case utf8.RuneCountInString(value.Name) > maxAuthorLen:
+		return value, fmt.Errorf("%w: author name is longer than %d characters", ErrValidation, maxAuthorLen)
+	case utf8.RuneCountInString(value.Bio) > maxBioLen:
+		return value, fmt.Errorf("%w: biography is longer than %d characters", ErrValidation, maxBioLen)
+	case value.BirthDate != "" && !lifeDatePattern.MatchString(value.BirthDate):
+		return value, fmt.Errorf("%w: invalid birth date %q", ErrValidation, value.BirthDate)
+	case value.DeathDate != "" && !lifeDatePattern.MatchString(value.DeathDate):
+		return value, fmt.Errorf("%w: invalid death date %q", ErrValidation, value.DeathDate)
+	}
+	if value.PhotoURL != "" {
+		u, err := url.Parse(value.PhotoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return value, fmt.Errorf("%w: invalid photo url %q", ErrValidation, value.PhotoURL)
+		}
+	}
+	return value, nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			return nil, fmt.Errorf("%w: empty tag", ErrValidation)
+		case utf8.RuneCountInString(tag) > maxTagLen:
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrValidation, tag, maxTagLen)
+		}
+		if !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	if len(res) > maxTags {
+		return nil, fmt.Errorf("%w: more than %d tags", ErrValidation, maxTags)
+	}
+	return res, nil
+}
+
+func normalizeSource(source entity.Source) (entity.Source, error) {
+	source.Kind = strings.ToLower(strings.TrimSpace(source.Kind))
+	source.Title = strings.TrimSpace(source.Title)
+	source.URL = strings.TrimSpace(source.URL)
+	if source.Kind == "" {
+		source.Kind = entity.SourceOther
+		if source.URL != "" && source.Title == "" {
+			source.Kind = entity.SourceURL
+		}
+	}
+	if !slices.Contains(sourceKinds, source.Kind) {
+		return source, fmt.Errorf("%w: unknown source kind %q", ErrValidation, source.Kind)
+	}
+	if source.URL != "" {
+		u, err := url.Parse(source.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return source, fmt.Errorf("%w: invalid source url %q", ErrValidation, source.URL)
+		}
+	}
+	if source.Year != 0 && (source.Year < minYear || source.Year > time.Now().Year()) {
+		return source, fmt.Errorf("%w: invalid source year %d", ErrValidation, source.Year)
+	}
+	return source, nil
+}